@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/opacity/storage-node/jobs"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*commands run instead of the server when the binary is started with a subcommand.
+Badger locks its directory, so these only work while the node is stopped; use the
+admin endpoints to back up or restore a running node.*/
+var commands = map[string]func(args []string) error{
+	"kv-backup":         kvBackupCommand,
+	"kv-restore":        kvRestoreCommand,
+	"kv-backup-remote":  kvBackupRemoteCommand,
+	"kv-restore-remote": kvRestoreRemoteCommand,
+	"kv-backup-list":    kvBackupListCommand,
+	"reconcile":         reconcileCommand,
+}
+
+/*runCommand returns false if args don't name a subcommand*/
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+
+	if err := command(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		utils.CloseKvStore()
+		os.Exit(1)
+	}
+	utils.CloseKvStore()
+	return true
+}
+
+// kv-backup <file> [since]
+func kvBackupCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: kv-backup <file> [since]")
+	}
+
+	var since uint64
+	if len(args) == 2 {
+		var err error
+		if since, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	nextSince, err := utils.BackupKvStore(f, since)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("kv store backed up to %s, pass %d as since for the next incremental backup\n", args[0], nextSince)
+	return nil
+}
+
+// kv-restore <file>, into an empty store to get the exact state of the backup
+func kvRestoreCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: kv-restore <file>")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := utils.RestoreKvStore(f); err != nil {
+		return err
+	}
+	fmt.Printf("kv store restored from %s\n", args[0])
+	return nil
+}
+
+// kv-backup-remote
+func kvBackupRemoteCommand(args []string) error {
+	backup, err := utils.BackupKvStoreToBlobStore()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("kv store backed up to %s (%d bytes)\n", backup.Key, backup.Size)
+	return nil
+}
+
+// kv-restore-remote <key>
+func kvRestoreRemoteCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: kv-restore-remote <key>")
+	}
+
+	if err := utils.RestoreKvStoreFromBlobStore(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("kv store restored as of %s\n", args[0])
+	return nil
+}
+
+// kv-backup-list
+func kvBackupListCommand(args []string) error {
+	backups, err := utils.ListKvBackups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		kind := "incremental"
+		if backup.Full {
+			kind = "full"
+		}
+		fmt.Printf("%s\t%s\t%s\tsince %d\tnext since %d\t%d bytes\n",
+			backup.Key, backup.CreatedAt.Format("2006-01-02 15:04:05"), kind, backup.Since, backup.NextSince, backup.Size)
+	}
+	return nil
+}
+
+// reconcile [-repair], lists the orphans unless -repair is given
+func reconcileCommand(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "-repair") {
+		return errors.New("usage: reconcile [-repair]")
+	}
+	repair := len(args) == 1
+
+	models.Connect(utils.Env.DatabaseURL)
+
+	report, err := jobs.Reconcile(repair)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range report.OrphanObjects {
+		fmt.Printf("orphan object\t%s\n", key)
+	}
+	for _, key := range report.CompletedFilesMissingObjects {
+		fmt.Printf("completed file missing\t%s\n", key)
+	}
+	for _, key := range report.UploadsMissingObjects {
+		fmt.Printf("upload missing\t%s\n", key)
+	}
+	for _, key := range report.PublicSharesMissingObjects {
+		fmt.Printf("public share missing\t%s\n", key)
+	}
+	fmt.Println(report.Summary())
+
+	if repair {
+		fmt.Printf("deleted %d orphan objects and reported %d missing objects of completed files\n",
+			len(report.OrphanObjects), len(report.CompletedFilesMissingObjects))
+	} else {
+		fmt.Println("dry run, nothing was changed; run reconcile -repair to delete the orphan objects")
+	}
+	return nil
+}
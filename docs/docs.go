@@ -118,7 +118,7 @@ var doc = `{
         },
         "/api/v1/delete": {
             "post": {
-                "description": "delete a file\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileID\": \"the handle of the file\",\n}",
+                "description": "move a file to the trash bin, it can be restored until it is purged after the trash retention\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileID\": \"the handle of the file\",\n}",
                 "consumes": [
                     "application/json"
                 ],
@@ -161,7 +161,7 @@ var doc = `{
         },
         "/api/v1/download": {
             "post": {
-                "description": "download a file without cryptographic verification",
+                "description": "download a file without cryptographic verification.\nThe data and the metadata of the file are made public, at fileDownloadUrl followed by /file and /metadata.\nThe SHA-256 of the file and of each of its parts are returned when the node knows them.\nThe egress quota of the owner of the file is only checked when the URL is returned.\nDeprecated, /api/v3/download/private keeps the objects private.",
                 "consumes": [
                     "application/json"
                 ],
@@ -193,6 +193,12 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "the account owning the file used its downloads for the month",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "such data does not exist",
                         "schema": {
@@ -210,7 +216,7 @@ var doc = `{
         },
         "/api/v1/init-upload": {
             "post": {
-                "description": "start an upload\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSizeInByte\": \"200000000000006\",\n\"endIndex\": 2\n}",
+                "description": "start an upload\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSizeInByte\": \"200000000000006\",\n\"endIndex\": 2,\n\"fileSha256\": \"the hex SHA-256 of the whole file, optional. The file is deleted if the data doesn't match\"\n}",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -510,7 +516,7 @@ var doc = `{
         },
         "/api/v1/renew": {
             "post": {
-                "description": "check the renewal status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "description": "check the renewal status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}\nthe node extends the expiration of what the account owns in the background and the response tells how far\nit went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what\nit owned before the node kept track of it",
                 "consumes": [
                     "application/json"
                 ],
@@ -663,7 +669,7 @@ var doc = `{
         },
         "/api/v1/upgrade": {
             "post": {
-                "description": "check the upgrade status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "description": "check the upgrade status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}\nthe node extends the expiration of what the account owns in the background and the response tells how far\nit went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what\nit owned before the node kept track of it",
                 "consumes": [
                     "application/json"
                 ],
@@ -761,7 +767,7 @@ var doc = `{
         },
         "/api/v1/upgradeV2": {
             "post": {
-                "description": "check the upgradeV2 status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "description": "check the upgradeV2 status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"storageLimit\": 100,\n\"durationInMonths\": 12,\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}\nthe node extends the expiration of what the account owns in the background and the response tells how far\nit went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what\nit owned before the node kept track of it",
                 "consumes": [
                     "application/json"
                 ],
@@ -859,7 +865,7 @@ var doc = `{
         },
         "/api/v1/upload": {
             "post": {
-                "description": "upload a chunk of a file. The first partIndex must be 1.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"partIndex\": 1,\n}",
+                "description": "upload a chunk of a file. The first partIndex must be 1.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"partIndex\": 1,\n\"chunkSha256\": \"the hex SHA-256 of the chunk, optional\",\n}",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -1004,24 +1010,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/delete": {
+        "/api/v2/auth/session": {
             "post": {
-                "description": "deletes files\nrequestBody should be a stringified version of:\n{\n\"fileIDs\": [],\n}",
+                "description": "exchange a signed request for a short-lived bearer token. Requests sent with an\n\"Authorization: Bearer \u003ctoken\u003e\" header skip the signature check, they still need the publicKey\nthe token was issued for and a requestBody.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "deletes files",
+                "summary": "create a session token",
                 "parameters": [
                     {
-                        "description": "file(s) deletion object",
-                        "name": "deleteFilesReq",
+                        "description": "session creation object",
+                        "name": "sessionCreateReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.deleteFilesReq"
+                            "$ref": "#/definitions/routes.sessionCreateReq"
                         }
                     }
                 ],
@@ -1029,7 +1035,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.deleteFilesRes"
+                            "$ref": "#/definitions/routes.sessionCreateRes"
                         }
                     },
                     "400": {
@@ -1038,8 +1044,35 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "revoke the session token sent in the \"Authorization: Bearer \u003ctoken\u003e\" header",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "revoke a session token",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "403": {
+                        "description": "session token is invalid, expired or revoked",
                         "schema": {
                             "type": "string"
                         }
@@ -1047,24 +1080,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/download/private": {
+        "/api/v2/delegated-keys/add": {
             "post": {
-                "description": "download a file without cryptographic verification",
+                "description": "register another public key that can act on the account's files and metadata within its scopes\nuntil it expires. Scopes are \"metadata:read\", \"upload\" and \"public-share\". Only the account's\nown key can manage delegated keys. A delegated key stops working if an account is created with it.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"delegatedPublicKey\": \"a 66-character public key\",\n\"scopes\": [\"metadata:read\", \"upload\"],\n\"expiresAt\": 1659325302,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "download a file without cryptographic verification",
+                "summary": "register a delegated key on an account",
                 "parameters": [
                     {
-                        "description": "download object for non-signed requests",
-                        "name": "routes.DownloadFileObj",
+                        "description": "delegated key creation object",
+                        "name": "delegatedKeyAddReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.DownloadFileObj"
+                            "$ref": "#/definitions/routes.delegatedKeyAddReq"
                         }
                     }
                 ],
@@ -1072,7 +1105,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.downloadFileRes"
+                            "$ref": "#/definitions/models.DelegatedKey"
                         }
                     },
                     "400": {
@@ -1081,14 +1114,14 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "such data does not exist",
+                    "403": {
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
                         "schema": {
                             "type": "string"
                         }
@@ -1096,24 +1129,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/download/public": {
+        "/api/v2/delegated-keys/list": {
             "post": {
-                "description": "returns the URLs for a public file and it's thumbnail, if no thumbnail is present, return a default one",
+                "description": "list the delegated keys registered on an account, expired ones included\nrequestBody should be a stringified version of (values are just examples):\n{\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "returns the URLs for a public file and it's thumbnail",
+                "summary": "list the delegated keys of an account",
                 "parameters": [
                     {
-                        "description": "download object for non-signed requests",
-                        "name": "routes.DownloadFileObj",
+                        "description": "delegated keys list object",
+                        "name": "delegatedKeysListReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.DownloadFileObj"
+                            "$ref": "#/definitions/routes.delegatedKeysListReq"
                         }
                     }
                 ],
@@ -1121,7 +1154,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.downloadPublicFileRes"
+                            "$ref": "#/definitions/routes.delegatedKeysListRes"
                         }
                     },
                     "400": {
@@ -1130,14 +1163,14 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "such data does not exist",
+                    "403": {
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
                         "schema": {
                             "type": "string"
                         }
@@ -1145,24 +1178,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/init-upload-public": {
+        "/api/v2/delegated-keys/remove": {
             "post": {
-                "description": "start a public upload.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"the file ID for which the public file should be created\",\n\"fileSizeInByte\": \"55600008877\",\n\"endIndex\": 2\n}",
+                "description": "remove a delegated key, it can't be used anymore\nrequestBody should be a stringified version of (values are just examples):\n{\n\"delegatedPublicKey\": \"a 66-character public key\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "start a public upload",
+                "summary": "remove a delegated key from an account",
                 "parameters": [
                     {
-                        "description": "an object to start a public file upload",
-                        "name": "InitFileUploadReq",
+                        "description": "delegated key removal object",
+                        "name": "delegatedKeyRemoveReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.InitFileUploadReq"
+                            "$ref": "#/definitions/routes.delegatedKeyRemoveReq"
                         }
                     }
                 ],
@@ -1180,13 +1213,13 @@ var doc = `{
                         }
                     },
                     "403": {
-                        "description": "signature did not match / account does not exist",
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "404": {
+                        "description": "delegated key does not exist",
                         "schema": {
                             "type": "string"
                         }
@@ -1194,24 +1227,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/metadata/add": {
+        "/api/v2/delete": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"metadataV2Vertex\": \"the vertex to add to your account metadataV2 encoded to base64\",\n\"metadataV2Edges\": \"the edges to add to your account metadataV2 encoded to base64\",\n\"metadataV2Sig\": \"a signature encoded to base64 confirming the metadata change, the publickey will be a key for the metadataV2\",\n\"timestamp\": 1557346389\n}",
+                "description": "move files to the trash bin, they can be restored until they are purged after the trash retention\nrequestBody should be a stringified version of:\n{\n\"fileIDs\": [],\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update metadataV2",
+                "summary": "deletes files",
                 "parameters": [
                     {
-                        "description": "update metadataV2 object",
-                        "name": "updateMetadataV2Req",
+                        "description": "file(s) deletion object",
+                        "name": "deleteFilesReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.updateMetadataV2Req"
+                            "$ref": "#/definitions/routes.deleteFilesReq"
                         }
                     }
                 ],
@@ -1219,23 +1252,11 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.updateMetadataV2Res"
+                            "$ref": "#/definitions/routes.deleteFilesRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, can't verify signature: (with the error)",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "403": {
-                        "description": "subscription expired, or the invoice response",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "no value found for that key, or account not found",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
@@ -1249,24 +1270,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/metadata/add-multiple": {
+        "/api/v2/download/private": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadatas\": [{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"metadataV2Vertex\": \"the vertex to add to your account metadataV2 encoded to base64\",\n\"metadataV2Edges\": \"the edges to add to your account metadataV2 encoded to base64\",\n\"metadataV2Sig\": \"a signature encoded to base64 confirming the metadata change, the publickey will be a key for the metadataV2\",\n},\n{ ... }]\n\"timestamp\": 1557346389\n}",
+                "description": "download a file without cryptographic verification.\nThe data and the metadata of the file are made public, at fileDownloadUrl followed by /file and /metadata.\nThe SHA-256 of the file and of each of its parts are returned when the node knows them.\nThe egress quota of the owner of the file is only checked when the URL is returned.\nDeprecated, /api/v3/download/private keeps the objects private.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update multiple metadataV2",
+                "summary": "download a file without cryptographic verification",
                 "parameters": [
                     {
-                        "description": "update metadataV2 objects",
-                        "name": "updateMetadataMultipleV2Req",
+                        "description": "download object for non-signed requests",
+                        "name": "routes.DownloadFileObj",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.updateMetadataMultipleV2Req"
+                            "$ref": "#/definitions/routes.DownloadFileObj"
                         }
                     }
                 ],
@@ -1274,17 +1295,23 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.updateMetadataMultipleV2Res"
+                            "$ref": "#/definitions/routes.downloadFileRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, can't verify signature: (with the error)",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice response",
+                        "description": "the account owning the file used its downloads for the month",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "such data does not exist",
                         "schema": {
                             "type": "string"
                         }
@@ -1298,24 +1325,30 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/metadata/delete": {
+        "/api/v2/download/public": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"timestamp\": 1557346389\n}",
+                "description": "returns the URLs for a public file and it's thumbnail, if no thumbnail is present, return a default one.\nWhen every public share of the file is password protected, the X-Share-Access-Token header must hold\nthe access token of one of them. When a public share of the file expires or has a maximum number of views,\nthe file is opened through the share without password that limits it the least, or the one the access token\nunlocked: a view of that share is counted and the URLs are presigned ones that stop working when it expires.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "delete a metadataV2",
+                "summary": "returns the URLs for a public file and it's thumbnail",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataV2Key and timestamp",
-                        "name": "metadataV2KeyReq",
+                        "type": "string",
+                        "description": "access token of a password protected public share of the file",
+                        "name": "X-Share-Access-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "download object for non-signed requests",
+                        "name": "routes.DownloadFileObj",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.metadataV2KeyReq"
+                            "$ref": "#/definitions/routes.DownloadFileObj"
                         }
                     }
                 ],
@@ -1323,23 +1356,29 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.downloadPublicFileRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, incorrect key length",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "the public share is password protected, or the account owning the file used its downloads for the month",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "account not found",
+                        "description": "such data does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "every public share of the file expired or reached its maximum number of views",
                         "schema": {
                             "type": "string"
                         }
@@ -1353,48 +1392,78 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/metadata/delete-multiple": {
+        "/api/v2/download/stream": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Keys\": [\"public key for the metadataV2 encoded to base64\", \"another public key for the metadataV2 encoded to base64\", \"...\"],\n\"timestamp\": 1557346389\n}",
+                "description": "stream the data, or the metadata, of a file of the account through the node, for clients that\ncan't reach the bucket. Supports a single HTTP byte range and If-None-Match. The bytes sent count\ntowards the egress usage of the account.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileID\": \"the handle of the file\",\n\"metadata\": false,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
-                "summary": "delete multiple metadataV2, if a key is not found, it won't be treated as an error",
+                "summary": "download a file through the node",
                 "parameters": [
                     {
-                        "description": "object for endpoint that only needs an array of metadataV2Keys and timestamp",
-                        "name": "metadataMultipleV2KeyReq",
+                        "description": "download stream object",
+                        "name": "downloadStreamReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.metadataMultipleV2KeyReq"
+                            "$ref": "#/definitions/routes.downloadStreamReq"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "byte range, e.g. bytes=0-99",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the ETag of a copy the client already has",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "the object data",
                         "schema": {
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "type": "string"
+                        }
+                    },
+                    "206": {
+                        "description": "the requested range of the object data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "the object still has the ETag given in If-None-Match",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "bad request, incorrect key length",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "signature did not match, or the account used its downloads for the month",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "account not found",
+                        "description": "such data does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "416": {
+                        "description": "the requested range is not satisfiable",
                         "schema": {
                             "type": "string"
                         }
@@ -1408,24 +1477,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/metadata/get": {
+        "/api/v2/files/list": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"timestamp\": 1557346389\n}",
+                "description": "list the completed files an account owns, by file handle. Pass the nextAfter of a page as after\nto get the next one. Files uploaded before the node kept track of their owner are not listed,\nnor are the files in the trash bin.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"after\": \"the last file handle of the previous page, optional\",\n\"limit\": 100,\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Retrieve account metadataV2",
+                "summary": "list the files of an account",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataV2Key and timestamp",
-                        "name": "metadataV2KeyReq",
+                        "description": "files list object",
+                        "name": "filesListReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.metadataV2KeyReq"
+                            "$ref": "#/definitions/routes.filesListReq"
                         }
                     }
                 ],
@@ -1433,23 +1502,29 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.getMetadataV2Res"
+                            "$ref": "#/definitions/routes.filesListRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, incorrect key length",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "no value found for that key, or account not found",
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -1457,24 +1532,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/metadata/get-public": {
+        "/api/v2/init-upload-direct": {
             "post": {
-                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"timestamp\": 1557346389\n}",
+                "description": "start an upload whose parts are PUT by the client to presigned URLs instead of being sent to the node.\nEvery part but the last must be at least 5MB. Call upload-complete-direct once all the parts are uploaded.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSizeInByte\": \"200000000000006\",\n\"endIndex\": 2,\n\"fileSha256\": \"the hex SHA-256 of the whole file, optional. The file is deleted if the data doesn't match\"\n}",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Retrieve account metadataV2",
+                "summary": "start an upload straight to the bucket",
                 "parameters": [
                     {
-                        "description": "object for endpoints that only need metadataV2Key and timestamp",
-                        "name": "metadataV2PublicKeyReq",
+                        "description": "an object to start a file upload",
+                        "name": "InitFileUploadReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.metadataV2PublicKeyReq"
+                            "$ref": "#/definitions/routes.InitFileUploadReq"
                         }
                     }
                 ],
@@ -1482,23 +1557,23 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.getMetadataV2Res"
+                            "$ref": "#/definitions/routes.initUploadDirectRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, incorrect key length",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "subscription expired, or the invoice resonse",
+                        "description": "signature did not match",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "no value found for that key, or account not found",
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -1506,65 +1581,73 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/public-share/:shortlink": {
-            "get": {
-                "description": "get the S3 URL for a publicly shared file",
+        "/api/v2/init-upload-public": {
+            "post": {
+                "description": "start a public upload.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"the file ID for which the public file should be created\",\n\"fileSizeInByte\": \"55600008877\",\n\"endIndex\": 2\n}",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get S3 url for a publicly shared file",
+                "summary": "start a public upload",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "shortlink ID",
-                        "name": "shortlink",
-                        "in": "path",
-                        "required": true
+                        "description": "an object to start a public file upload",
+                        "name": "InitFileUploadReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.InitFileUploadReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.PublicFileDownloadResp"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
                     },
-                    "404": {
-                        "description": "file does not exist",
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "there was an error parsing your request",
+                    "403": {
+                        "description": "signature did not match / account does not exist",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
             }
         },
-        "/api/v2/public-share/convert": {
+        "/api/v2/metadata/add": {
             "post": {
-                "description": "convert private file to a public shared one\nrequestBody should be a stringified version of:\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSize\": 543534,\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"metadataV2Vertex\": \"the vertex to add to your account metadataV2 encoded to base64\",\n\"metadataV2Edges\": \"the edges to add to your account metadataV2 encoded to base64\",\n\"metadataV2Sig\": \"a signature encoded to base64 confirming the metadata change, the publickey will be a key for the metadataV2\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "convert private file to a public shared one",
+                "summary": "Update metadataV2",
                 "parameters": [
                     {
-                        "description": "an object to do the conversion of a private file to a public one",
-                        "name": "PrivateToPublicReq",
+                        "description": "update metadataV2 object",
+                        "name": "updateMetadataV2Req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.PrivateToPublicReq"
+                            "$ref": "#/definitions/routes.updateMetadataV2Req"
                         }
                     }
                 ],
@@ -1572,23 +1655,29 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.updateMetadataV2Res"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to parse request body: (with the error)",
+                        "description": "bad request, can't verify signature: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "signature did not match",
+                        "description": "subscription expired, or the invoice response",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "the data does not exist",
+                        "description": "no value found for that key, or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -1596,24 +1685,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/public-share/revoke": {
+        "/api/v2/metadata/add-multiple": {
             "post": {
-                "description": "remove a public share entry, revoke the share\nrequestBody should be a stringified version of):\n{\n\"shortlink\": \"the shortlink of the completed file\",\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadatas\": [{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"metadataV2Vertex\": \"the vertex to add to your account metadataV2 encoded to base64\",\n\"metadataV2Edges\": \"the edges to add to your account metadataV2 encoded to base64\",\n\"metadataV2Sig\": \"a signature encoded to base64 confirming the metadata change, the publickey will be a key for the metadataV2\",\n},\n{ ... }],\n\"failedMetadatas\": [\"metadata key\": \"error value\", ...]\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "revokes public share",
+                "summary": "Update multiple metadataV2",
                 "parameters": [
                     {
-                        "description": "an object to do operations on a public share",
-                        "name": "PublicShareOpsReq",
+                        "description": "update metadataV2 objects",
+                        "name": "updateMetadataMultipleV2Req",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.PublicShareOpsReq"
+                            "$ref": "#/definitions/routes.updateMetadataMultipleV2Req"
                         }
                     }
                 ],
@@ -1621,29 +1710,23 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.updateMetadataMultipleV2Res"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to revoke public share",
+                        "description": "bad request, can't verify signature: (with the error)",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "signature did not match",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "file does not exist",
+                        "description": "subscription expired, or the invoice response",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "public file could not be deleted from databse or S3",
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -1651,24 +1734,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/public-share/shortlink": {
+        "/api/v2/metadata/delete": {
             "post": {
-                "description": "this endpoint will created a new shortlink based on the fileHandle, a title and a description\nrequestBody should be a stringified version of:\n{\n\"fileId\": \"the ID of the file\",\n\"title\": \"the title of the file\",\n\"description\": \"a description of the file\",\n\"mimeType\": \"the file mimeType example: image/png\",\n\"fileExtension\": \"the file extension, example: png\"\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "creates a shortlink",
+                "summary": "delete a metadataV2",
                 "parameters": [
                     {
-                        "description": "an object to create a shortlink for a public shared file",
-                        "name": "CreateShortlinkReq",
+                        "description": "object for endpoints that only need metadataV2Key and timestamp",
+                        "name": "metadataV2KeyReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.CreateShortlinkReq"
+                            "$ref": "#/definitions/routes.metadataV2KeyReq"
                         }
                     }
                 ],
@@ -1676,23 +1759,29 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.CreateShortlinkResp"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to parse request body: (with the error)",
+                        "description": "bad request, incorrect key length",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "signature did not match",
+                        "description": "subscription expired, or the invoice resonse",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "the data does not exist",
+                        "description": "account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -1700,24 +1789,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/public-share/views-count": {
+        "/api/v2/metadata/delete-multiple": {
             "post": {
-                "description": "get the views count for a publicly shared file\nrequestBody should be a stringified version of:\n{\n\"shortlink\": \"the shortlink of the completed file\",\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Keys\": [\"public key for the metadataV2 encoded to base64\", \"another public key for the metadataV2 encoded to base64\", \"...\"],\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get views count",
+                "summary": "delete multiple metadataV2, if a key is not found, it won't be treated as an error",
                 "parameters": [
                     {
-                        "description": "an object to do operations on a public share",
-                        "name": "PublicShareOpsReq",
+                        "description": "object for endpoint that only needs an array of metadataV2Keys and timestamp",
+                        "name": "metadataMultipleV2KeyReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.PublicShareOpsReq"
+                            "$ref": "#/definitions/routes.metadataMultipleV2KeyReq"
                         }
                     }
                 ],
@@ -1725,23 +1814,29 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.viewsCountResp"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to get views count",
+                        "description": "bad request, incorrect key length",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "403": {
-                        "description": "signature did not match",
+                        "description": "subscription expired, or the invoice resonse",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "public share or file does not exist",
+                        "description": "account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
                         "schema": {
                             "type": "string"
                         }
@@ -1749,24 +1844,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/renew": {
+        "/api/v2/metadata/get": {
             "post": {
-                "description": "check the renewalV2 status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "check the renewalV2 status",
+                "summary": "Retrieve account metadataV2",
                 "parameters": [
                     {
-                        "description": "check renewalV2 status object",
-                        "name": "checkRenewalV2StatusReq",
+                        "description": "object for endpoints that only need metadataV2Key and timestamp",
+                        "name": "metadataV2KeyReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.checkRenewalV2StatusReq"
+                            "$ref": "#/definitions/routes.metadataV2KeyReq"
                         }
                     }
                 ],
@@ -1774,23 +1869,23 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.StatusRes"
+                            "$ref": "#/definitions/routes.getMetadataV2Res"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to parse request body: (with the error)",
+                        "description": "bad request, incorrect key length",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "no account with that id: (with your accountID)",
+                    "403": {
+                        "description": "subscription expired, or the invoice resonse",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "404": {
+                        "description": "no value found for that key, or account not found",
                         "schema": {
                             "type": "string"
                         }
@@ -1798,24 +1893,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/renew/invoice": {
+        "/api/v2/metadata/get-public": {
             "post": {
-                "description": "get an invoice to renew an account\nrequestBody should be a stringified version of (values are just examples):\n{\n}",
+                "description": "requestBody should be a stringified version of (values are just examples):\n{\n\"metadataV2Key\": \"public key for the metadataV2 encoded to base64\",\n\"timestamp\": 1557346389\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get an invoice to renew an account",
+                "summary": "Retrieve account metadataV2",
                 "parameters": [
                     {
-                        "description": "get renewalV2 invoice object",
-                        "name": "getRenewalV2AccountInvoiceReq",
+                        "description": "object for endpoints that only need metadataV2Key and timestamp",
+                        "name": "metadataV2PublicKeyReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.getRenewalV2AccountInvoiceReq"
+                            "$ref": "#/definitions/routes.metadataV2PublicKeyReq"
                         }
                     }
                 ],
@@ -1823,23 +1918,23 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.getRenewalV2AccountInvoiceRes"
+                            "$ref": "#/definitions/routes.getMetadataV2Res"
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to parse request body: (with the error)",
+                        "description": "bad request, incorrect key length",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "no account with that id: (with your accountID)",
+                    "403": {
+                        "description": "subscription expired, or the invoice resonse",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "some information about the internal error",
+                    "404": {
+                        "description": "no value found for that key, or account not found",
                         "schema": {
                             "type": "string"
                         }
@@ -1847,28 +1942,58 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/smart-contracts": {
+        "/api/v2/public-share/:shortlink": {
             "get": {
-                "description": "gets the smart contracts address and related info",
+                "description": "get the S3 URL for a publicly shared file.\nWhile every public share of the file is password protected, or one of them expires or has a maximum number of\nviews, the public objects are private and the URLs are presigned ones that stop working when the share expires.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "gets the smart contracts address",
+                "summary": "get S3 url for a publicly shared file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "shortlink ID",
+                        "name": "shortlink",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access token of the public share, when it is password protected",
+                        "name": "X-Share-Access-Token",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/routes.SmartContractResp"
-                            }
+                            "$ref": "#/definitions/routes.PublicFileDownloadResp"
+                        }
+                    },
+                    "403": {
+                        "description": "the public share is password protected, or the account owning the file used its downloads for the month",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "404": {
-                        "description": "no account with that id: (with your accountID)",
+                        "description": "file does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "this public share expired or reached its maximum number of views",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "there was an error parsing your request",
                         "schema": {
                             "type": "string"
                         }
@@ -1876,24 +2001,24 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/upload-public": {
+        "/api/v2/public-share/convert": {
             "post": {
-                "description": "upload a chunk of a file. The first partIndex must be 1. The storage for this file does not count\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"partIndex\": 1,\n}",
+                "description": "convert private file to a public shared one\nrequestBody should be a stringified version of:\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"fileSize\": 543534,\n}",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "upload a chunk of a file",
+                "summary": "convert private file to a public shared one",
                 "parameters": [
                     {
-                        "description": "an object to upload a chunk of an unencrypted file (the storage for this file does no count)",
-                        "name": "UploadFileReq",
+                        "description": "an object to do the conversion of a private file to a public one",
+                        "name": "PrivateToPublicReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/routes.UploadFileReq"
+                            "$ref": "#/definitions/routes.PrivateToPublicReq"
                         }
                     }
                 ],
@@ -1905,7 +2030,7 @@ var doc = `{
                         }
                     },
                     "400": {
-                        "description": "bad request, unable to parse request body",
+                        "description": "bad request, unable to parse request body: (with the error)",
                         "schema": {
                             "type": "string"
                         }
@@ -1917,13 +2042,7 @@ var doc = `{
                         }
                     },
                     "404": {
-                        "description": "file or account not found",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "500": {
-                        "description": "some information about the internal error",
+                        "description": "the data does not exist",
                         "schema": {
                             "type": "string"
                         }
@@ -1931,16 +2050,27 @@ var doc = `{
                 }
             }
         },
-        "/api/v2/upload-status-public": {
+        "/api/v2/public-share/password": {
             "post": {
-                "description": "check status of a public upload and creates a thumbnail in case the file is an image. If the mimeType is send, the API will not create the thumbnail\n\"jpg\" (or \"jpeg\"), \"png\", \"gif\", \"tif\" (or \"tiff\") and \"bmp\" are supported\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"mimeType\": \"the mime type of the file\",\n\"title\": \"file title\",\n\"description\": \"a description to be used as metatags value\"\n}",
+                "description": "protect a public share with a password, or remove its password. While every public share of a file\nis protected the public file is only handed out through presigned URLs.\nrequestBody should be a stringified version of:\n{\n\"shortlink\": \"the shortlink of the completed file\",\n\"password\": \"the new password, empty to remove it\"\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "check status of a public upload",
+                "summary": "set the password of a public share",
+                "parameters": [
+                    {
+                        "description": "public share password object",
+                        "name": "publicSharePasswordReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.publicSharePasswordReq"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1961,7 +2091,7 @@ var doc = `{
                         }
                     },
                     "404": {
-                        "description": "file not found",
+                        "description": "public share or file does not exist",
                         "schema": {
                             "type": "string"
                         }
@@ -1975,28 +2105,1158 @@ var doc = `{
                 }
             }
         },
-        "/plans": {
-            "get": {
-                "description": "get the plans we sell",
+        "/api/v2/public-share/revoke": {
+            "post": {
+                "description": "remove a public share entry, revoke the share. The public objects of the file are deleted with its last share\nrequestBody should be a stringified version of):\n{\n\"shortlink\": \"the shortlink of the completed file\",\n}",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "get the plans we sell",
+                "summary": "revokes public share",
+                "parameters": [
+                    {
+                        "description": "an object to do operations on a public share",
+                        "name": "PublicShareOpsReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.PublicShareOpsReq"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/routes.PlanResponse"
+                            "$ref": "#/definitions/routes.StatusRes"
                         }
-                    }
+                    },
+                    "400": {
+                        "description": "bad request, unable to revoke public share",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "file does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "public file could not be deleted from databse or S3",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/public-share/shortlink": {
+            "post": {
+                "description": "this endpoint will created a new shortlink based on the fileHandle, a title and a description\nrequestBody should be a stringified version of:\n{\n\"fileId\": \"the ID of the file\",\n\"title\": \"the title of the file\",\n\"description\": \"a description of the file\",\n\"mimeType\": \"the file mimeType example: image/png\",\n\"fileExtension\": \"the file extension, example: png\",\n\"password\": \"optional, a password needed to open the shortlink\",\n\"expiresAt\": \"optional, the unix time at which the shortlink stops working\",\n\"maxViews\": \"optional, how many times the shortlink can be opened\"\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "creates a shortlink",
+                "parameters": [
+                    {
+                        "description": "an object to create a shortlink for a public shared file",
+                        "name": "CreateShortlinkReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.CreateShortlinkReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.CreateShortlinkResp"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the data does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/public-share/unlock": {
+            "post": {
+                "description": "exchange the password of a public share for a short-lived access token to send in the\nX-Share-Access-Token header when getting the shortlink or downloading the public file.\nWrong passwords are throttled for each public share.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "unlock a password protected public share",
+                "parameters": [
+                    {
+                        "description": "public share unlock object",
+                        "name": "publicShareUnlockReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.publicShareUnlockReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.publicShareUnlockRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "wrong password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "public share does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "too many wrong passwords, try again later",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/public-share/views-count": {
+            "post": {
+                "description": "get the views count for a publicly shared file\nrequestBody should be a stringified version of:\n{\n\"shortlink\": \"the shortlink of the completed file\",\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get views count",
+                "parameters": [
+                    {
+                        "description": "an object to do operations on a public share",
+                        "name": "PublicShareOpsReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.PublicShareOpsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.viewsCountResp"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to get views count",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "public share or file does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/renew": {
+            "post": {
+                "description": "check the renewalV2 status\nrequestBody should be a stringified version of (values are just examples):\n{\n\"metadataKeys\": \"[\"someKey\", \"someOtherKey]\",\n\"fileHandles\": \"[\"someHandle\", \"someOtherHandle]\",\n}\nthe node extends the expiration of what the account owns in the background and the response tells how far\nit went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what\nit owned before the node kept track of it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "check the renewalV2 status",
+                "parameters": [
+                    {
+                        "description": "check renewalV2 status object",
+                        "name": "checkRenewalV2StatusReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.checkRenewalV2StatusReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/renew/invoice": {
+            "post": {
+                "description": "get an invoice to renew an account\nrequestBody should be a stringified version of (values are just examples):\n{\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get an invoice to renew an account",
+                "parameters": [
+                    {
+                        "description": "get renewalV2 invoice object",
+                        "name": "getRenewalV2AccountInvoiceReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.getRenewalV2AccountInvoiceReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.getRenewalV2AccountInvoiceRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/smart-contracts": {
+            "get": {
+                "description": "gets the smart contracts address and related info",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "gets the smart contracts address",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/routes.SmartContractResp"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/trash/list": {
+            "post": {
+                "description": "list the deleted files of an account that can still be restored, by file handle. Pass the\nnextAfter of a page as after to get the next one.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"after\": \"the last file handle of the previous page, optional\",\n\"limit\": 100,\n\"timestamp\": 1557346389\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "list the trash bin of an account",
+                "parameters": [
+                    {
+                        "description": "trash list object",
+                        "name": "trashListReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.trashListReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.trashListRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/trash/restore": {
+            "post": {
+                "description": "take deleted files out of the trash bin before they are purged. Their public shares are not restored.\nrequestBody should be a stringified version of:\n{\n\"fileIDs\": [],\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "restore files from the trash bin",
+                "parameters": [
+                    {
+                        "description": "restore files object",
+                        "name": "restoreFilesReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.restoreFilesReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.restoreFilesRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no account with that id: (with your accountID)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/tus": {
+            "post": {
+                "description": "create a resumable upload with the tus 1.0.0 creation extension. The request is authenticated with\nthe Opacity-Public-Key, Opacity-Signature and Opacity-Request-Body headers, which hold what the\npublicKey, signature and requestBody fields hold in the other requests, or with a session token.\nUpload-Metadata must have a \"metadata\" key with the metadata of the file, and may have a \"sha256\" key\nwith the hex SHA-256 of the whole file. The file is deleted if the data doesn't match it.",
+                "summary": "create a tus upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size of the file in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "metadata \u003cthe metadata of the file, base64 encoded\u003e",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "a 66-character public key",
+                        "name": "Opacity-Public-Key",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "a 128 character string created when you signed the request body",
+                        "name": "Opacity-Signature",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the stringified request body, with the fileHandle and a timestamp",
+                        "name": "Opacity-Request-Body",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "the URL of the upload, its uploadToken query authenticates the next requests of the upload"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "unsupported tus version",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "upload is too big",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "options": {
+                "description": "the tus 1.0.0 version, extensions and maximum upload size supported by the node",
+                "summary": "tus server configuration",
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Tus-Extension": {
+                                "type": "string",
+                                "description": "creation,termination"
+                            },
+                            "Tus-Max-Size": {
+                                "type": "integer",
+                                "description": "the biggest Upload-Length accepted"
+                            },
+                            "Tus-Version": {
+                                "type": "string",
+                                "description": "1.0.0"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/tus/{fileHandle}": {
+            "delete": {
+                "description": "stop a tus upload and delete what was uploaded, with the tus 1.0.0 termination extension.\nAuthenticated by the uploadToken of the Location returned at creation, or like the creation.",
+                "summary": "terminate a tus upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the file handle of the upload",
+                        "name": "fileHandle",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the upload token from the Location returned at creation",
+                        "name": "uploadToken",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no file with that id: (with the file handle)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "get how many bytes of a tus upload were received. Authenticated by the uploadToken of the Location\nreturned at creation, or like the creation.",
+                "summary": "get the offset of a tus upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the file handle of the upload",
+                        "name": "fileHandle",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the upload token from the Location returned at creation",
+                        "name": "uploadToken",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Upload-Length": {
+                                "type": "integer",
+                                "description": "size of the file in bytes"
+                            },
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "bytes received"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no file with that id: (with the file handle)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "append the body to a tus upload at Upload-Offset. The upload is completed with the last byte.\nAuthenticated by the uploadToken of the Location returned at creation, or like the creation.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "summary": "upload bytes of a tus upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the file handle of the upload",
+                        "name": "fileHandle",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the upload token from the Location returned at creation",
+                        "name": "uploadToken",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset the body starts at",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "bytes received"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "no file with that id: (with the file handle)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Upload-Offset does not match the offset of the upload",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Content-Type must be application/offset+octet-stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "another request is uploading to this upload",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/upload-complete-direct": {
+            "post": {
+                "description": "check the parts uploaded to the presigned URLs of init-upload-direct and finish the file.\nReturns the missing parts while some are not uploaded yet.\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "complete an upload straight to the bucket",
+                "parameters": [
+                    {
+                        "description": "an object to complete an upload",
+                        "name": "UploadStatusReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.UploadStatusReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "the uploaded parts don't match the size of the file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "file or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/upload-public": {
+            "post": {
+                "description": "upload a chunk of a file. The first partIndex must be 1. The storage for this file does not count\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"partIndex\": 1,\n\"chunkSha256\": \"the hex SHA-256 of the chunk, optional\",\n}",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "upload a chunk of a file",
+                "parameters": [
+                    {
+                        "description": "an object to upload a chunk of an unencrypted file (the storage for this file does no count)",
+                        "name": "UploadFileReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.UploadFileReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "file or account not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v2/upload-status-public": {
+            "post": {
+                "description": "check status of a public upload and creates a thumbnail in case the file is an image. If the mimeType is send, the API will not create the thumbnail\n\"jpg\" (or \"jpeg\"), \"png\", \"gif\", \"tif\" (or \"tiff\") and \"bmp\" are supported\nrequestBody should be a stringified version of (values are just examples):\n{\n\"fileHandle\": \"a deterministically created file handle\",\n\"mimeType\": \"the mime type of the file\",\n\"title\": \"file title\",\n\"description\": \"a description to be used as metatags value\"\n}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "check status of a public upload",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.StatusRes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "signature did not match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "file not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v3/download/private": {
+            "post": {
+                "description": "download a file without cryptographic verification.\nReturns presigned URLs of the data and of the metadata of the file that can be used until urlsExpireAt.\nThe egress quota of the owner of the file is checked when the URLs are returned, what is downloaded\nwith them counts towards it once the node read the access logs of the bucket.\nThe SHA-256 of the file and of each of its parts are returned when the node knows them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "download a file without cryptographic verification",
+                "parameters": [
+                    {
+                        "description": "download object for non-signed requests",
+                        "name": "routes.DownloadFileObj",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/routes.DownloadFileObj"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.downloadFileV3Res"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unable to parse request body: (with the error)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "the account owning the file used its downloads for the month",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "such data does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "some information about the internal error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/blobs/{key}": {
+            "get": {
+                "description": "serves the public objects that GetBucketUrl points at when the node stores its objects on the local filesystem,\nand private ones through the presigned URLs the download route returns. Supports a single HTTP byte range.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "summary": "download an object from the local blob store",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the object key, e.g. the file handle followed by /public",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "when the presigned URL expires",
+                        "name": "expires",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the signature of the presigned URL",
+                        "name": "signature",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "byte range, e.g. bytes=0-99",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "the object data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "206": {
+                        "description": "the requested range of the object data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied, object is not public or the URL signature is invalid or expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the object does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "416": {
+                        "description": "the requested range is not satisfiable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "takes a part of a multipart upload PUT to a presigned URL returned by init-upload-direct,\nwhen the node stores its objects on the local filesystem.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "summary": "upload a part to the local blob store",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "the object key, e.g. the file handle followed by /file",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the multipart upload",
+                        "name": "uploadId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the part number",
+                        "name": "partNumber",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "when the URL expires",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the signature of the URL",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the base64 MD5 of the part, the part is rejected if it doesn't match",
+                        "name": "Content-MD5",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "access denied, the URL signature is invalid or expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "the upload does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans": {
+            "get": {
+                "description": "get the plans we sell",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "get the plans we sell",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/routes.PlanResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.DelegatedKey": {
+            "type": "object",
+            "required": [
+                "accountID",
+                "expiresAt",
+                "keyID",
+                "publicKey",
+                "scopes"
+            ],
+            "properties": {
+                "accountID": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 64
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "keyID": {
+                    "description": "KeyID is the hash of the delegated public key, like an AccountID is the hash of the account's key",
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 64
+                },
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66
+                },
+                "scopes": {
+                    "type": "string",
+                    "example": "metadata:read,upload"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "models.Invoice": {
             "type": "object",
             "required": [
@@ -2015,12 +3275,28 @@ var doc = `{
                 }
             }
         },
+        "models.PartDigest": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "the hex SHA-256 of the part"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 5242880
+                }
+            }
+        },
         "routes.CreateShortlinkReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2034,7 +3310,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2067,8 +3343,7 @@ var doc = `{
             "required": [
                 "metadata",
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "metadata": {
@@ -2089,7 +3364,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2109,8 +3384,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2124,7 +3398,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2147,8 +3421,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2162,7 +3435,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2198,6 +3471,10 @@ var doc = `{
         "routes.StatusRes": {
             "type": "object",
             "properties": {
+                "expiryPropagation": {
+                    "description": "ExpiryPropagation is how far the expiration of a renewal or upgrade was carried to the files and metadatas",
+                    "$ref": "#/definitions/routes.expiryPropagationRes"
+                },
                 "status": {
                     "type": "string",
                     "example": "status of the request"
@@ -2209,11 +3486,11 @@ var doc = `{
             "required": [
                 "chunkData",
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "chunkData": {
+                    "description": "Documents the form, the handlers stream the chunk from the request instead of filling it",
                     "type": "string",
                     "example": "a binary string of the chunk data"
                 },
@@ -2228,7 +3505,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2240,8 +3517,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2255,7 +3531,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2267,8 +3543,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2282,7 +3557,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2349,6 +3624,11 @@ var doc = `{
                 "createdAt": {
                     "type": "string"
                 },
+                "egressUsedThisMonth": {
+                    "description": "how much they downloaded this month, in GB",
+                    "type": "number",
+                    "example": 12.5
+                },
                 "ethAddress": {
                     "description": "the eth address they will send payment to",
                     "type": "string",
@@ -2359,6 +3639,11 @@ var doc = `{
                 "expirationDate": {
                     "type": "string"
                 },
+                "maxEgressGBPerMonth": {
+                    "description": "0 when downloads are not limited",
+                    "type": "integer",
+                    "example": 256
+                },
                 "maxFolders": {
                     "type": "integer",
                     "example": 2000
@@ -2424,8 +3709,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2439,7 +3723,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2451,8 +3735,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2466,7 +3749,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2478,8 +3761,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2493,7 +3775,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2505,8 +3787,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2520,7 +3801,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2539,12 +3820,278 @@ var doc = `{
                 }
             }
         },
-        "routes.createStripePaymentReq": {
+        "routes.createStripePaymentReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.delegatedKeyAddReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.delegatedKeyRemoveReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.delegatedKeysListReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.delegatedKeysListRes": {
+            "type": "object",
+            "properties": {
+                "delegatedKeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DelegatedKey"
+                    }
+                }
+            }
+        },
+        "routes.deleteFileReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.deleteFileRes": {
+            "type": "object"
+        },
+        "routes.deleteFilesReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.deleteFilesRes": {
+            "type": "object",
+            "properties": {
+                "unsuccessfulDeletions": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "routes.directUploadPartURL": {
+            "type": "object",
+            "properties": {
+                "partNumber": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "url": {
+                    "type": "string",
+                    "example": "a presigned URL to PUT the part to"
+                }
+            }
+        },
+        "routes.downloadFileRes": {
+            "type": "object",
+            "properties": {
+                "fileDownloadUrl": {
+                    "description": "Url should point to S3, thus client does not need to download it from this node.\nThe data and the metadata of the file are at this url followed by /file and /metadata.",
+                    "type": "string",
+                    "example": "a URL to use to download the file"
+                },
+                "fileSha256": {
+                    "description": "Digests to verify the downloaded file with, when the uploader gave them or the parts went through the node",
+                    "type": "string",
+                    "example": "the hex SHA-256 of the whole file"
+                },
+                "fileSha256Verified": {
+                    "description": "Whether the node checked the data has FileSha256, a file that doesn't is deleted",
+                    "type": "boolean",
+                    "example": true
+                },
+                "partDigests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PartDigest"
+                    }
+                }
+            }
+        },
+        "routes.downloadFileV3Res": {
+            "type": "object",
+            "properties": {
+                "fileDataUrl": {
+                    "description": "The urls are presigned urls of the objects in S3, thus client does not need to download them from this node.\nThey stop working at UrlsExpireAt, the objects themselves stay private.",
+                    "type": "string",
+                    "example": "a URL to use to download the data of the file"
+                },
+                "fileMetadataUrl": {
+                    "type": "string",
+                    "example": "a URL to use to download the metadata of the file"
+                },
+                "fileSha256": {
+                    "description": "Digests to verify the downloaded file with, when the uploader gave them or the parts went through the node",
+                    "type": "string",
+                    "example": "the hex SHA-256 of the whole file"
+                },
+                "fileSha256Verified": {
+                    "description": "Whether the node checked the data has FileSha256, a file that doesn't is deleted",
+                    "type": "boolean",
+                    "example": true
+                },
+                "partDigests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PartDigest"
+                    }
+                },
+                "urlsExpireAt": {
+                    "type": "integer",
+                    "example": 1557346389
+                }
+            }
+        },
+        "routes.downloadPublicFileRes": {
+            "type": "object",
+            "properties": {
+                "fileDownloadThumbnailUrl": {
+                    "type": "string",
+                    "example": "a URL to use to download the public file thumbnail"
+                },
+                "fileDownloadUrl": {
+                    "type": "string",
+                    "example": "a URL to use to download the public file"
+                }
+            }
+        },
+        "routes.downloadStreamReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2558,7 +4105,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2566,42 +4113,28 @@ var doc = `{
                 }
             }
         },
-        "routes.deleteFileReq": {
+        "routes.expiryPropagationRes": {
             "type": "object",
-            "required": [
-                "publicKey",
-                "requestBody",
-                "signature"
-            ],
             "properties": {
-                "publicKey": {
-                    "type": "string",
-                    "maxLength": 66,
-                    "minLength": 66,
-                    "example": "a 66-character public key"
+                "completed": {
+                    "type": "boolean",
+                    "example": false
                 },
-                "requestBody": {
-                    "type": "string",
-                    "example": "look at description for example"
+                "filesUpdated": {
+                    "type": "integer",
+                    "example": 120
                 },
-                "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
-                    "type": "string",
-                    "maxLength": 128,
-                    "minLength": 128,
-                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                "metadatasUpdated": {
+                    "type": "integer",
+                    "example": 8
                 }
             }
         },
-        "routes.deleteFileRes": {
-            "type": "object"
-        },
-        "routes.deleteFilesReq": {
+        "routes.filesListReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2615,7 +4148,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2623,37 +4156,19 @@ var doc = `{
                 }
             }
         },
-        "routes.deleteFilesRes": {
+        "routes.filesListRes": {
             "type": "object",
             "properties": {
-                "unsuccessfulDeletions": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.listedFile"
                     }
-                }
-            }
-        },
-        "routes.downloadFileRes": {
-            "type": "object",
-            "properties": {
-                "fileDownloadUrl": {
-                    "description": "Url should point to S3, thus client does not need to download it from this node.",
-                    "type": "string",
-                    "example": "a URL to use to download the file"
-                }
-            }
-        },
-        "routes.downloadPublicFileRes": {
-            "type": "object",
-            "properties": {
-                "fileDownloadThumbnailUrl": {
-                    "type": "string",
-                    "example": "a URL to use to download the public file thumbnail"
                 },
-                "fileDownloadUrl": {
+                "nextAfter": {
+                    "description": "NextAfter is the after of the next page, empty on the last page",
                     "type": "string",
-                    "example": "a URL to use to download the public file"
+                    "example": "the last file handle of this page"
                 }
             }
         },
@@ -2661,8 +4176,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2676,7 +4190,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2746,8 +4260,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2761,7 +4274,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2781,8 +4294,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2796,7 +4308,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2816,8 +4328,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2831,7 +4342,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2851,8 +4362,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2866,7 +4376,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2882,12 +4392,51 @@ var doc = `{
                 }
             }
         },
+        "routes.initUploadDirectRes": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "integer",
+                    "example": 1659325302
+                },
+                "partURLs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.directUploadPartURL"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "File is init. Please upload the parts to their URLs"
+                }
+            }
+        },
+        "routes.listedFile": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer",
+                    "example": 1557346389
+                },
+                "expiredAt": {
+                    "type": "integer",
+                    "example": 1659325302
+                },
+                "fileHandle": {
+                    "type": "string",
+                    "example": "a 64-char file handle"
+                },
+                "fileSizeInByte": {
+                    "type": "integer",
+                    "example": 200000000000006
+                }
+            }
+        },
         "routes.metadataKeyReq": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2901,7 +4450,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2913,8 +4462,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2928,7 +4476,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2940,8 +4488,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -2955,7 +4502,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -2975,6 +4522,137 @@ var doc = `{
                 }
             }
         },
+        "routes.publicSharePasswordReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.publicShareUnlockReq": {
+            "type": "object",
+            "required": [
+                "password",
+                "shortlink"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "example": "the password of the public share"
+                },
+                "shortlink": {
+                    "type": "string",
+                    "example": "the short link of the completed file"
+                }
+            }
+        },
+        "routes.publicShareUnlockRes": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string",
+                    "example": "a 64 character hex string to send in the X-Share-Access-Token header"
+                },
+                "expiresAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "routes.restoreFilesReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.restoreFilesRes": {
+            "type": "object",
+            "properties": {
+                "unsuccessfulRestores": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "routes.sessionCreateReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.sessionCreateRes": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "a 64 character hex string to send as 'Authorization: Bearer \u003ctoken\u003e'"
+                }
+            }
+        },
         "routes.stripeDataObj": {
             "type": "object",
             "properties": {
@@ -3010,6 +4688,10 @@ var doc = `{
                 "chargePaid": {
                     "type": "boolean"
                 },
+                "expiryPropagation": {
+                    "description": "ExpiryPropagation is how far the expiration of a renewal or upgrade was carried to the files and metadatas",
+                    "$ref": "#/definitions/routes.expiryPropagationRes"
+                },
                 "opctTxStatus": {
                     "type": "string"
                 },
@@ -3025,12 +4707,83 @@ var doc = `{
                 }
             }
         },
+        "routes.trashListReq": {
+            "type": "object",
+            "required": [
+                "publicKey",
+                "requestBody"
+            ],
+            "properties": {
+                "publicKey": {
+                    "type": "string",
+                    "maxLength": 66,
+                    "minLength": 66,
+                    "example": "a 66-character public key"
+                },
+                "requestBody": {
+                    "type": "string",
+                    "example": "look at description for example"
+                },
+                "signature": {
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 128,
+                    "example": "a 128 character string created when you signed the request with your private key or account handle"
+                }
+            }
+        },
+        "routes.trashListRes": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/routes.trashedFile"
+                    }
+                },
+                "nextAfter": {
+                    "description": "NextAfter is the after of the next page, empty on the last page",
+                    "type": "string",
+                    "example": "the last file handle of this page"
+                }
+            }
+        },
+        "routes.trashedFile": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer",
+                    "example": 1557346389
+                },
+                "expiredAt": {
+                    "type": "integer",
+                    "example": 1659325302
+                },
+                "fileHandle": {
+                    "type": "string",
+                    "example": "a 64-char file handle"
+                },
+                "fileSizeInByte": {
+                    "type": "integer",
+                    "example": 200000000000006
+                },
+                "purgeAt": {
+                    "description": "PurgeAt is when the file is deleted for good, unless restored before",
+                    "type": "integer",
+                    "example": 1559938389
+                },
+                "trashedAt": {
+                    "type": "integer",
+                    "example": 1557346389
+                }
+            }
+        },
         "routes.updateMetadataMultipleV2Req": {
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -3044,7 +4797,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -3055,8 +4808,7 @@ var doc = `{
         "routes.updateMetadataMultipleV2Res": {
             "type": "object",
             "required": [
-                "expirationDate",
-                "metadatas"
+                "expirationDate"
             ],
             "properties": {
                 "expirationDate": {
@@ -3080,8 +4832,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -3095,7 +4846,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -3128,8 +4879,7 @@ var doc = `{
             "type": "object",
             "required": [
                 "publicKey",
-                "requestBody",
-                "signature"
+                "requestBody"
             ],
             "properties": {
                 "publicKey": {
@@ -3143,7 +4893,7 @@ var doc = `{
                     "example": "look at description for example"
                 },
                 "signature": {
-                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]",
+                    "description": "signature without 0x prefix is broken into\nR: sig[0:63]\nS: sig[64:127]\nIt can be left out when the request carries a session token instead",
                     "type": "string",
                     "maxLength": 128,
                     "minLength": 128,
@@ -3194,6 +4944,10 @@ var doc = `{
             "properties": {
                 "count": {
                     "type": "integer"
+                },
+                "egressBytes": {
+                    "type": "integer",
+                    "example": 1024
                 }
             }
         },
@@ -3206,6 +4960,10 @@ var doc = `{
                 "costInUSD": {
                     "type": "number"
                 },
+                "maxEgressGBPerMonth": {
+                    "description": "0 doesn't limit downloads, see verifyFileOwnerEgressQuota for what it limits",
+                    "type": "integer"
+                },
                 "maxFolders": {
                     "type": "integer"
                 },
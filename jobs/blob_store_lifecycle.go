@@ -0,0 +1,26 @@
+package jobs
+
+import (
+	"github.com/opacity/storage-node/utils"
+)
+
+type blobStoreLifecycle struct{}
+
+func (e blobStoreLifecycle) Name() string {
+	return "blobStoreLifecycle"
+}
+
+func (e blobStoreLifecycle) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (e blobStoreLifecycle) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	err := utils.RunBlobStoreLifecycle()
+	utils.LogIfError(err, nil)
+}
+
+func (e blobStoreLifecycle) Runnable() bool {
+	return utils.BlobStoreEmulatesLifecycle()
+}
@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*egressLogIngester reads the S3 server access logs of the bucket appended to a local file and counts the
+bytes of the objects it served towards the egress usage of the accounts owning the files, and towards the
+public shares of the files*/
+type egressLogIngester struct{}
+
+// How many lines of the access log a run reads at most
+const egressLogLinesPerRun = 100000
+
+func (e egressLogIngester) Name() string {
+	return "egressLogIngester"
+}
+
+func (e egressLogIngester) ScheduleInterval() string {
+	return "@every 5m"
+}
+
+func (e egressLogIngester) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	path := utils.Env.S3AccessLogPath
+	if err := ingestAccessLog(path, egressLogLinesPerRun); err != nil {
+		utils.LogIfError(err, map[string]interface{}{"path": path})
+	}
+}
+
+func (e egressLogIngester) Runnable() bool {
+	return models.DB != nil && utils.Env.S3AccessLogPath != ""
+}
+
+/*ingestAccessLog counts the downloads of up to maxLines complete lines of the access log at path, starting
+where the previous run stopped. A log shorter than the saved offset was rotated and is read from the start.*/
+func ingestAccessLog(path string, maxLines int) error {
+	offset, err := models.GetAccessLogOffset(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	var egresses []models.DirectEgress
+	reader := bufio.NewReader(file)
+	for lines := 0; lines < maxLines; lines++ {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			// The rest of the line is still being written, the next run reads it
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(len(line))
+
+		entry, err := utils.ParseS3AccessLogLine(line)
+		if err != nil {
+			utils.LogIfError(fmt.Errorf("skipping access log line: %v", err), map[string]interface{}{"path": path})
+			continue
+		}
+		if egress, ok := directEgressFromAccessLog(entry); ok {
+			egresses = append(egresses, egress)
+		}
+	}
+
+	return models.RecordDirectEgress(path, offset, egresses)
+}
+
+/*directEgressFromAccessLog returns the egress of a download of an object of a file, reads made by the node
+itself are not downloads*/
+func directEgressFromAccessLog(entry utils.S3AccessLogEntry) (models.DirectEgress, bool) {
+	if !entry.IsObjectDownload() || isNodeRequester(entry.Requester) {
+		return models.DirectEgress{}, false
+	}
+	parts := strings.SplitN(entry.Key, "/", 2)
+	if len(parts) != 2 || len(parts[0]) != 64 {
+		return models.DirectEgress{}, false
+	}
+	fileID := parts[0]
+	return models.DirectEgress{
+		FileID: fileID,
+		Month:  models.EgressMonth(entry.Time),
+		Bytes:  entry.BytesSent,
+		Public: entry.Key == models.GetFileDataPublicKey(fileID),
+	}, true
+}
+
+/*isNodeRequester returns whether the requester of an access log line is one of the identities of the node*/
+func isNodeRequester(requester string) bool {
+	for _, nodeRequester := range strings.Split(utils.Env.S3AccessLogNodeRequesters, ",") {
+		if nodeRequester = strings.TrimSpace(nodeRequester); nodeRequester != "" && nodeRequester == requester {
+			return true
+		}
+	}
+	return false
+}
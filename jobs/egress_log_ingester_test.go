@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func accessLogLineForTest(operation, key string, status int, bytesSent int64) string {
+	return accessLogLineWithRequesterForTest("-", operation, key, status, bytesSent)
+}
+
+func accessLogLineWithRequesterForTest(requester, operation, key string, status int, bytesSent int64) string {
+	return fmt.Sprintf(`owner bucket [%s] 192.0.2.3 %s ID %s %s "GET /%s HTTP/1.1" %d - %d 100 10 10 "-" "agent" -`+"\n",
+		time.Now().UTC().Format("02/Jan/2006:15:04:05 -0700"), requester, operation, key, key, status, bytesSent)
+}
+
+func Test_Egress_Log_Ingester(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+	models.DeleteFileOwnersForTest(t)
+	models.DeleteEgressUsagesForTest(t)
+	models.DeleteAccessLogOffsetsForTest(t)
+	models.DeletePublicSharesForTest(t)
+
+	accountID := utils.RandHexString(64)
+	owned := createCompletedFileForScrubTest(t, 4)
+	assert.Nil(t, models.SetFileOwner(owned.FileID, accountID))
+	publicShare := models.PublicShare{PublicID: utils.RandSeqFromRunes(10, []rune("abcdef")), FileID: owned.FileID}
+	assert.Nil(t, models.DB.Create(&publicShare).Error)
+	notOwned := createCompletedFileForScrubTest(t, 4)
+
+	dir, err := ioutil.TempDir("", "access-logs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+
+	log := accessLogLineForTest("REST.GET.OBJECT", models.GetFileDataKey(owned.FileID), 200, 100) +
+		accessLogLineForTest("REST.GET.OBJECT", models.GetFileDataPublicKey(owned.FileID), 206, 30) +
+		accessLogLineForTest("REST.HEAD.OBJECT", models.GetFileDataKey(owned.FileID), 200, 0) +
+		accessLogLineForTest("REST.GET.OBJECT", models.GetFileDataKey(owned.FileID), 403, 200) +
+		accessLogLineForTest("REST.GET.OBJECT", models.GetFileDataKey(notOwned.FileID), 200, 1000) +
+		"garbage\n"
+	assert.Nil(t, ioutil.WriteFile(path, []byte(log), 0644))
+
+	assert.Nil(t, ingestAccessLog(path, egressLogLinesPerRun))
+
+	month := models.EgressMonth(time.Now())
+	egressUsage, err := models.GetEgressUsage(accountID, month)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(130), egressUsage.DirectBytes)
+	publicShare, err = models.GetPublicShareByID(publicShare.PublicID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(30), publicShare.EgressBytes)
+	offset, err := models.GetAccessLogOffset(path)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(log)), offset)
+
+	// Lines already read are not counted again, an incomplete line waits for the next run
+	partial := accessLogLineForTest("REST.GET.OBJECT", models.GetFileMetadataKey(owned.FileID), 200, 7)
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = file.WriteString(partial[:10])
+	assert.Nil(t, err)
+	assert.Nil(t, ingestAccessLog(path, egressLogLinesPerRun))
+	_, err = file.WriteString(partial[10:])
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	assert.Nil(t, ingestAccessLog(path, egressLogLinesPerRun))
+
+	egressUsage, err = models.GetEgressUsage(accountID, month)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(137), egressUsage.DirectBytes)
+}
+
+func Test_Egress_Log_Ingester_Skips_The_Node(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+	models.DeleteFileOwnersForTest(t)
+	models.DeleteEgressUsagesForTest(t)
+	models.DeleteAccessLogOffsetsForTest(t)
+
+	nodeRequester := "arn:aws:iam::123456789012:user/storage-node"
+	defaultRequesters := utils.Env.S3AccessLogNodeRequesters
+	utils.Env.S3AccessLogNodeRequesters = "arn:aws:iam::123456789012:user/other-node, " + nodeRequester
+	defer func() {
+		utils.Env.S3AccessLogNodeRequesters = defaultRequesters
+	}()
+
+	accountID := utils.RandHexString(64)
+	owned := createCompletedFileForScrubTest(t, 4)
+	assert.Nil(t, models.SetFileOwner(owned.FileID, accountID))
+
+	dir, err := ioutil.TempDir("", "access-logs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.log")
+
+	// The node streaming the file, reading it back or converting it to a public one doesn't download it
+	log := accessLogLineWithRequesterForTest(nodeRequester, "REST.GET.OBJECT", models.GetFileDataKey(owned.FileID), 200, 1000) +
+		accessLogLineWithRequesterForTest("arn:aws:iam::123456789012:user/someone", "REST.GET.OBJECT",
+			models.GetFileDataKey(owned.FileID), 200, 20) +
+		accessLogLineForTest("REST.GET.OBJECT", models.GetFileDataKey(owned.FileID), 200, 3)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(log), 0644))
+
+	assert.Nil(t, ingestAccessLog(path, egressLogLinesPerRun))
+
+	egressUsage, err := models.GetEgressUsage(accountID, models.EgressMonth(time.Now()))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(23), egressUsage.DirectBytes)
+}
@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type expiryPropagator struct{}
+
+// How many files, then metadatas, of an account are updated at once
+const expiryPropagationBatchSize = 500
+
+// How many propagations are picked up by a run
+const expiryPropagationsPerRun = 100
+
+// How many batches a propagation gets in a run, so that one large account doesn't hold up the others
+const expiryPropagationBatchesPerRun = 20
+
+func (e expiryPropagator) Name() string {
+	return "expiryPropagator"
+}
+
+func (e expiryPropagator) ScheduleInterval() string {
+	return "@every 1m"
+}
+
+func (e expiryPropagator) Run() {
+	expiryPropagations, err := models.GetExpiryPropagationsInProgress(expiryPropagationsPerRun)
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+
+	for _, expiryPropagation := range expiryPropagations {
+		for i := 0; i < expiryPropagationBatchesPerRun; i++ {
+			done, err := expiryPropagation.PropagateBatch(expiryPropagationBatchSize)
+			if err != nil {
+				utils.LogIfError(err, map[string]interface{}{"accountID": expiryPropagation.AccountID})
+				break
+			}
+			if done {
+				break
+			}
+		}
+	}
+}
+
+func (e expiryPropagator) Runnable() bool {
+	return models.DB != nil
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type integrityScrubber struct{}
+
+// How many completed files are read from the database at once
+var integrityScrubBatchSize = 500
+
+// File ID of the last completed file checked, the next run starts after it
+var integrityScrubCursor string
+
+func (e integrityScrubber) Name() string {
+	return "integrityScrubber"
+}
+
+func (e integrityScrubber) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (e integrityScrubber) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	scrubbed, issues, failed := 0, 0, 0
+	for scrubbed < utils.Env.IntegrityScrubFilesPerRun {
+		completedFiles, err := models.GetCompletedFilesAfter(integrityScrubCursor, integrityScrubBatchSize)
+		if err != nil {
+			utils.LogIfError(err, nil)
+			break
+		}
+
+		for _, completedFile := range completedFiles {
+			// The cursor moves past files that can't be checked too, they are retried on the next pass
+			integrityScrubCursor = completedFile.FileID
+			scrubbed++
+
+			found, err := scrubCompletedFile(completedFile)
+			if err != nil {
+				utils.LogIfError(err, map[string]interface{}{"fileID": completedFile.FileID})
+				failed++
+				continue
+			}
+			issues += len(found)
+			utils.Metrics_Integrity_Files_Scrubbed_Counter.Inc()
+		}
+
+		if len(completedFiles) < integrityScrubBatchSize {
+			// Every completed file was checked, the next run starts over
+			integrityScrubCursor = ""
+			utils.LogIfError(models.ResolveIntegrityReportsOfDeletedFiles(), nil)
+			utils.Metrics_Integrity_Last_Pass_Timestamp.Set(float64(time.Now().Unix()))
+			break
+		}
+	}
+
+	if issues > 0 {
+		utils.SlackLogError(fmt.Sprintf("%s found %d issues in %d completed files", e.Name(), issues, scrubbed))
+	}
+	if failed > 0 {
+		utils.SlackLogError(fmt.Sprintf("%s could not check %d of %d completed files", e.Name(), failed, scrubbed))
+	}
+
+	updateIntegrityMetrics()
+}
+
+func (e integrityScrubber) Runnable() bool {
+	return models.DB != nil && utils.IsBlobStoreEnabled()
+}
+
+/*scrubCompletedFile checks the objects of the completed file are in the bucket with the right size,
+keeps a report of each issue found and resolves the issues it doesn't have anymore*/
+func scrubCompletedFile(completedFile models.CompletedFile) ([]string, error) {
+	var issues []string
+
+	dataSize, dataExists, err := utils.HeadDefaultBucketObject(models.GetFileDataKey(completedFile.FileID))
+	if err != nil {
+		return nil, err
+	}
+	_, metadataExists, err := utils.HeadDefaultBucketObject(models.GetFileMetadataKey(completedFile.FileID))
+	if err != nil {
+		return nil, err
+	}
+
+	if !dataExists {
+		issues = append(issues, models.IntegrityIssueDataMissing)
+		if err := models.RecordIntegrityIssue(completedFile.FileID, models.IntegrityIssueDataMissing, completedFile.FileSizeInByte, 0); err != nil {
+			return nil, err
+		}
+	} else if completedFile.FileSizeInByte != 0 && dataSize != completedFile.FileSizeInByte {
+		issues = append(issues, models.IntegrityIssueSizeMismatch)
+		if err := models.RecordIntegrityIssue(completedFile.FileID, models.IntegrityIssueSizeMismatch, completedFile.FileSizeInByte, dataSize); err != nil {
+			return nil, err
+		}
+	}
+	if !metadataExists {
+		issues = append(issues, models.IntegrityIssueMetadataMissing)
+		if err := models.RecordIntegrityIssue(completedFile.FileID, models.IntegrityIssueMetadataMissing, 0, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	return issues, models.ResolveIntegrityIssues(completedFile.FileID, issues)
+}
+
+func updateIntegrityMetrics() {
+	counts, err := models.CountOpenIntegrityReports()
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+	for issue, count := range counts {
+		utils.Metrics_Integrity_Open_Issues.WithLabelValues(issue).Set(float64(count))
+	}
+}
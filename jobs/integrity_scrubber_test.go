@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCompletedFileForScrubTest(t *testing.T, fileSizeInByte int64) models.CompletedFile {
+	completedFile := models.CompletedFile{
+		FileID:         utils.GenerateFileHandle(),
+		ModifierHash:   utils.GenerateFileHandle(),
+		FileSizeInByte: fileSizeInByte,
+	}
+	assert.Nil(t, models.DB.Create(&completedFile).Error)
+	return completedFile
+}
+
+/*failingHeadBlobStore fails every HeadObject, so no file can be checked*/
+type failingHeadBlobStore struct {
+	utils.BlobStore
+}
+
+func (failingHeadBlobStore) HeadObject(key string) (*s3.HeadObjectOutput, error) {
+	return nil, errors.New("head failed")
+}
+
+func Test_Integrity_Scrubber(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+	models.DeleteIntegrityReportsForTest(t)
+	integrityScrubCursor = ""
+
+	healthy := createCompletedFileForScrubTest(t, 4)
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataKey(healthy.FileID), "data", ""))
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileMetadataKey(healthy.FileID), "metadata", ""))
+
+	truncated := createCompletedFileForScrubTest(t, 100)
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataKey(truncated.FileID), "data", ""))
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileMetadataKey(truncated.FileID), "metadata", ""))
+
+	missing := createCompletedFileForScrubTest(t, 4)
+
+	integrityScrubber{}.Run()
+
+	counts, err := models.CountOpenIntegrityReports()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, counts[models.IntegrityIssueDataMissing])
+	assert.Equal(t, 1, counts[models.IntegrityIssueMetadataMissing])
+	assert.Equal(t, 1, counts[models.IntegrityIssueSizeMismatch])
+	assert.Equal(t, float64(1), utils.GetMetricGauge(utils.Metrics_Integrity_Open_Issues.WithLabelValues(models.IntegrityIssueSizeMismatch)))
+	assert.Equal(t, "", integrityScrubCursor)
+
+	// The next pass resolves the issues that were fixed
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataKey(missing.FileID), "data", ""))
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileMetadataKey(missing.FileID), "metadata", ""))
+
+	integrityScrubber{}.Run()
+
+	reports, err := models.GetOpenIntegrityReports(10)
+	assert.Nil(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, truncated.FileID, reports[0].FileID)
+	assert.Equal(t, int64(4), reports[0].ActualSize)
+
+	for _, fileID := range []string{healthy.FileID, truncated.FileID, missing.FileID} {
+		utils.DeleteDefaultBucketObject(models.GetFileDataKey(fileID))
+		utils.DeleteDefaultBucketObject(models.GetFileMetadataKey(fileID))
+	}
+}
+
+func Test_Integrity_Scrubber_Moves_Past_Failures(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+	models.DeleteIntegrityReportsForTest(t)
+	integrityScrubCursor = ""
+
+	store := utils.GetBlobStore()
+	utils.SetBlobStore(failingHeadBlobStore{store})
+	defer utils.SetBlobStore(store)
+	batchSize := integrityScrubBatchSize
+	integrityScrubBatchSize = 1
+	defer func() { integrityScrubBatchSize = batchSize }()
+
+	createCompletedFileForScrubTest(t, 4)
+	createCompletedFileForScrubTest(t, 4)
+
+	// Every batch is full and fails, the run must still get to the end of the files
+	integrityScrubber{}.Run()
+
+	assert.Equal(t, "", integrityScrubCursor)
+	counts, err := models.CountOpenIntegrityReports()
+	assert.Nil(t, err)
+	assert.Empty(t, counts)
+}
@@ -57,6 +57,18 @@ func ScheduleBackgroundJobs() {
 		upgradeDeleter{},
 		renewalDeleter{},
 		expiredAccountDeleter{},
+		blobStoreLifecycle{},
+		kvStoreBackup{},
+		kvStoreGC{},
+		integrityScrubber{},
+		reconciler{},
+		usageRecomputer{},
+		expiryPropagator{},
+		trashPurger{},
+		privateAclMigrator{},
+		egressLogIngester{},
+		publicShareExpirer{},
+		sha256Verifier{},
 	}
 
 	for _, s := range jobs {
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/utils"
+)
+
+type kvStoreBackup struct{}
+
+func (e kvStoreBackup) Name() string {
+	return "kvStoreBackup"
+}
+
+func (e kvStoreBackup) ScheduleInterval() string {
+	return utils.Env.KvBackupInterval
+}
+
+func (e kvStoreBackup) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	backup, err := utils.BackupKvStoreToBlobStore()
+	if err != nil {
+		utils.SlackLogError(fmt.Sprintf("kv store backup failed: %v", err))
+		utils.GetLogger("jobs-kv-store-backup").Errorf("Some error occur on backing up the kv store: %v", err)
+		return
+	}
+
+	utils.SlackLog(fmt.Sprintf("kv store backed up to %s (%d bytes)", backup.Key, backup.Size))
+}
+
+func (e kvStoreBackup) Runnable() bool {
+	return utils.GetBadgerDb() != nil && utils.IsBlobStoreEnabled()
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/utils"
+)
+
+type kvStoreGC struct{}
+
+// Size of the kv store after the previous run, to detect abnormal growth
+var kvStoreLastSize int64
+
+func (e kvStoreGC) Name() string {
+	return "kvStoreGC"
+}
+
+func (e kvStoreGC) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (e kvStoreGC) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	rewrites, err := utils.RunKvStoreValueLogGC(utils.Env.KvGCDiscardRatio)
+	if err != nil {
+		utils.GetLogger("jobs-kv-store-gc").Errorf("Some error occur on collecting the value log garbage: %v", err)
+	}
+
+	stats, err := utils.GetKvStoreStats()
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+	utils.SlackLog(fmt.Sprintf("kv store value log GC rewrote %d files, lsm size: %d bytes, vlog size: %d bytes, keys: %d",
+		rewrites, stats.LsmSize, stats.VlogSize, stats.Keys))
+
+	if kvStoreGrewTooMuch(kvStoreLastSize, stats.TotalSize(), utils.Env.KvGrowthAlertPercent) {
+		utils.SlackLogError(fmt.Sprintf("kv store grew from %d to %d bytes since the last GC run, more than the %d%% threshold",
+			kvStoreLastSize, stats.TotalSize(), utils.Env.KvGrowthAlertPercent))
+	}
+	kvStoreLastSize = stats.TotalSize()
+}
+
+func (e kvStoreGC) Runnable() bool {
+	return utils.GetBadgerDb() != nil
+}
+
+func kvStoreGrewTooMuch(previousSize, size int64, thresholdPercent int) bool {
+	if previousSize <= 0 || thresholdPercent <= 0 {
+		return false
+	}
+	return (size-previousSize)*100 > previousSize*int64(thresholdPercent)
+}
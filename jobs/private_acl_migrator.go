@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*privateAclMigrator reverts the public-read ACL the node used to set on the data and metadata objects of
+completed files when they were uploaded, those are now read through presigned URLs. The v1 and v2 download
+routes still make the objects of the files they return public for the clients that haven't moved to v3.*/
+type privateAclMigrator struct{}
+
+// How many completed files are read from the database at once
+const privateAclMigrationBatchSize = 500
+
+// How many completed files a run makes private
+const privateAclMigrationFilesPerRun = 10000
+
+var (
+	// File ID of the last completed file made private, the next run starts after it
+	privateAclMigrationCursor string
+	// Set once every completed file was made private, files completed since then never were public.
+	// A restart of the node goes through them again, which is harmless.
+	privateAclMigrationDone bool
+)
+
+func (e privateAclMigrator) Name() string {
+	return "privateAclMigrator"
+}
+
+func (e privateAclMigrator) ScheduleInterval() string {
+	return "@every 10m"
+}
+
+func (e privateAclMigrator) Run() {
+	if privateAclMigrationDone {
+		return
+	}
+	utils.SlackLog("running " + e.Name())
+
+	migrated := 0
+	for migrated < privateAclMigrationFilesPerRun {
+		completedFiles, err := models.GetCompletedFilesAfter(privateAclMigrationCursor, privateAclMigrationBatchSize)
+		if err != nil {
+			utils.LogIfError(err, nil)
+			return
+		}
+
+		for _, completedFile := range completedFiles {
+			if err := models.MakeCompletedFileObjectsPrivate(completedFile.FileID); err != nil {
+				// Stop here so that the next run tries this file again
+				utils.LogIfError(err, map[string]interface{}{"fileID": completedFile.FileID})
+				return
+			}
+			privateAclMigrationCursor = completedFile.FileID
+			migrated++
+		}
+
+		if len(completedFiles) < privateAclMigrationBatchSize {
+			privateAclMigrationDone = true
+			utils.SlackLog(fmt.Sprintf("%s made the objects of every completed file private", e.Name()))
+			return
+		}
+	}
+}
+
+func (e privateAclMigrator) Runnable() bool {
+	return models.DB != nil && utils.IsBlobStoreEnabled()
+}
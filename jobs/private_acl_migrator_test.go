@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Private_Acl_Migrator(t *testing.T) {
+	fake, ok := utils.GetBlobStore().(*utils.S3Fake)
+	if !ok {
+		t.Skip("needs the fake blob store to read the ACLs")
+	}
+	models.DeleteCompletedFilesForTest(t)
+	privateAclMigrationCursor = ""
+	privateAclMigrationDone = false
+
+	completedFile := createCompletedFileForScrubTest(t, 4)
+	for _, key := range []string{models.GetFileDataKey(completedFile.FileID), models.GetFileMetadataKey(completedFile.FileID)} {
+		assert.Nil(t, utils.SetDefaultBucketObject(key, "data", ""))
+		assert.Nil(t, utils.SetDefaultObjectCannedAcl(key, utils.CannedAcl_PublicRead))
+	}
+	// Its objects are missing, it doesn't hold up the others
+	createCompletedFileForScrubTest(t, 4)
+
+	privateAclMigrator{}.Run()
+
+	assert.True(t, privateAclMigrationDone)
+	for _, key := range []string{models.GetFileDataKey(completedFile.FileID), models.GetFileMetadataKey(completedFile.FileID)} {
+		cannedAcl, err := fake.GetObjectCannedAcl(key)
+		assert.Nil(t, err)
+		assert.Equal(t, utils.CannedAcl_Private, cannedAcl)
+	}
+}
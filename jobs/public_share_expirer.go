@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*publicShareExpirer removes the public shares that expired or were viewed as many times as allowed, and the
+public objects of the files no public share refers to anymore. The public objects of the files still shared are
+made readable by anyone again once no share left limits them*/
+type publicShareExpirer struct{}
+
+// How many public shares a run removes at most
+const publicShareExpirerBatchSize = 1000
+
+func (e publicShareExpirer) Name() string {
+	return "publicShareExpirer"
+}
+
+func (e publicShareExpirer) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (e publicShareExpirer) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	publicShares, err := models.GetExhaustedPublicShares(publicShareExpirerBatchSize)
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+
+	fileIDs := make([]string, 0, len(publicShares))
+	for _, publicShare := range publicShares {
+		if err := publicShare.RemovePublicShare(); err != nil {
+			utils.LogIfError(err, map[string]interface{}{"publicID": publicShare.PublicID})
+			continue
+		}
+		fileIDs = append(fileIDs, publicShare.FileID)
+	}
+
+	// Files can have several public shares, the objects stay while one of them is left
+	stillShared, err := models.GetExistingPublicShareFileIDs(fileIDs)
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+	var publicKeys []string
+	unshared := make(map[string]bool)
+	synced := make(map[string]bool)
+	for _, fileID := range fileIDs {
+		if stillShared[fileID] {
+			// The share that kept the public objects private may be gone
+			if !synced[fileID] {
+				synced[fileID] = true
+				utils.LogIfError(models.SyncPublicFileAcl(fileID), map[string]interface{}{"fileID": fileID})
+			}
+			continue
+		}
+		if unshared[fileID] {
+			continue
+		}
+		unshared[fileID] = true
+		publicKeys = append(publicKeys, models.GetFileDataPublicKey(fileID), models.GetPublicThumbnailKey(fileID))
+	}
+
+	if len(publicKeys) > 0 {
+		utils.LogIfError(utils.DeleteDefaultBucketObjects(publicKeys), nil)
+	}
+}
+
+func (e publicShareExpirer) Runnable() bool {
+	return models.DB != nil
+}
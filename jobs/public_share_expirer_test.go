@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createPublicShareForExpirerTest(t *testing.T, fileID string, expiresAt *time.Time, maxViews, viewsCount int) models.PublicShare {
+	publicShare := models.CreatePublicShareObj()
+	publicShare.FileID = fileID
+	publicShare.ExpiresAt = expiresAt
+	publicShare.MaxViews = maxViews
+	publicShare.ViewsCount = viewsCount
+	assert.Nil(t, models.DB.Create(&publicShare).Error)
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataPublicKey(fileID), "public", ""))
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetPublicThumbnailKey(fileID), "thumbnail", ""))
+	return publicShare
+}
+
+func Test_Public_Share_Expirer(t *testing.T) {
+	models.DeletePublicSharesForTest(t)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	expired := createPublicShareForExpirerTest(t, utils.GenerateFileHandle(), &past, 0, 0)
+	viewedOut := createPublicShareForExpirerTest(t, utils.GenerateFileHandle(), nil, 3, 3)
+	live := createPublicShareForExpirerTest(t, utils.GenerateFileHandle(), &future, 3, 2)
+	// The file of this expired share has another share left, its objects are kept
+	expiredOfShared := createPublicShareForExpirerTest(t, live.FileID, &past, 0, 0)
+
+	publicShareExpirer{}.Run()
+
+	for _, publicShare := range []models.PublicShare{expired, viewedOut, expiredOfShared} {
+		_, err := models.GetPublicShareByID(publicShare.PublicID)
+		assert.True(t, gorm.IsRecordNotFoundError(err))
+	}
+	_, err := models.GetPublicShareByID(live.PublicID)
+	assert.Nil(t, err)
+
+	for _, fileID := range []string{expired.FileID, viewedOut.FileID} {
+		assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataPublicKey(fileID)))
+		assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetPublicThumbnailKey(fileID)))
+	}
+	assert.True(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataPublicKey(live.FileID)))
+}
+
+func Test_Public_Share_Expirer_Opens_Objects_No_Share_Limits_Anymore(t *testing.T) {
+	models.DeletePublicSharesForTest(t)
+	past := time.Now().Add(-time.Hour)
+
+	open := createPublicShareForExpirerTest(t, utils.GenerateFileHandle(), nil, 0, 0)
+	createPublicShareForExpirerTest(t, open.FileID, &past, 0, 0)
+	assert.Nil(t, models.SyncPublicFileAcl(open.FileID))
+	acl, err := utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(models.GetFileDataPublicKey(open.FileID))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.CannedAcl_Private, acl)
+
+	publicShareExpirer{}.Run()
+
+	acl, err = utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(models.GetFileDataPublicKey(open.FileID))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.CannedAcl_PublicRead, acl)
+}
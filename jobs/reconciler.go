@@ -0,0 +1,297 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type reconciler struct{}
+
+// Objects written more recently than this may belong to a row that is about to be created
+var reconcileGracePeriod = time.Hour
+
+// How many rows are read from the database at once
+const reconcileBatchSize = 1000
+
+// The objects kept in the bucket for a file, in the order of reconcileObjectSuffixes
+const (
+	reconcileObjectData = 1 << iota
+	reconcileObjectMetadata
+	reconcileObjectPublic
+	reconcileObjectThumbnail
+	reconcileObjectTail
+)
+
+// The part of the keys of the objects after the file ID
+var reconcileObjectSuffixes = []string{"file", "metadata", "public", "thumbnail", "tail"}
+
+/*ReconcileReport is what a reconciliation of the bucket with the database found*/
+type ReconcileReport struct {
+	ObjectsChecked int
+	RowsChecked    int
+	/*OrphanObjects are the keys of the objects no row refers to*/
+	OrphanObjects []string
+	/*CompletedFilesMissingObjects are the keys of objects of completed files that are not in the bucket*/
+	CompletedFilesMissingObjects []string
+	/*UploadsMissingObjects are the keys of objects of uploads in progress that are not in the bucket*/
+	UploadsMissingObjects []string
+	/*PublicSharesMissingObjects are the keys of objects of public shares that are not in the bucket*/
+	PublicSharesMissingObjects []string
+}
+
+func (e reconciler) Name() string {
+	return "reconciler"
+}
+
+func (e reconciler) ScheduleInterval() string {
+	return "@weekly"
+}
+
+/*Run only reports what it finds, the repairs are left to the reconcile command*/
+func (e reconciler) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	report, err := Reconcile(false)
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+
+	utils.Metrics_Reconcile_Orphan_Objects.Set(float64(len(report.OrphanObjects)))
+	utils.Metrics_Reconcile_Missing_Objects.Set(float64(len(report.CompletedFilesMissingObjects) +
+		len(report.UploadsMissingObjects) + len(report.PublicSharesMissingObjects)))
+
+	utils.SlackLog(report.Summary())
+	if len(report.OrphanObjects) > 0 || len(report.CompletedFilesMissingObjects) > 0 {
+		utils.SlackLogError(e.Name() + " found orphans, run the reconcile command to look at them")
+	}
+}
+
+func (e reconciler) Runnable() bool {
+	return models.DB != nil && utils.IsBlobStoreEnabled()
+}
+
+/*Summary is a one line description of the report*/
+func (report ReconcileReport) Summary() string {
+	return fmt.Sprintf("checked %d objects and %d rows: %d orphan objects, %d missing objects of completed files, "+
+		"%d of uploads and %d of public shares", report.ObjectsChecked, report.RowsChecked, len(report.OrphanObjects),
+		len(report.CompletedFilesMissingObjects), len(report.UploadsMissingObjects), len(report.PublicSharesMissingObjects))
+}
+
+/*Reconcile diffs the objects of the bucket with the CompletedFile, File and PublicShare rows. Unless repair
+is set it changes nothing; otherwise the orphan objects are deleted and the completed files missing objects
+get an integrity report.*/
+func Reconcile(repair bool) (ReconcileReport, error) {
+	report := ReconcileReport{}
+	startedAt := time.Now()
+
+	objectsByFileID, err := reconcileBucketObjects(&report, startedAt)
+	if err != nil {
+		return report, err
+	}
+	if err := reconcileCompletedFiles(&report, objectsByFileID, startedAt); err != nil {
+		return report, err
+	}
+	if err := reconcileUploads(&report, objectsByFileID, startedAt); err != nil {
+		return report, err
+	}
+	if err := reconcilePublicShares(&report, objectsByFileID, startedAt); err != nil {
+		return report, err
+	}
+
+	if !repair {
+		return report, nil
+	}
+
+	if len(report.OrphanObjects) > 0 {
+		if err := utils.DeleteDefaultBucketObjects(report.OrphanObjects); err != nil {
+			return report, err
+		}
+	}
+	for _, key := range report.CompletedFilesMissingObjects {
+		fileID, object := splitReconcileObjectKey(key)
+		issue := models.IntegrityIssueDataMissing
+		if object == reconcileObjectMetadata {
+			issue = models.IntegrityIssueMetadataMissing
+		}
+		if err := models.RecordIntegrityIssue(fileID, issue, 0, 0); err != nil {
+			return report, err
+		}
+	}
+	updateIntegrityMetrics()
+
+	return report, nil
+}
+
+/*splitReconcileObjectKey returns the file ID and the kind of object of keys laid out by the file routes,
+and 0 for any other key (kv store backups, free uploads...)*/
+func splitReconcileObjectKey(key string) (string, int) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 2 || len(parts[0]) != 64 {
+		return "", 0
+	}
+	for i, suffix := range reconcileObjectSuffixes {
+		if parts[1] == suffix {
+			return parts[0], 1 << i
+		}
+	}
+	return "", 0
+}
+
+/*reconcileBucketObjects lists the objects of the bucket, reports those no row refers to and returns the
+objects found for each file ID*/
+func reconcileBucketObjects(report *ReconcileReport, startedAt time.Time) (map[string]int, error) {
+	objectsByFileID := make(map[string]int)
+	var pageErr error
+
+	err := utils.IterateDefaultBucketAllObjects(func(objects []*s3.Object) bool {
+		var fileIDs []string
+		candidates := make(map[string]int)
+		for _, object := range objects {
+			report.ObjectsChecked++
+			key := aws.StringValue(object.Key)
+			fileID, kind := splitReconcileObjectKey(key)
+			if kind == 0 {
+				continue
+			}
+			objectsByFileID[fileID] |= kind
+			if aws.TimeValue(object.LastModified).After(startedAt.Add(-reconcileGracePeriod)) {
+				continue
+			}
+			if _, ok := candidates[fileID]; !ok {
+				fileIDs = append(fileIDs, fileID)
+			}
+			candidates[fileID] |= kind
+		}
+		if len(fileIDs) == 0 {
+			return true
+		}
+
+		completedFiles, err := models.GetExistingCompletedFileIDs(fileIDs)
+		if err != nil {
+			pageErr = err
+			return false
+		}
+		uploads, err := models.GetExistingFileIDs(fileIDs)
+		if err != nil {
+			pageErr = err
+			return false
+		}
+		publicShares, err := models.GetExistingPublicShareFileIDs(fileIDs)
+		if err != nil {
+			pageErr = err
+			return false
+		}
+
+		for _, fileID := range fileIDs {
+			if uploads[fileID] {
+				continue
+			}
+			orphanKinds := reconcileObjectTail
+			if !completedFiles[fileID] {
+				orphanKinds |= reconcileObjectData | reconcileObjectMetadata
+			}
+			if !publicShares[fileID] {
+				orphanKinds |= reconcileObjectPublic | reconcileObjectThumbnail
+			}
+			report.OrphanObjects = append(report.OrphanObjects, reconcileObjectKeys(fileID, candidates[fileID]&orphanKinds)...)
+		}
+		return true
+	})
+	if pageErr != nil {
+		return objectsByFileID, pageErr
+	}
+	return objectsByFileID, err
+}
+
+/*reconcileObjectKeys returns the keys of the kinds of objects of the file*/
+func reconcileObjectKeys(fileID string, kinds int) []string {
+	var keys []string
+	for i, suffix := range reconcileObjectSuffixes {
+		if kinds&(1<<i) != 0 {
+			keys = append(keys, fileID+"/"+suffix)
+		}
+	}
+	return keys
+}
+
+func reconcileCompletedFiles(report *ReconcileReport, objectsByFileID map[string]int, startedAt time.Time) error {
+	cursor := ""
+	for {
+		completedFiles, err := models.GetCompletedFilesAfter(cursor, reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, completedFile := range completedFiles {
+			cursor = completedFile.FileID
+			if completedFile.CreatedAt.After(startedAt) {
+				continue
+			}
+			report.RowsChecked++
+			missing := (reconcileObjectData | reconcileObjectMetadata) &^ objectsByFileID[completedFile.FileID]
+			report.CompletedFilesMissingObjects = append(report.CompletedFilesMissingObjects,
+				reconcileObjectKeys(completedFile.FileID, missing)...)
+		}
+		if len(completedFiles) < reconcileBatchSize {
+			return nil
+		}
+	}
+}
+
+/*reconcileUploads checks the metadata of uploads in progress, their data is only in the bucket once completed*/
+func reconcileUploads(report *ReconcileReport, objectsByFileID map[string]int, startedAt time.Time) error {
+	cursor := ""
+	for {
+		files, err := models.GetFilesAfter(cursor, reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			cursor = file.FileID
+			if file.CreatedAt.After(startedAt) {
+				continue
+			}
+			report.RowsChecked++
+			// Public uploads have no metadata object
+			if aws.StringValue(file.AwsObjectKey) != models.GetFileDataKey(file.FileID) {
+				continue
+			}
+			if objectsByFileID[file.FileID]&reconcileObjectMetadata == 0 {
+				report.UploadsMissingObjects = append(report.UploadsMissingObjects, models.GetFileMetadataKey(file.FileID))
+			}
+		}
+		if len(files) < reconcileBatchSize {
+			return nil
+		}
+	}
+}
+
+func reconcilePublicShares(report *ReconcileReport, objectsByFileID map[string]int, startedAt time.Time) error {
+	cursor := ""
+	for {
+		publicShares, err := models.GetPublicSharesAfter(cursor, reconcileBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, publicShare := range publicShares {
+			cursor = publicShare.PublicID
+			if publicShare.CreatedAt.After(startedAt) {
+				continue
+			}
+			report.RowsChecked++
+			if objectsByFileID[publicShare.FileID]&reconcileObjectPublic == 0 {
+				report.PublicSharesMissingObjects = append(report.PublicSharesMissingObjects,
+					models.GetFileDataPublicKey(publicShare.FileID))
+			}
+		}
+		if len(publicShares) < reconcileBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SplitReconcileObjectKey(t *testing.T) {
+	fileID := utils.GenerateFileHandle()
+
+	id, kind := splitReconcileObjectKey(models.GetFileDataKey(fileID))
+	assert.Equal(t, fileID, id)
+	assert.Equal(t, reconcileObjectData, kind)
+
+	_, kind = splitReconcileObjectKey(models.GetFileUploadTailKey(fileID))
+	assert.Equal(t, reconcileObjectTail, kind)
+
+	_, kind = splitReconcileObjectKey(utils.KvBackupPrefix + "backup.bak")
+	assert.Equal(t, 0, kind)
+	_, kind = splitReconcileObjectKey(fileID + "/unknown")
+	assert.Equal(t, 0, kind)
+
+	assert.Equal(t, []string{models.GetFileDataKey(fileID), models.GetFileMetadataKey(fileID)},
+		reconcileObjectKeys(fileID, reconcileObjectData|reconcileObjectMetadata))
+}
+
+func Test_Reconcile(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+	models.DeleteFilesForTest(t)
+	models.DeletePublicSharesForTest(t)
+	models.DeleteIntegrityReportsForTest(t)
+
+	setObject := func(key string) {
+		assert.Nil(t, utils.SetDefaultBucketObject(key, "data", ""))
+	}
+
+	healthy := createCompletedFileForScrubTest(t, 4)
+	setObject(models.GetFileDataKey(healthy.FileID))
+	setObject(models.GetFileMetadataKey(healthy.FileID))
+
+	missingData := createCompletedFileForScrubTest(t, 4)
+	setObject(models.GetFileMetadataKey(missingData.FileID))
+
+	upload := models.File{
+		FileID:       utils.GenerateFileHandle(),
+		EndIndex:     1,
+		ModifierHash: utils.GenerateFileHandle(),
+	}
+	upload.AwsObjectKey = aws.String(models.GetFileDataKey(upload.FileID))
+	assert.Nil(t, models.DB.Create(&upload).Error)
+	setObject(models.GetFileMetadataKey(upload.FileID))
+	setObject(models.GetFileUploadTailKey(upload.FileID))
+
+	orphanID := utils.GenerateFileHandle()
+	setObject(models.GetFileDataKey(orphanID))
+	setObject(models.GetPublicThumbnailKey(orphanID))
+
+	// The objects were just written, they are all in the grace period
+	report, err := Reconcile(false)
+	assert.Nil(t, err)
+	assert.Empty(t, report.OrphanObjects)
+
+	reconcileGracePeriod = 0
+	defer func() { reconcileGracePeriod = time.Hour }()
+
+	report, err = Reconcile(false)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{models.GetFileDataKey(orphanID), models.GetPublicThumbnailKey(orphanID)}, report.OrphanObjects)
+	assert.Equal(t, []string{models.GetFileDataKey(missingData.FileID)}, report.CompletedFilesMissingObjects)
+	assert.Empty(t, report.UploadsMissingObjects)
+	assert.Empty(t, report.PublicSharesMissingObjects)
+
+	// A dry run changes nothing
+	assert.True(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(orphanID)))
+
+	_, err = Reconcile(true)
+	assert.Nil(t, err)
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(orphanID)))
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetPublicThumbnailKey(orphanID)))
+	assert.True(t, utils.DoesDefaultBucketObjectExist(models.GetFileUploadTailKey(upload.FileID)))
+
+	reports, err := models.GetOpenIntegrityReports(10)
+	assert.Nil(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, missingData.FileID, reports[0].FileID)
+	assert.Equal(t, models.IntegrityIssueDataMissing, reports[0].Issue)
+
+	utils.DeleteDefaultBucketObjectKeys(healthy.FileID)
+	utils.DeleteDefaultBucketObjectKeys(missingData.FileID)
+	utils.DeleteDefaultBucketObjectKeys(upload.FileID)
+}
@@ -66,6 +66,9 @@ func (e s3Deleter) Run() {
 	err = models.DeleteAllCompletedFiles(fileIDs)
 	utils.LogIfError(err, nil)
 
+	err = models.DeleteFileOwners(fileIDs)
+	utils.LogIfError(err, nil)
+
 	err = models.RemovePublicSharesByIds(fileIDs)
 	utils.LogIfError(err, nil)
 }
@@ -34,5 +34,5 @@ func (e s3ExpireAccess) Run() {
 }
 
 func (e s3ExpireAccess) Runnable() bool {
-	return models.DB != nil && utils.IsS3Enabled()
+	return models.DB != nil && utils.IsBlobStoreEnabled()
 }
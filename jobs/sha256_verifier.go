@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*sha256Verifier reads back the data of the completed files whose uploader gave the SHA-256 of the whole file,
+and purges the files whose data doesn't have it. Completing an upload doesn't read the file again, as direct
+uploads never go through the node.*/
+type sha256Verifier struct{}
+
+// How many completed files a run checks at most
+const sha256VerifierBatchSize = 100
+
+func (e sha256Verifier) Name() string {
+	return "sha256Verifier"
+}
+
+func (e sha256Verifier) ScheduleInterval() string {
+	return "@every 10m"
+}
+
+func (e sha256Verifier) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	completedFiles, err := models.GetCompletedFilesWithUnverifiedSha256(sha256VerifierBatchSize)
+	if err != nil {
+		utils.LogIfError(err, nil)
+		return
+	}
+
+	mismatches := 0
+	for _, completedFile := range completedFiles {
+		matches, err := verifyCompletedFileSha256(completedFile)
+		if err != nil {
+			utils.LogIfError(err, map[string]interface{}{"fileID": completedFile.FileID})
+			continue
+		}
+		if !matches {
+			mismatches++
+		}
+	}
+
+	if mismatches > 0 {
+		utils.SlackLogError(fmt.Sprintf("%s purged %d files whose data didn't have the SHA-256 given by the uploader",
+			e.Name(), mismatches))
+	}
+}
+
+func (e sha256Verifier) Runnable() bool {
+	return models.DB != nil && utils.IsBlobStoreEnabled()
+}
+
+/*verifyCompletedFileSha256 marks the SHA-256 of the file verified when its data has it, and purges the file
+otherwise*/
+func verifyCompletedFileSha256(completedFile models.CompletedFile) (bool, error) {
+	sha, err := getFileDataSha256(completedFile.FileID)
+	if err != nil {
+		return false, err
+	}
+	if sha == completedFile.Sha256 {
+		return true, completedFile.SetSha256Verified()
+	}
+
+	accountID, err := models.GetFileOwnerAccountID(completedFile.FileID)
+	if err != nil {
+		return false, err
+	}
+	return false, models.PurgeCompletedFile(completedFile, accountID)
+}
+
+/*getFileDataSha256 returns the hex SHA-256 of the data of a file, read back from the bucket*/
+func getFileDataSha256(fileID string) (string, error) {
+	output, err := utils.GetBucketObject(models.GetFileDataKey(fileID), "", false)
+	if err != nil {
+		return "", err
+	}
+	defer output.Body.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, output.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCompletedFileWithSha256ForTest(t *testing.T, data, sha string) models.CompletedFile {
+	completedFile := createCompletedFileForScrubTest(t, int64(len(data)))
+	assert.Nil(t, models.DB.Model(&completedFile).UpdateColumn("sha256", sha).Error)
+	completedFile.Sha256 = sha
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataKey(completedFile.FileID), data, ""))
+	return completedFile
+}
+
+func Test_Sha256_Verifier(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+
+	sum := sha256.Sum256([]byte("good data"))
+	good := createCompletedFileWithSha256ForTest(t, "good data", hex.EncodeToString(sum[:]))
+	bad := createCompletedFileWithSha256ForTest(t, "bad data", hex.EncodeToString(sum[:]))
+
+	sha256Verifier{}.Run()
+
+	completedFile, err := models.GetCompletedFileByFileID(good.FileID)
+	assert.Nil(t, err)
+	assert.True(t, completedFile.Sha256Verified)
+	assert.True(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(good.FileID)))
+
+	_, err = models.GetCompletedFileByFileID(bad.FileID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(bad.FileID)))
+
+	unverified, err := models.GetCompletedFilesWithUnverifiedSha256(10)
+	assert.Nil(t, err)
+	assert.Len(t, unverified, 0)
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type trashPurger struct{}
+
+// How many trashed files are read from the database at once
+const trashPurgeBatchSize = 500
+
+func (e trashPurger) Name() string {
+	return "trashPurger"
+}
+
+func (e trashPurger) ScheduleInterval() string {
+	return "@every 1h"
+}
+
+func (e trashPurger) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	trashedBefore := time.Now().Add(-time.Duration(utils.Env.TrashRetentionDays) * 24 * time.Hour)
+	purged := 0
+	for {
+		completedFiles, err := models.GetCompletedFilesTrashedBefore(trashedBefore, trashPurgeBatchSize)
+		if err != nil {
+			utils.LogIfError(err, nil)
+			break
+		}
+
+		failed := 0
+		for _, completedFile := range completedFiles {
+			if err := purgeTrashedFile(completedFile); err != nil {
+				utils.LogIfError(err, map[string]interface{}{"fileID": completedFile.FileID})
+				failed++
+				continue
+			}
+			purged++
+		}
+
+		// The files that failed stay in the trash bin, stop rather than reading them again
+		if len(completedFiles) < trashPurgeBatchSize || failed > 0 {
+			break
+		}
+	}
+
+	utils.SlackLog(fmt.Sprintf("%s purged %d files", e.Name(), purged))
+}
+
+func (e trashPurger) Runnable() bool {
+	return models.DB != nil
+}
+
+func purgeTrashedFile(completedFile models.CompletedFile) error {
+	accountID, err := models.GetFileOwnerAccountID(completedFile.FileID)
+	if err != nil {
+		return err
+	}
+	return models.PurgeCompletedFile(completedFile, accountID)
+}
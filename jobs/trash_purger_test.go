@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Trash_Purger(t *testing.T) {
+	models.DeleteCompletedFilesForTest(t)
+
+	expired := createCompletedFileForScrubTest(t, 4)
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataKey(expired.FileID), "data", ""))
+	trashedAt := time.Now().Add(-time.Duration(utils.Env.TrashRetentionDays+1) * 24 * time.Hour)
+	assert.Nil(t, models.DB.Model(&expired).UpdateColumn("trashed_at", trashedAt).Error)
+
+	recent := createCompletedFileForScrubTest(t, 4)
+	assert.Nil(t, models.DB.Model(&recent).UpdateColumn("trashed_at", time.Now()).Error)
+
+	notTrashed := createCompletedFileForScrubTest(t, 4)
+
+	trashPurger{}.Run()
+
+	_, err := models.GetCompletedFileByFileID(expired.FileID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(expired.FileID)))
+
+	_, err = models.GetCompletedFileByFileID(recent.FileID)
+	assert.Nil(t, err)
+	_, err = models.GetCompletedFileByFileID(notTrashed.FileID)
+	assert.Nil(t, err)
+}
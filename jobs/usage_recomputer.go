@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type usageRecomputer struct{}
+
+// How many accounts are read from the database at once
+const usageRecomputeBatchSize = 500
+
+func (e usageRecomputer) Name() string {
+	return "usageRecomputer"
+}
+
+func (e usageRecomputer) ScheduleInterval() string {
+	return "@daily"
+}
+
+func (e usageRecomputer) Run() {
+	utils.SlackLog("running " + e.Name())
+
+	checked, drifted, fixed, unindexed := 0, 0, 0, 0
+	cursor := ""
+	for {
+		accounts, err := models.GetAccountsAfter(cursor, usageRecomputeBatchSize)
+		if err != nil {
+			utils.LogIfError(err, nil)
+			return
+		}
+
+		for _, account := range accounts {
+			cursor = account.AccountID
+			report, err := models.ComputeAccountUsage(account)
+			if err != nil {
+				utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
+				continue
+			}
+			checked++
+			if !report.Drifted() {
+				continue
+			}
+			drifted++
+			if !report.OwnersIndexed {
+				// What the account owned before the owner indexes is only known once it renews or upgrades
+				unindexed++
+				continue
+			}
+			if !utils.Env.UsageRecomputeRepair {
+				continue
+			}
+			if err := models.FixAccountUsage(report); err != nil {
+				utils.LogIfError(err, map[string]interface{}{"accountID": account.AccountID})
+				continue
+			}
+			fixed++
+		}
+
+		if len(accounts) < usageRecomputeBatchSize {
+			break
+		}
+	}
+
+	utils.Metrics_Accounts_With_Drifted_Usage.Set(float64(drifted - fixed))
+	utils.SlackLog(fmt.Sprintf("%s checked %d accounts, %d had drifted counters and %d were fixed, %d can't be fixed until their owners are indexed",
+		e.Name(), checked, drifted, fixed, unindexed))
+}
+
+func (e usageRecomputer) Runnable() bool {
+	return models.DB != nil
+}
@@ -49,6 +49,10 @@ func main() {
 
 	utils.SetLive()
 
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	err := services.InitStripe(utils.Env.StripeKeyProd)
 	utils.PanicOnError(err)
 
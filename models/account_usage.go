@@ -0,0 +1,132 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*AccountUsage are the storage counters of an account*/
+type AccountUsage struct {
+	StorageUsedInByte        int64 `json:"storageUsedInByte"`
+	TotalFolders             int   `json:"totalFolders"`
+	TotalMetadataSizeInBytes int64 `json:"totalMetadataSizeInBytes"`
+}
+
+/*ErrOwnersNotIndexed is returned when fixing the counters of an account whose files and metadatas are not all
+in the owner indexes yet, since the computed counters would leave out the ones missing*/
+var ErrOwnersNotIndexed = errors.New("the account owns files or metadatas from before the owner indexes, its counters can't be recomputed")
+
+/*AccountUsageReport compares the counters kept on an account with the ones computed from what it owns*/
+type AccountUsageReport struct {
+	AccountID string       `json:"accountID"`
+	Recorded  AccountUsage `json:"recorded"`
+	Computed  AccountUsage `json:"computed"`
+	/*OwnersIndexed is whether Computed counts everything the account owns*/
+	OwnersIndexed bool `json:"ownersIndexed"`
+	/*staleMetadataKeys are owned metadatas that are not in the kv store anymore*/
+	staleMetadataKeys []string
+}
+
+/*Drifted is whether the counters kept on the account don't match what it owns*/
+func (report AccountUsageReport) Drifted() bool {
+	return report.Recorded != report.Computed
+}
+
+/*RecordedUsage returns the counters kept on the account*/
+func (account *Account) RecordedUsage() AccountUsage {
+	return AccountUsage{
+		StorageUsedInByte:        account.StorageUsedInByte,
+		TotalFolders:             account.TotalFolders,
+		TotalMetadataSizeInBytes: account.TotalMetadataSizeInBytes,
+	}
+}
+
+/*ComputeAccountUsage recomputes the counters of the account from the completed files and the metadatas
+it owns. The metadatas sizes are the lengths of their values in the kv store, like the deletes assume.*/
+func ComputeAccountUsage(account Account) (AccountUsageReport, error) {
+	report := AccountUsageReport{
+		AccountID:     account.AccountID,
+		Recorded:      account.RecordedUsage(),
+		OwnersIndexed: account.OwnersIndexed,
+	}
+
+	storageUsed, err := GetStorageUsedInByteByOwner(account.AccountID)
+	if err != nil {
+		return report, err
+	}
+	report.Computed.StorageUsedInByte = storageUsed
+
+	metadataOwners, err := GetMetadataOwnersByAccountID(account.AccountID)
+	if err != nil {
+		return report, err
+	}
+	if len(metadataOwners) == 0 {
+		return report, nil
+	}
+
+	kvKeys := utils.KVKeys{}
+	for _, metadataOwner := range metadataOwners {
+		kvKey, err := metadataOwner.KvKey()
+		if err != nil {
+			return report, err
+		}
+		kvKeys = append(kvKeys, kvKey)
+	}
+	metadatas, err := utils.BatchGet(&kvKeys)
+	if err != nil {
+		return report, err
+	}
+
+	for i, metadataOwner := range metadataOwners {
+		metadata, ok := (*metadatas)[kvKeys[i]]
+		if !ok {
+			report.staleMetadataKeys = append(report.staleMetadataKeys, metadataOwner.MetadataKey)
+			continue
+		}
+		report.Computed.TotalFolders++
+		report.Computed.TotalMetadataSizeInBytes += int64(len(metadata))
+	}
+
+	return report, nil
+}
+
+/*FixAccountUsage sets the counters of the account to the computed ones and forgets the metadatas
+that expired from the kv store. Accounts that are not in the owner indexes yet are left alone.*/
+func FixAccountUsage(report AccountUsageReport) error {
+	if !report.OwnersIndexed {
+		return ErrOwnersNotIndexed
+	}
+	if err := DeleteMetadataOwners(report.staleMetadataKeys); err != nil {
+		return err
+	}
+	return DB.Model(&Account{}).Where("account_id = ?", report.AccountID).UpdateColumns(map[string]interface{}{
+		"storage_used_in_byte":         report.Computed.StorageUsedInByte,
+		"total_folders":                report.Computed.TotalFolders,
+		"total_metadata_size_in_bytes": report.Computed.TotalMetadataSizeInBytes,
+	}).Error
+}
+
+/*SetOwnersIndexed records that everything the account owns is in the owner indexes*/
+func SetOwnersIndexed(accountID string) error {
+	return DB.Model(&Account{}).Where("account_id = ?", accountID).UpdateColumn("owners_indexed", true).Error
+}
+
+/*GetAccountsAfter returns up to limit accounts whose account ID comes after accountID, in account ID order*/
+func GetAccountsAfter(accountID string, limit int) ([]Account, error) {
+	accounts := []Account{}
+	err := DB.Where("account_id > ?", accountID).Order("account_id").Limit(limit).Find(&accounts).Error
+	return accounts, err
+}
+
+/*FreeStorageSpaceInByte takes the size off the storage used by the account, without going below 0. Unlike
+UseStorageSpaceInByte it doesn't need the account to be paid, files are freed after accounts expire too.*/
+func FreeStorageSpaceInByte(accountID string, sizeInByte int64) error {
+	return freeStorageSpaceInByte(DB, accountID, sizeInByte)
+}
+
+func freeStorageSpaceInByte(db *gorm.DB, accountID string, sizeInByte int64) error {
+	return db.Model(&Account{}).Where("account_id = ?", accountID).UpdateColumn("storage_used_in_byte",
+		gorm.Expr("GREATEST(storage_used_in_byte - ?, 0)", sizeInByte)).Error
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Account_Usage(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_ComputeAccountUsage(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteCompletedFilesForTest(t)
+	DeleteFileOwnersForTest(t)
+	DeleteMetadataOwnersForTest(t)
+
+	account := returnValidAccount()
+	account.TotalFolders = 5
+	assert.Nil(t, DB.Create(&account).Error)
+
+	for _, size := range []int64{100, 200} {
+		completedFile := CompletedFile{
+			FileID:         utils.GenerateFileHandle(),
+			ModifierHash:   utils.GenerateFileHandle(),
+			FileSizeInByte: size,
+		}
+		assert.Nil(t, DB.Create(&completedFile).Error)
+		assert.Nil(t, SetFileOwner(completedFile.FileID, account.AccountID))
+	}
+	// Owned by nobody, or by another account
+	notOwned := CompletedFile{FileID: utils.GenerateFileHandle(), ModifierHash: utils.GenerateFileHandle(), FileSizeInByte: 1000}
+	assert.Nil(t, DB.Create(&notOwned).Error)
+
+	metadataV1Key := utils.GenerateFileHandle()
+	metadataV2KeyBin := []byte(utils.RandSeqFromRunes(33, []rune("abcdef")))
+	metadataV2Key := base64.URLEncoding.EncodeToString(metadataV2KeyBin)
+	expiredMetadataKey := utils.GenerateFileHandle()
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		metadataV1Key:            "1234",
+		string(metadataV2KeyBin): "123456",
+	}, time.Hour))
+	assert.Nil(t, SetMetadataOwner(metadataV1Key, account.AccountID, 1))
+	assert.Nil(t, SetMetadataOwner(metadataV2Key, account.AccountID, 2))
+	assert.Nil(t, SetMetadataOwner(expiredMetadataKey, account.AccountID, 1))
+
+	report, err := ComputeAccountUsage(account)
+	assert.Nil(t, err)
+	assert.True(t, report.Drifted())
+	assert.Equal(t, AccountUsage{StorageUsedInByte: 300, TotalFolders: 2, TotalMetadataSizeInBytes: 10}, report.Computed)
+	assert.Equal(t, int64(10*1e9), report.Recorded.StorageUsedInByte)
+
+	// The account may own files from before the owner indexes until it is backfilled
+	assert.Equal(t, ErrOwnersNotIndexed, FixAccountUsage(report))
+
+	assert.Nil(t, SetOwnersIndexed(account.AccountID))
+	account, err = GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	report, err = ComputeAccountUsage(account)
+	assert.Nil(t, err)
+	assert.True(t, report.OwnersIndexed)
+	assert.Nil(t, FixAccountUsage(report))
+
+	accountFromDB, err := GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, report.Computed, accountFromDB.RecordedUsage())
+
+	metadataOwners, err := GetMetadataOwnersByAccountID(account.AccountID)
+	assert.Nil(t, err)
+	assert.Len(t, metadataOwners, 2)
+
+	report, err = ComputeAccountUsage(accountFromDB)
+	assert.Nil(t, err)
+	assert.False(t, report.Drifted())
+}
@@ -41,6 +41,7 @@ type Account struct {
 	Upgrades                 []Upgrade         `gorm:"foreignkey:AccountID;association_foreignkey:AccountID"`
 	ExpiredAt                time.Time         `json:"expiredAt"`
 	NetworkIdPaid            uint              `json:"networkIdPaid"`
+	OwnersIndexed            bool              `json:"ownersIndexed" gorm:"not null;default:false"` // whether everything the account owns is in the owner indexes
 }
 
 /*SpaceReport defines a model for capturing the space allotted compared to space used*/
@@ -307,6 +308,12 @@ func (account *Account) MaxAllowedMetadatas() int {
 	return utils.Env.Plans[int(account.StorageLimit)].MaxFolders
 }
 
+/*MaxEgressInBytes returns how many bytes an account may download in a month based on its plan, 0 when the
+plan doesn't limit it*/
+func (account *Account) MaxEgressInBytes() int64 {
+	return int64(utils.Env.Plans[int(account.StorageLimit)].MaxEgressGBPerMonth) * 1e9
+}
+
 /*CanAddNewMetadata checks if an account can have another metadata*/
 func (account *Account) CanAddNewMetadata() bool {
 	intendedNumberOfMetadatas := account.TotalFolders + 1
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -17,6 +18,14 @@ type CompletedFile struct {
 	FileSizeInByte int64     `json:"fileSizeInByte"`
 	ModifierHash   string    `json:"modifierHash" validate:"required,len=64" minLength:"64" maxLength:"64"`
 	ApiVersion     int       `json:"apiVersion" validate:"omitempty,gte=1" gorm:"default:1"`
+	/*Sha256 is the hex SHA-256 of the whole file given by the client when the upload started*/
+	Sha256 string `json:"sha256" validate:"omitempty,len=64,hexadecimal"`
+	/*Sha256Verified is set once the sha256 verifier read the data back and found Sha256, the file is purged otherwise*/
+	Sha256Verified bool `json:"sha256Verified" gorm:"not null;default:false"`
+	/*PartDigests is the JSON of the PartDigest of each part, when the node has one for every part*/
+	PartDigests *string `json:"-" gorm:"type:mediumtext"`
+	/*TrashedAt is when the file was deleted, it is purged once the trash retention is over*/
+	TrashedAt *time.Time `json:"trashedAt,omitempty" gorm:"index"`
 }
 
 /*BeforeCreate - callback called before the row is created*/
@@ -29,6 +38,31 @@ func (completedFile *CompletedFile) BeforeUpdate(scope *gorm.Scope) error {
 	return utils.Validator.Struct(completedFile)
 }
 
+/*SetPartDigests keeps the digests of the parts of the file, without saving it*/
+func (completedFile *CompletedFile) SetPartDigests(digests []PartDigest) error {
+	if len(digests) == 0 {
+		completedFile.PartDigests = nil
+		return nil
+	}
+	digestsAsBytes, err := json.Marshal(digests)
+	if err != nil {
+		return err
+	}
+	digestsAsString := string(digestsAsBytes)
+	completedFile.PartDigests = &digestsAsString
+	return nil
+}
+
+/*GetPartDigests returns the digests of the parts of the file, nil when they are not known*/
+func (completedFile *CompletedFile) GetPartDigests() ([]PartDigest, error) {
+	if completedFile.PartDigests == nil {
+		return nil, nil
+	}
+	var digests []PartDigest
+	err := json.Unmarshal([]byte(*completedFile.PartDigests), &digests)
+	return digests, err
+}
+
 func GetAllExpiredCompletedFiles(expiredTime time.Time) ([]string, error) {
 	files := []CompletedFile{}
 	if err := DB.Where("expired_at < ?", expiredTime).Find(&files).Error; err != nil {
@@ -74,6 +108,117 @@ func GetCompletedFileByFileID(fileID string) (CompletedFile, error) {
 	return completedFile, err
 }
 
+/*GetCompletedFilesAfter returns up to limit completed files whose file ID comes after fileID, in file ID order*/
+func GetCompletedFilesAfter(fileID string, limit int) ([]CompletedFile, error) {
+	completedFiles := []CompletedFile{}
+	err := DB.Where("file_id > ?", fileID).Order("file_id").Limit(limit).Find(&completedFiles).Error
+	return completedFiles, err
+}
+
+/*GetExistingCompletedFileIDs returns which of the file IDs have a completed file*/
+func GetExistingCompletedFileIDs(fileIDs []string) (map[string]bool, error) {
+	return getExistingFileIDs(DB.Model(&CompletedFile{}), fileIDs)
+}
+
+/*Trash moves the file to the trash bin of the account. It keeps its objects and keeps counting against
+the storage of the account until it is purged.*/
+func (completedFile *CompletedFile) Trash(accountID string) error {
+	// The purge needs to know whose storage to free
+	if err := SetFileOwner(completedFile.FileID, accountID); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := DB.Model(completedFile).UpdateColumn("trashed_at", now).Error; err != nil {
+		return err
+	}
+	completedFile.TrashedAt = &now
+	return nil
+}
+
+/*MakeCompletedFileObjectsPrivate sets the private ACL on the data and metadata objects of the file, which
+v1 and v2 downloads make readable by anyone. Objects that are missing are left to the integrity scrubber*/
+func MakeCompletedFileObjectsPrivate(fileID string) error {
+	for _, key := range []string{GetFileDataKey(fileID), GetFileMetadataKey(fileID)} {
+		if !utils.DoesDefaultBucketObjectExist(key) {
+			continue
+		}
+		if err := utils.SetDefaultObjectCannedAcl(key, utils.CannedAcl_Private); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*Restore takes the file out of the trash bin*/
+func (completedFile *CompletedFile) Restore() error {
+	if err := DB.Model(completedFile).UpdateColumn("trashed_at", gorm.Expr("NULL")).Error; err != nil {
+		return err
+	}
+	completedFile.TrashedAt = nil
+	return nil
+}
+
+/*PurgeCompletedFile deletes the file for good: its objects, its row, its owner and its public shares,
+and frees its size from the storage used by the account owning it. The storage is freed in the transaction
+deleting the row, so a file purged twice is only freed once and a failed purge doesn't free anything.*/
+func PurgeCompletedFile(completedFile CompletedFile, accountID string) error {
+	if err := utils.DeleteDefaultBucketObjectKeys(completedFile.FileID); err != nil {
+		return err
+	}
+
+	tx := DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Error; err != nil {
+		return err
+	}
+
+	deleted := tx.Delete(&completedFile)
+	if err := deleted.Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if deleted.RowsAffected > 0 && accountID != "" {
+		if err := freeStorageSpaceInByte(tx, accountID, completedFile.FileSizeInByte); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Where("file_id = ?", completedFile.FileID).Delete(FileOwner{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	return RemovePublicSharesById(completedFile.FileID)
+}
+
+/*GetCompletedFilesWithUnverifiedSha256 returns up to limit completed files given a SHA-256 the data wasn't checked against yet*/
+func GetCompletedFilesWithUnverifiedSha256(limit int) ([]CompletedFile, error) {
+	completedFiles := []CompletedFile{}
+	err := DB.Where("sha256 <> '' AND sha256_verified = ?", false).Order("created_at").Limit(limit).Find(&completedFiles).Error
+	return completedFiles, err
+}
+
+/*SetSha256Verified marks the SHA-256 of the completed file as checked against its data*/
+func (completedFile *CompletedFile) SetSha256Verified() error {
+	completedFile.Sha256Verified = true
+	return DB.Model(completedFile).UpdateColumn("sha256_verified", true).Error
+}
+
+/*GetCompletedFilesTrashedBefore returns up to limit completed files moved to the trash bin before trashedBefore*/
+func GetCompletedFilesTrashedBefore(trashedBefore time.Time, limit int) ([]CompletedFile, error) {
+	completedFiles := []CompletedFile{}
+	err := DB.Where("trashed_at < ?", trashedBefore).Order("trashed_at").Limit(limit).Find(&completedFiles).Error
+	return completedFiles, err
+}
+
 /*UpdateExpiredAt receives an array of file handles and updates the ExpiredAt times of any file that matches
 one of the file handles*/
 func UpdateExpiredAt(fileHandles []string, key string, newExpiredAtTime time.Time) error {
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jinzhu/gorm"
 	"github.com/opacity/storage-node/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -187,3 +188,51 @@ func Test_UpdateExpiredAt(t *testing.T) {
 	assert.Equal(t, startingExpiredAtTime.Day(), completedFile.ExpiredAt.Day())
 	assert.Nil(t, err)
 }
+
+func Test_Trash_Restore_And_Purge_CompletedFile(t *testing.T) {
+	DeleteAccountsForTest(t)
+	DeleteCompletedFilesForTest(t)
+	DeleteFileOwnersForTest(t)
+
+	account := returnValidAccount()
+	assert.Nil(t, DB.Create(&account).Error)
+
+	completedFile := CompletedFile{
+		FileID:         utils.GenerateFileHandle(),
+		ModifierHash:   utils.GenerateFileHandle(),
+		FileSizeInByte: 1000,
+	}
+	assert.Nil(t, DB.Create(&completedFile).Error)
+
+	assert.Nil(t, completedFile.Trash(account.AccountID))
+	trashed, err := GetCompletedFilesTrashedBefore(time.Now().Add(time.Minute), 10)
+	assert.Nil(t, err)
+	assert.Len(t, trashed, 1)
+	ownerAccountID, err := GetFileOwnerAccountID(completedFile.FileID)
+	assert.Nil(t, err)
+	assert.Equal(t, account.AccountID, ownerAccountID)
+
+	assert.Nil(t, completedFile.Restore())
+	completedFileFromDB, err := GetCompletedFileByFileID(completedFile.FileID)
+	assert.Nil(t, err)
+	assert.Nil(t, completedFileFromDB.TrashedAt)
+
+	assert.Nil(t, PurgeCompletedFile(completedFileFromDB, account.AccountID))
+	_, err = GetCompletedFileByFileID(completedFile.FileID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+	accountFromDB, err := GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, account.StorageUsedInByte-1000, accountFromDB.StorageUsedInByte)
+
+	// Purging it again doesn't free its size twice
+	assert.Nil(t, PurgeCompletedFile(completedFileFromDB, account.AccountID))
+	accountFromDB, err = GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, account.StorageUsedInByte-1000, accountFromDB.StorageUsedInByte)
+
+	// The storage used never goes below 0
+	assert.Nil(t, FreeStorageSpaceInByte(account.AccountID, account.StorageUsedInByte))
+	accountFromDB, err = GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), accountFromDB.StorageUsedInByte)
+}
@@ -12,6 +12,16 @@ type CompletedUploadIndex struct {
 	FileID string `gorm:"primary_key" json:"fileID" validate:"required"`
 	Index  int    `gorm:"primary_key;auto_increment:false" json:"index" validate:"required"`
 	Etag   string `json:"etag" validate:"required"`
+	/*Sha256 is the hex SHA-256 of the part as the node received it, empty when the part didn't go through the node*/
+	Sha256 string `json:"sha256" validate:"omitempty,len=64,hexadecimal"`
+	Size   int64  `json:"size" gorm:"default:0"`
+}
+
+/*PartDigest is the SHA-256 of a part of a file, for clients to verify what they download*/
+type PartDigest struct {
+	Index  int    `json:"index" example:"1"`
+	Size   int64  `json:"size" example:"5242880"`
+	Sha256 string `json:"sha256" example:"the hex SHA-256 of the part"`
 }
 
 /*BeforeCreate - callback called before the row is created*/
@@ -25,10 +35,17 @@ func (completedUploadIndex *CompletedUploadIndex) BeforeUpdate(scope *gorm.Scope
 }
 
 func CreateCompletedUploadIndex(fileID string, index int, etag string) error {
+	return CreateCompletedUploadIndexWithDigest(fileID, index, etag, "", 0)
+}
+
+/*CreateCompletedUploadIndexWithDigest records a part along with the SHA-256 of its size bytes*/
+func CreateCompletedUploadIndexWithDigest(fileID string, index int, etag, sha256 string, size int64) error {
 	c := CompletedUploadIndex{
 		FileID: fileID,
 		Index:  index,
 		Etag:   etag,
+		Sha256: sha256,
+		Size:   size,
 	}
 	return DB.Create(&c).Error
 }
@@ -68,6 +85,27 @@ func GetCompletedPartsAsArray(fileID string) ([]*s3.CompletedPart, error) {
 	return completedParts, nil
 }
 
+/*GetCompletedUploadDigests returns the digests of the parts of a file in order, or nil unless every part has one*/
+func GetCompletedUploadDigests(fileID string) ([]PartDigest, error) {
+	completedIndexes := []CompletedUploadIndex{}
+	if err := DB.Where("file_id = ?", fileID).Order("index").Find(&completedIndexes).Error; err != nil {
+		return nil, err
+	}
+
+	var digests []PartDigest
+	for _, index := range completedIndexes {
+		if index.Sha256 == "" {
+			return nil, nil
+		}
+		digests = append(digests, PartDigest{
+			Index:  index.Index,
+			Size:   index.Size,
+			Sha256: index.Sha256,
+		})
+	}
+	return digests, nil
+}
+
 func GetIncompleteIndexesAsArray(fileID string, endIndex int) ([]int64, error) {
 	var incompletedIndex []int64
 	completedIndexes := []CompletedUploadIndex{}
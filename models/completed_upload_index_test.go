@@ -73,3 +73,20 @@ func Test_GetIncompleteIndexesAsArray(t *testing.T) {
 	assert.Equal(t, int64(4), l[1])
 	assert.Equal(t, int64(6), l[2])
 }
+
+func Test_GetCompletedUploadDigests(t *testing.T) {
+	DeleteCompletedUploadIndexesForTest(t)
+
+	sha := utils.GenerateFileHandle()
+	assert.Nil(t, CreateCompletedUploadIndexWithDigest("test_bar6", 2, "b", sha, 10))
+	assert.Nil(t, CreateCompletedUploadIndexWithDigest("test_bar6", 1, "a", sha, 20))
+	digests, err := GetCompletedUploadDigests("test_bar6")
+	assert.Nil(t, err)
+	assert.Equal(t, []PartDigest{{Index: 1, Size: 20, Sha256: sha}, {Index: 2, Size: 10, Sha256: sha}}, digests)
+
+	// Without a digest for every part there is nothing to verify the file with
+	assert.Nil(t, CreateCompletedUploadIndex("test_bar6", 3, "c"))
+	digests, err = GetCompletedUploadDigests("test_bar6")
+	assert.Nil(t, err)
+	assert.Nil(t, digests)
+}
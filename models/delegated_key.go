@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	/*DelegatedScopeMetadataRead allows reading metadata*/
+	DelegatedScopeMetadataRead = "metadata:read"
+
+	/*DelegatedScopeUpload allows uploading files and checking their upload status*/
+	DelegatedScopeUpload = "upload"
+
+	/*DelegatedScopePublicShare allows creating, inspecting and revoking public shares*/
+	DelegatedScopePublicShare = "public-share"
+)
+
+/*DelegatedScopes are all the scopes a delegated key can be given*/
+var DelegatedScopes = []string{DelegatedScopeMetadataRead, DelegatedScopeUpload, DelegatedScopePublicShare}
+
+/*DelegatedKey is an additional public key registered on an account, allowed to act on
+the account's files and metadata within its scopes until it expires*/
+type DelegatedKey struct {
+	/*KeyID is the hash of the delegated public key, like an AccountID is the hash of the account's key*/
+	KeyID     string `gorm:"primary_key" json:"keyID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	AccountID string `gorm:"not null;index:idx_delegated_key_account_id" json:"accountID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	PublicKey string `gorm:"not null" json:"publicKey" validate:"required,len=66" minLength:"66" maxLength:"66"`
+	/*AccountPublicKey is the master key of the account, file and metadata permissions are bound to it*/
+	AccountPublicKey string    `gorm:"not null" json:"-" validate:"required,len=66"`
+	Scopes           string    `gorm:"not null" json:"scopes" validate:"required" example:"metadata:read,upload"`
+	ExpiresAt        time.Time `json:"expiresAt" validate:"required"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (delegatedKey *DelegatedKey) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(delegatedKey)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (delegatedKey *DelegatedKey) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(delegatedKey)
+}
+
+/*IsValidDelegatedScope returns whether the scope is one of DelegatedScopes*/
+func IsValidDelegatedScope(scope string) bool {
+	for _, s := range DelegatedScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*HasScope returns whether the delegated key was given the scope*/
+func (delegatedKey DelegatedKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(delegatedKey.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+/*IsExpired returns whether the delegated key can't be used anymore*/
+func (delegatedKey DelegatedKey) IsExpired() bool {
+	return !delegatedKey.ExpiresAt.After(time.Now())
+}
+
+/*GetDelegatedKeyByID returns the delegated key with the hash of its public key*/
+func GetDelegatedKeyByID(keyID string) (DelegatedKey, error) {
+	delegatedKey := DelegatedKey{}
+	err := DB.Where("key_id = ?", keyID).First(&delegatedKey).Error
+	return delegatedKey, err
+}
+
+/*GetDelegatedKeysByAccountID returns the delegated keys registered on an account, expired ones included*/
+func GetDelegatedKeysByAccountID(accountID string) ([]DelegatedKey, error) {
+	delegatedKeys := []DelegatedKey{}
+	err := DB.Where("account_id = ?", accountID).Order("created_at").Find(&delegatedKeys).Error
+	return delegatedKeys, err
+}
+
+/*DeleteDelegatedKey removes a delegated key from an account*/
+func DeleteDelegatedKey(accountID, keyID string) error {
+	return DB.Where("account_id = ? AND key_id = ?", accountID, keyID).Delete(DelegatedKey{}).Error
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*EgressUsage is how many bytes of its files an account downloaded in a month*/
+type EgressUsage struct {
+	AccountID string `gorm:"primary_key;size:64" json:"accountID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	/*Month is the year and month of the downloads, like 2006-01*/
+	Month string `gorm:"primary_key;size:7" json:"month" validate:"required,len=7"`
+	/*ProxiedBytes are the bytes streamed to the account by the node*/
+	ProxiedBytes int64 `gorm:"not null;default:0" json:"proxiedBytes" validate:"gte=0"`
+	/*DirectBytes are the bytes the bucket served, read from its access logs*/
+	DirectBytes int64     `gorm:"not null;default:0" json:"directBytes" validate:"gte=0"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+/*AccessLogOffset is how far the node read a bucket access log, so that no line is counted twice*/
+type AccessLogOffset struct {
+	Path      string    `gorm:"primary_key;size:255" json:"path" validate:"required"`
+	Offset    int64     `json:"offset" validate:"gte=0"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+/*DirectEgress are bytes of an object of a file the bucket served*/
+type DirectEgress struct {
+	FileID string
+	Month  string
+	Bytes  int64
+	/*Public is set for the object of the file its public shares link to*/
+	Public bool
+}
+
+type egressUsageKey struct {
+	accountID string
+	month     string
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (egressUsage *EgressUsage) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(egressUsage)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (egressUsage *EgressUsage) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(egressUsage)
+}
+
+/*TotalBytes are all the bytes the account downloaded in the month*/
+func (egressUsage EgressUsage) TotalBytes() int64 {
+	return egressUsage.ProxiedBytes + egressUsage.DirectBytes
+}
+
+/*EgressMonth is the Month of the egress usage downloads made at t count towards*/
+func EgressMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+/*AddProxiedEgress adds the bytes streamed to the account to its egress usage of the current month*/
+func AddProxiedEgress(accountID string, bytes int64) error {
+	return addEgress(DB, "proxied_bytes", accountID, EgressMonth(time.Now()), bytes)
+}
+
+func addEgress(db *gorm.DB, column, accountID, month string, bytes int64) error {
+	if bytes <= 0 {
+		return nil
+	}
+	return db.Exec("INSERT INTO egress_usages (account_id, month, "+column+", updated_at) VALUES (?, ?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE "+column+" = "+column+" + VALUES("+column+"), updated_at = VALUES(updated_at)",
+		accountID, month, bytes, time.Now()).Error
+}
+
+/*GetEgressUsage returns the egress usage of the account in the month, empty when it downloaded nothing*/
+func GetEgressUsage(accountID, month string) (EgressUsage, error) {
+	egressUsage := EgressUsage{}
+	err := DB.Where("account_id = ? AND month = ?", accountID, month).First(&egressUsage).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return EgressUsage{AccountID: accountID, Month: month}, nil
+	}
+	return egressUsage, err
+}
+
+/*IsEgressQuotaExceeded returns whether the account downloaded as much as its plan allows this month*/
+func IsEgressQuotaExceeded(account Account) (bool, error) {
+	maxEgressInBytes := account.MaxEgressInBytes()
+	if maxEgressInBytes == 0 {
+		return false, nil
+	}
+	egressUsage, err := GetEgressUsage(account.AccountID, EgressMonth(time.Now()))
+	return egressUsage.TotalBytes() >= maxEgressInBytes, err
+}
+
+/*GetAccessLogOffset returns how far the access log at path was read*/
+func GetAccessLogOffset(path string) (int64, error) {
+	accessLogOffset := AccessLogOffset{}
+	err := DB.Where("path = ?", path).First(&accessLogOffset).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return 0, nil
+	}
+	return accessLogOffset.Offset, err
+}
+
+/*
+RecordDirectEgress adds the bytes to the egress usage of the accounts owning the files and to their public
+shares, and saves the offset the access log at path was read to, all in one transaction. Bytes of files
+without an owner are dropped.
+*/
+func RecordDirectEgress(path string, offset int64, egresses []DirectEgress) error {
+	owners := make(map[string]string)
+	usages := make(map[egressUsageKey]int64)
+	publicBytes := make(map[string]int64)
+	for _, egress := range egresses {
+		accountID, ok := owners[egress.FileID]
+		if !ok {
+			var err error
+			if accountID, err = GetFileOwnerAccountID(egress.FileID); err != nil {
+				return err
+			}
+			owners[egress.FileID] = accountID
+		}
+		if accountID != "" {
+			usages[egressUsageKey{accountID: accountID, month: egress.Month}] += egress.Bytes
+		}
+		if egress.Public {
+			publicBytes[egress.FileID] += egress.Bytes
+		}
+	}
+
+	tx := DB.Begin()
+	for key, bytes := range usages {
+		if err := addEgress(tx, "direct_bytes", key.accountID, key.month, bytes); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for fileID, bytes := range publicBytes {
+		if err := tx.Model(&PublicShare{}).Where("file_id = ?", fileID).
+			UpdateColumn("egress_bytes", gorm.Expr("egress_bytes + ?", bytes)).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Save(&AccessLogOffset{Path: path, Offset: offset}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
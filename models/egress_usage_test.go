@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Egress_Usage(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_AddProxiedEgress(t *testing.T) {
+	DeleteEgressUsagesForTest(t)
+	accountID := utils.RandHexString(64)
+	month := EgressMonth(time.Now())
+
+	egressUsage, err := GetEgressUsage(accountID, month)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), egressUsage.ProxiedBytes)
+
+	assert.Nil(t, AddProxiedEgress(accountID, 100))
+	assert.Nil(t, AddProxiedEgress(accountID, 0))
+	assert.Nil(t, AddProxiedEgress(accountID, 50))
+
+	egressUsage, err = GetEgressUsage(accountID, month)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(150), egressUsage.ProxiedBytes)
+
+	egressUsage, err = GetEgressUsage(accountID, EgressMonth(time.Now().AddDate(0, -1, 0)))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), egressUsage.ProxiedBytes)
+}
+
+func Test_IsEgressQuotaExceeded(t *testing.T) {
+	DeleteEgressUsagesForTest(t)
+	account := returnValidAccount()
+
+	exceeded, err := IsEgressQuotaExceeded(account)
+	assert.Nil(t, err)
+	assert.False(t, exceeded)
+
+	assert.Nil(t, AddProxiedEgress(account.AccountID, account.MaxEgressInBytes()-1))
+	exceeded, err = IsEgressQuotaExceeded(account)
+	assert.Nil(t, err)
+	assert.False(t, exceeded)
+
+	assert.Nil(t, AddProxiedEgress(account.AccountID, 1))
+	exceeded, err = IsEgressQuotaExceeded(account)
+	assert.Nil(t, err)
+	assert.True(t, exceeded)
+}
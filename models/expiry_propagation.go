@@ -0,0 +1,151 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*ExpiryPropagationStatusType is how far an expiry propagation went*/
+type ExpiryPropagationStatusType int
+
+const (
+	/*ExpiryPropagationInProgress - some owned files or metadatas still have the old expiration*/
+	ExpiryPropagationInProgress ExpiryPropagationStatusType = iota + 1
+
+	/*ExpiryPropagationCompleted - every owned file and metadata has the new expiration*/
+	ExpiryPropagationCompleted
+)
+
+/*ExpiryPropagation extends the expiration of the files and metadatas an account owns after it was renewed
+or upgraded. The cursors are kept so that it resumes where it stopped.*/
+type ExpiryPropagation struct {
+	AccountID          string                      `gorm:"primary_key" json:"accountID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	CreatedAt          time.Time                   `json:"createdAt"`
+	UpdatedAt          time.Time                   `json:"updatedAt"`
+	FilesExpiredAt     time.Time                   `json:"filesExpiredAt" validate:"required"`
+	MetadatasExpiredAt time.Time                   `json:"metadatasExpiredAt" validate:"required"`
+	Status             ExpiryPropagationStatusType `json:"status" validate:"required,gte=1"`
+	/*FileCursor is the file ID of the last completed file updated*/
+	FileCursor string `json:"-"`
+	/*MetadataCursor is the key of the last metadata owner updated*/
+	MetadataCursor   string `json:"-"`
+	FilesUpdated     int    `json:"filesUpdated"`
+	MetadatasUpdated int    `json:"metadatasUpdated"`
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (expiryPropagation *ExpiryPropagation) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(expiryPropagation)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (expiryPropagation *ExpiryPropagation) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(expiryPropagation)
+}
+
+/*StartExpiryPropagation (re)starts extending the expiration of everything the account owns, replacing
+a propagation that may still be in progress*/
+func StartExpiryPropagation(accountID string, filesExpiredAt, metadatasExpiredAt time.Time) error {
+	return DB.Save(&ExpiryPropagation{
+		AccountID:          accountID,
+		FilesExpiredAt:     filesExpiredAt,
+		MetadatasExpiredAt: metadatasExpiredAt,
+		Status:             ExpiryPropagationInProgress,
+	}).Error
+}
+
+/*GetExpiryPropagation returns the last expiry propagation of the account*/
+func GetExpiryPropagation(accountID string) (ExpiryPropagation, error) {
+	expiryPropagation := ExpiryPropagation{}
+	err := DB.Where("account_id = ?", accountID).First(&expiryPropagation).Error
+	return expiryPropagation, err
+}
+
+/*GetExpiryPropagationsInProgress returns up to limit propagations that are not completed, oldest first*/
+func GetExpiryPropagationsInProgress(limit int) ([]ExpiryPropagation, error) {
+	expiryPropagations := []ExpiryPropagation{}
+	err := DB.Where("status = ?", ExpiryPropagationInProgress).Order("updated_at").Limit(limit).Find(&expiryPropagations).Error
+	return expiryPropagations, err
+}
+
+/*PropagateBatch extends the expiration of up to limit files, then of up to limit metadatas once all the
+files are done, and saves the progress. It returns whether the propagation is completed.*/
+func (expiryPropagation *ExpiryPropagation) PropagateBatch(limit int) (bool, error) {
+	filesDone, err := expiryPropagation.propagateFiles(limit)
+	if err != nil {
+		return false, err
+	}
+	if filesDone {
+		metadatasDone, err := expiryPropagation.propagateMetadatas(limit)
+		if err != nil {
+			return false, err
+		}
+		if metadatasDone {
+			expiryPropagation.Status = ExpiryPropagationCompleted
+		}
+	}
+
+	err = DB.Model(expiryPropagation).UpdateColumns(map[string]interface{}{
+		"file_cursor":       expiryPropagation.FileCursor,
+		"metadata_cursor":   expiryPropagation.MetadataCursor,
+		"files_updated":     expiryPropagation.FilesUpdated,
+		"metadatas_updated": expiryPropagation.MetadatasUpdated,
+		"status":            expiryPropagation.Status,
+		"updated_at":        time.Now(),
+	}).Error
+	return expiryPropagation.Status == ExpiryPropagationCompleted, err
+}
+
+func (expiryPropagation *ExpiryPropagation) propagateFiles(limit int) (bool, error) {
+	completedFiles, err := GetCompletedFilesByOwner(expiryPropagation.AccountID, expiryPropagation.FileCursor, limit)
+	if err != nil || len(completedFiles) == 0 {
+		return err == nil, err
+	}
+
+	var fileIDs []string
+	for _, completedFile := range completedFiles {
+		fileIDs = append(fileIDs, completedFile.FileID)
+	}
+	if err := DB.Table("completed_files").Where("file_id IN (?)", fileIDs).Updates(map[string]interface{}{
+		"expired_at": expiryPropagation.FilesExpiredAt,
+		"updated_at": time.Now()}).Error; err != nil {
+		return false, err
+	}
+
+	expiryPropagation.FileCursor = fileIDs[len(fileIDs)-1]
+	expiryPropagation.FilesUpdated += len(fileIDs)
+	return len(completedFiles) < limit, nil
+}
+
+/*propagateMetadatas sets the new ttl on the metadatas and on their permission hashes, metadatas that are
+not in the kv store anymore are skipped*/
+func (expiryPropagation *ExpiryPropagation) propagateMetadatas(limit int) (bool, error) {
+	metadataOwners, err := GetMetadataOwnersByAccountIDAfter(expiryPropagation.AccountID, expiryPropagation.MetadataCursor, limit)
+	if err != nil || len(metadataOwners) == 0 {
+		return err == nil, err
+	}
+
+	kvKeys := utils.KVKeys{}
+	for _, metadataOwner := range metadataOwners {
+		kvKey, err := metadataOwner.KvKey()
+		if err != nil {
+			return false, err
+		}
+		kvKeys = append(kvKeys, kvKey, kvKey+"_permissionHash")
+	}
+	kvs, err := utils.BatchGet(&kvKeys)
+	if err != nil {
+		return false, err
+	}
+	if len(*kvs) > 0 {
+		if err := utils.BatchSet(kvs, time.Until(expiryPropagation.MetadatasExpiredAt)); err != nil {
+			return false, err
+		}
+	}
+
+	expiryPropagation.MetadataCursor = metadataOwners[len(metadataOwners)-1].MetadataKey
+	expiryPropagation.MetadatasUpdated += len(metadataOwners)
+	return len(metadataOwners) < limit, nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Expiry_Propagation(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_ExpiryPropagation_PropagateBatch(t *testing.T) {
+	DeleteCompletedFilesForTest(t)
+	DeleteFileOwnersForTest(t)
+	DeleteMetadataOwnersForTest(t)
+	DeleteExpiryPropagationsForTest(t)
+
+	accountID := utils.GenerateFileHandle()
+	oldExpiredAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	newExpiredAt := time.Now().Add(24 * time.Hour * 365).Truncate(time.Second)
+
+	var ownedFileIDs []string
+	for i := 0; i < 2; i++ {
+		completedFile := CompletedFile{
+			FileID:       utils.GenerateFileHandle(),
+			ModifierHash: utils.GenerateFileHandle(),
+			ExpiredAt:    oldExpiredAt,
+		}
+		assert.Nil(t, DB.Create(&completedFile).Error)
+		assert.Nil(t, SetFileOwner(completedFile.FileID, accountID))
+		ownedFileIDs = append(ownedFileIDs, completedFile.FileID)
+	}
+	otherAccountFile := CompletedFile{FileID: utils.GenerateFileHandle(), ModifierHash: utils.GenerateFileHandle(), ExpiredAt: oldExpiredAt}
+	assert.Nil(t, DB.Create(&otherAccountFile).Error)
+	assert.Nil(t, SetFileOwner(otherAccountFile.FileID, utils.GenerateFileHandle()))
+
+	metadataKey := utils.GenerateFileHandle()
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		metadataKey:                     "metadata",
+		metadataKey + "_permissionHash": "permissionHash",
+	}, time.Hour))
+	assert.Nil(t, SetMetadataOwner(metadataKey, accountID, 1))
+
+	assert.Nil(t, StartExpiryPropagation(accountID, newExpiredAt, newExpiredAt.Add(time.Hour)))
+	expiryPropagations, err := GetExpiryPropagationsInProgress(10)
+	assert.Nil(t, err)
+	assert.Len(t, expiryPropagations, 1)
+
+	expiryPropagation := expiryPropagations[0]
+	done := false
+	batches := 0
+	for !done {
+		done, err = expiryPropagation.PropagateBatch(1)
+		assert.Nil(t, err)
+		batches++
+	}
+	// One file per batch, the metadata along with the end of the files, then the end of the metadatas
+	assert.Equal(t, 4, batches)
+
+	expiryPropagation, err = GetExpiryPropagation(accountID)
+	assert.Nil(t, err)
+	assert.Equal(t, ExpiryPropagationCompleted, expiryPropagation.Status)
+	assert.Equal(t, 2, expiryPropagation.FilesUpdated)
+	assert.Equal(t, 1, expiryPropagation.MetadatasUpdated)
+
+	for _, fileID := range ownedFileIDs {
+		completedFile, err := GetCompletedFileByFileID(fileID)
+		assert.Nil(t, err)
+		assert.Equal(t, newExpiredAt.Unix(), completedFile.ExpiredAt.Unix())
+	}
+	completedFile, err := GetCompletedFileByFileID(otherAccountFile.FileID)
+	assert.Nil(t, err)
+	assert.Equal(t, oldExpiredAt.Unix(), completedFile.ExpiredAt.Unix())
+
+	_, expirationTime, err := utils.GetValueFromKV(metadataKey + "_permissionHash")
+	assert.Nil(t, err)
+	assert.True(t, expirationTime.After(time.Now().Add(24*time.Hour)))
+
+	expiryPropagations, err = GetExpiryPropagationsInProgress(10)
+	assert.Nil(t, err)
+	assert.Len(t, expiryPropagations, 0)
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/meirf/gopart"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*FileOwner links a completed file to the account whose storage it uses. Files completed before
+the index existed have no owner.*/
+type FileOwner struct {
+	FileID    string    `gorm:"primary_key" json:"fileID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	AccountID string    `gorm:"not null;index:idx_file_owner_account_id" json:"accountID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (fileOwner *FileOwner) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(fileOwner)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (fileOwner *FileOwner) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(fileOwner)
+}
+
+/*SetFileOwner records the account owning the file*/
+func SetFileOwner(fileID, accountID string) error {
+	return DB.Save(&FileOwner{FileID: fileID, AccountID: accountID}).Error
+}
+
+/*DeleteFileOwners forgets the owners of the files*/
+func DeleteFileOwners(fileIDs []string) error {
+	for fileIDsRange := range gopart.Partition(len(fileIDs), 5000) {
+		if err := DB.Where("file_id IN (?)", fileIDs[fileIDsRange.Low:fileIDsRange.High]).Delete(FileOwner{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*GetCompletedFilesByOwner returns up to limit completed files owned by the account whose file ID comes
+after fileID, in file ID order, trashed or not*/
+func GetCompletedFilesByOwner(accountID, fileID string, limit int) ([]CompletedFile, error) {
+	return getCompletedFilesByOwner(DB, accountID, fileID, limit)
+}
+
+/*GetUntrashedCompletedFilesByOwner is GetCompletedFilesByOwner without the files in the trash bin*/
+func GetUntrashedCompletedFilesByOwner(accountID, fileID string, limit int) ([]CompletedFile, error) {
+	return getCompletedFilesByOwner(DB.Where("completed_files.trashed_at IS NULL"), accountID, fileID, limit)
+}
+
+/*GetTrashedCompletedFilesByOwner is GetCompletedFilesByOwner with only the files in the trash bin*/
+func GetTrashedCompletedFilesByOwner(accountID, fileID string, limit int) ([]CompletedFile, error) {
+	return getCompletedFilesByOwner(DB.Where("completed_files.trashed_at IS NOT NULL"), accountID, fileID, limit)
+}
+
+func getCompletedFilesByOwner(query *gorm.DB, accountID, fileID string, limit int) ([]CompletedFile, error) {
+	completedFiles := []CompletedFile{}
+	err := query.Joins("JOIN file_owners ON file_owners.file_id = completed_files.file_id").
+		Where("file_owners.account_id = ? AND completed_files.file_id > ?", accountID, fileID).
+		Order("completed_files.file_id").Limit(limit).Find(&completedFiles).Error
+	return completedFiles, err
+}
+
+/*GetFileOwnerAccountID returns the ID of the account owning the file, empty when it has no owner*/
+func GetFileOwnerAccountID(fileID string) (string, error) {
+	fileOwner := FileOwner{}
+	err := DB.Where("file_id = ?", fileID).First(&fileOwner).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return "", nil
+	}
+	return fileOwner.AccountID, err
+}
+
+/*GetStorageUsedInByteByOwner sums the sizes of the completed files owned by the account*/
+func GetStorageUsedInByteByOwner(accountID string) (int64, error) {
+	rows, err := DB.Table("file_owners").
+		Joins("JOIN completed_files ON completed_files.file_id = file_owners.file_id").
+		Where("file_owners.account_id = ?", accountID).
+		Select("COALESCE(SUM(completed_files.file_size_in_byte), 0)").Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	total := int64(0)
+	if rows.Next() {
+		if err := rows.Scan(&total); err != nil {
+			return 0, err
+		}
+	}
+	return total, rows.Err()
+}
@@ -26,6 +26,12 @@ type File struct {
 	CompletedIndexes *string   `json:"completedIndexes" gorm:"type:mediumtext"`
 	ModifierHash     string    `json:"modifierHash" validate:"required,len=64" minLength:"64" maxLength:"64"`
 	ApiVersion       int       `json:"apiVersion" validate:"omitempty,gte=1" gorm:"default:1"`
+	/*UploadLength is the size of the file announced when a tus upload is created, 0 for other uploads*/
+	UploadLength int64 `json:"uploadLength" gorm:"default:0"`
+	/*FileSizeInByte is the size of the file announced by init-upload, 0 for uploads started before it was kept*/
+	FileSizeInByte int64 `json:"fileSizeInByte" gorm:"default:0"`
+	/*Sha256 is the hex SHA-256 of the whole file announced by the client, if it gave one*/
+	Sha256 string `json:"sha256" validate:"omitempty,len=64,hexadecimal"`
 }
 
 type IndexMap map[int64]*s3.CompletedPart
@@ -114,6 +120,11 @@ func GetPublicThumbnailKey(fileID string) string {
 	return fileID + "/thumbnail"
 }
 
+/*GetFileUploadTailKey is where a tus upload keeps the bytes received after its last complete part*/
+func GetFileUploadTailKey(fileID string) string {
+	return fileID + "/tail"
+}
+
 /*Return File object(first one) if there is not any error. If not found, return nil without error. */
 func GetFileById(fileID string) (File, error) {
 	file := File{}
@@ -229,6 +240,18 @@ func (file *File) UploadCompleted() bool {
 	return true
 }
 
+/*GetFilesAfter returns up to limit uploads whose file ID comes after fileID, in file ID order*/
+func GetFilesAfter(fileID string, limit int) ([]File, error) {
+	files := []File{}
+	err := DB.Where("file_id > ?", fileID).Order("file_id").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+/*GetExistingFileIDs returns which of the file IDs have an upload in progress*/
+func GetExistingFileIDs(fileIDs []string) (map[string]bool, error) {
+	return getExistingFileIDs(DB.Model(&File{}), fileIDs)
+}
+
 /*FinishUpload - finishes the upload*/
 func (file *File) FinishUpload() (CompletedFile, error) {
 	allChunksUploaded := file.UploadCompleted()
@@ -246,12 +269,21 @@ func (file *File) FinishUpload() (CompletedFile, error) {
 		return CompletedFile{}, err
 	}
 
+	digests, err := GetCompletedUploadDigests(file.FileID)
+	if err != nil {
+		return CompletedFile{}, err
+	}
+
 	objectSize := utils.GetDefaultBucketObjectSize(objectKey)
 	completedFile := CompletedFile{
 		FileID:         file.FileID,
 		ExpiredAt:      file.ExpiredAt,
 		FileSizeInByte: objectSize,
 		ModifierHash:   file.ModifierHash,
+		Sha256:         file.Sha256,
+	}
+	if err := completedFile.SetPartDigests(digests); err != nil {
+		return CompletedFile{}, err
 	}
 	if err := DB.Save(&completedFile).Error; err != nil {
 		return CompletedFile{}, err
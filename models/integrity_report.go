@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	/*IntegrityIssueDataMissing is for completed files whose data object is not in the bucket*/
+	IntegrityIssueDataMissing = "data_missing"
+
+	/*IntegrityIssueMetadataMissing is for completed files whose metadata object is not in the bucket*/
+	IntegrityIssueMetadataMissing = "metadata_missing"
+
+	/*IntegrityIssueSizeMismatch is for completed files whose data object doesn't have FileSizeInByte bytes*/
+	IntegrityIssueSizeMismatch = "size_mismatch"
+)
+
+/*IntegrityIssues are all the issues the integrity scrubber looks for*/
+var IntegrityIssues = []string{IntegrityIssueDataMissing, IntegrityIssueMetadataMissing, IntegrityIssueSizeMismatch}
+
+/*IntegrityReport is an issue the integrity scrubber found with a completed file. A report stays open
+until a later pass doesn't find the issue anymore.*/
+type IntegrityReport struct {
+	ID           uint       `gorm:"primary_key" json:"id"`
+	FileID       string     `gorm:"not null;index:idx_integrity_report_file_id" json:"fileID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	Issue        string     `gorm:"not null" json:"issue" validate:"required,oneof=data_missing metadata_missing size_mismatch"`
+	ExpectedSize int64      `json:"expectedSize"`
+	ActualSize   int64      `json:"actualSize"`
+	FirstSeenAt  time.Time  `json:"firstSeenAt"`
+	LastSeenAt   time.Time  `json:"lastSeenAt"`
+	ResolvedAt   *time.Time `json:"resolvedAt"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (report *IntegrityReport) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(report)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (report *IntegrityReport) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(report)
+}
+
+/*RecordIntegrityIssue opens a report for the issue of the file, or refreshes the one already open*/
+func RecordIntegrityIssue(fileID, issue string, expectedSize, actualSize int64) error {
+	now := time.Now()
+	report := IntegrityReport{}
+	err := DB.Where("file_id = ? AND issue = ? AND resolved_at IS NULL", fileID, issue).First(&report).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+	if err == nil {
+		report.ExpectedSize = expectedSize
+		report.ActualSize = actualSize
+		report.LastSeenAt = now
+		return DB.Save(&report).Error
+	}
+
+	report = IntegrityReport{
+		FileID:       fileID,
+		Issue:        issue,
+		ExpectedSize: expectedSize,
+		ActualSize:   actualSize,
+		FirstSeenAt:  now,
+		LastSeenAt:   now,
+	}
+	return DB.Create(&report).Error
+}
+
+/*ResolveIntegrityIssues closes the open reports of the file, except for the issues it still has*/
+func ResolveIntegrityIssues(fileID string, remainingIssues []string) error {
+	query := DB.Model(&IntegrityReport{}).Where("file_id = ? AND resolved_at IS NULL", fileID)
+	if len(remainingIssues) > 0 {
+		query = query.Where("issue NOT IN (?)", remainingIssues)
+	}
+	return query.UpdateColumn("resolved_at", time.Now()).Error
+}
+
+/*ResolveIntegrityReportsOfDeletedFiles closes the open reports of files that are not completed files anymore*/
+func ResolveIntegrityReportsOfDeletedFiles() error {
+	return DB.Model(&IntegrityReport{}).
+		Where("resolved_at IS NULL AND file_id NOT IN (?)", DB.Table("completed_files").Select("file_id").QueryExpr()).
+		UpdateColumn("resolved_at", time.Now()).Error
+}
+
+/*GetOpenIntegrityReports returns up to limit open reports, the most recently seen first*/
+func GetOpenIntegrityReports(limit int) ([]IntegrityReport, error) {
+	reports := []IntegrityReport{}
+	err := DB.Where("resolved_at IS NULL").Order("last_seen_at desc").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+/*CountOpenIntegrityReports returns the number of open reports of each issue*/
+func CountOpenIntegrityReports() (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, issue := range IntegrityIssues {
+		counts[issue] = 0
+	}
+
+	rows, err := DB.Model(&IntegrityReport{}).Select("issue, count(*)").Where("resolved_at IS NULL").Group("issue").Rows()
+	if err != nil {
+		return counts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var issue string
+		var count int
+		if err := rows.Scan(&issue, &count); err != nil {
+			return counts, err
+		}
+		counts[issue] = count
+	}
+	return counts, rows.Err()
+}
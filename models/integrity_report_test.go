@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Integrity_Report(t *testing.T) {
+	utils.SetTesting("../.env")
+	Connect(utils.Env.TestDatabaseURL)
+}
+
+func Test_RecordIntegrityIssue_Refreshes_Open_Report(t *testing.T) {
+	DeleteIntegrityReportsForTest(t)
+	fileID := utils.GenerateFileHandle()
+
+	assert.Nil(t, RecordIntegrityIssue(fileID, IntegrityIssueSizeMismatch, 100, 10))
+	assert.Nil(t, RecordIntegrityIssue(fileID, IntegrityIssueSizeMismatch, 100, 20))
+
+	reports, err := GetOpenIntegrityReports(10)
+	assert.Nil(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, int64(20), reports[0].ActualSize)
+	assert.False(t, reports[0].LastSeenAt.Before(reports[0].FirstSeenAt))
+}
+
+func Test_RecordIntegrityIssue_Rejects_Unknown_Issue(t *testing.T) {
+	assert.NotNil(t, RecordIntegrityIssue(utils.GenerateFileHandle(), "unknown", 0, 0))
+}
+
+func Test_ResolveIntegrityIssues(t *testing.T) {
+	DeleteIntegrityReportsForTest(t)
+	fileID := utils.GenerateFileHandle()
+
+	assert.Nil(t, RecordIntegrityIssue(fileID, IntegrityIssueDataMissing, 100, 0))
+	assert.Nil(t, RecordIntegrityIssue(fileID, IntegrityIssueMetadataMissing, 0, 0))
+
+	assert.Nil(t, ResolveIntegrityIssues(fileID, []string{IntegrityIssueMetadataMissing}))
+	counts, err := CountOpenIntegrityReports()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, counts[IntegrityIssueDataMissing])
+	assert.Equal(t, 1, counts[IntegrityIssueMetadataMissing])
+	assert.Equal(t, 0, counts[IntegrityIssueSizeMismatch])
+
+	assert.Nil(t, ResolveIntegrityIssues(fileID, nil))
+	reports, err := GetOpenIntegrityReports(10)
+	assert.Nil(t, err)
+	assert.Empty(t, reports)
+}
+
+func Test_ResolveIntegrityReportsOfDeletedFiles(t *testing.T) {
+	DeleteIntegrityReportsForTest(t)
+	DeleteCompletedFilesForTest(t)
+	completedFile := CompletedFile{
+		FileID:       utils.GenerateFileHandle(),
+		ModifierHash: utils.GenerateFileHandle(),
+	}
+	assert.Nil(t, DB.Create(&completedFile).Error)
+
+	assert.Nil(t, RecordIntegrityIssue(completedFile.FileID, IntegrityIssueDataMissing, 0, 0))
+	assert.Nil(t, RecordIntegrityIssue(utils.GenerateFileHandle(), IntegrityIssueDataMissing, 0, 0))
+
+	assert.Nil(t, ResolveIntegrityReportsOfDeletedFiles())
+	reports, err := GetOpenIntegrityReports(10)
+	assert.Nil(t, err)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, completedFile.FileID, reports[0].FileID)
+}
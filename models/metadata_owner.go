@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/utils"
+)
+
+/*MetadataOwner links a metadata to the account it counts against. MetadataKey is the key as the
+client sends it: hex for v1 metadata, URL safe base64 for v2 metadata.*/
+type MetadataOwner struct {
+	MetadataKey string    `gorm:"primary_key" json:"metadataKey" validate:"required,max=64"`
+	AccountID   string    `gorm:"not null;index:idx_metadata_owner_account_id" json:"accountID" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	ApiVersion  int       `json:"apiVersion" validate:"required,oneof=1 2"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+/*BeforeCreate - callback called before the row is created*/
+func (metadataOwner *MetadataOwner) BeforeCreate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(metadataOwner)
+}
+
+/*BeforeUpdate - callback called before the row is updated*/
+func (metadataOwner *MetadataOwner) BeforeUpdate(scope *gorm.Scope) error {
+	return utils.Validator.Struct(metadataOwner)
+}
+
+/*KvKey is the key the metadata is kept under in the kv store*/
+func (metadataOwner MetadataOwner) KvKey() (string, error) {
+	if metadataOwner.ApiVersion == 1 {
+		return metadataOwner.MetadataKey, nil
+	}
+	keyBin, err := base64.URLEncoding.DecodeString(metadataOwner.MetadataKey)
+	return string(keyBin), err
+}
+
+/*SetMetadataOwner records the account owning the metadata*/
+func SetMetadataOwner(metadataKey, accountID string, apiVersion int) error {
+	return DB.Save(&MetadataOwner{MetadataKey: metadataKey, AccountID: accountID, ApiVersion: apiVersion}).Error
+}
+
+/*DeleteMetadataOwners forgets the owners of the metadatas*/
+func DeleteMetadataOwners(metadataKeys []string) error {
+	if len(metadataKeys) == 0 {
+		return nil
+	}
+	return DB.Where("metadata_key IN (?)", metadataKeys).Delete(MetadataOwner{}).Error
+}
+
+/*GetMetadataOwnersByAccountID returns the metadatas owned by the account*/
+func GetMetadataOwnersByAccountID(accountID string) ([]MetadataOwner, error) {
+	metadataOwners := []MetadataOwner{}
+	err := DB.Where("account_id = ?", accountID).Find(&metadataOwners).Error
+	return metadataOwners, err
+}
+
+/*GetMetadataOwnersByAccountIDAfter returns up to limit metadatas owned by the account whose key comes after
+metadataKey, in key order*/
+func GetMetadataOwnersByAccountIDAfter(accountID, metadataKey string, limit int) ([]MetadataOwner, error) {
+	metadataOwners := []MetadataOwner{}
+	err := DB.Where("account_id = ? AND metadata_key > ?", accountID, metadataKey).Order("metadata_key").
+		Limit(limit).Find(&metadataOwners).Error
+	return metadataOwners, err
+}
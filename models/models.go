@@ -8,6 +8,7 @@ import (
 	/*blank import to make drivers available*/
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
+	"github.com/meirf/gopart"
 	"github.com/opacity/storage-node/services"
 	"github.com/opacity/storage-node/utils"
 )
@@ -44,6 +45,13 @@ func Connect(dbURL string) {
 	DB.AutoMigrate(&ExpiredAccount{})
 	DB.AutoMigrate(&PublicShare{})
 	DB.AutoMigrate(&SmartContract{})
+	DB.AutoMigrate(&DelegatedKey{})
+	DB.AutoMigrate(&IntegrityReport{})
+	DB.AutoMigrate(&FileOwner{})
+	DB.AutoMigrate(&MetadataOwner{})
+	DB.AutoMigrate(&ExpiryPropagation{})
+	DB.AutoMigrate(&EgressUsage{})
+	DB.AutoMigrate(&AccessLogOffset{})
 	DB.AutoMigrate(&utils.PlanInfo{})
 }
 
@@ -51,3 +59,18 @@ func Connect(dbURL string) {
 func Close() {
 	DB.Close()
 }
+
+/*getExistingFileIDs returns which of the file IDs are in the file_id column of the query's table*/
+func getExistingFileIDs(query *gorm.DB, fileIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for fileIDsRange := range gopart.Partition(len(fileIDs), 5000) {
+		var found []string
+		if err := query.Where("file_id IN (?)", fileIDs[fileIDsRange.Low:fileIDsRange.High]).Pluck("file_id", &found).Error; err != nil {
+			return existing, err
+		}
+		for _, fileID := range found {
+			existing[fileID] = true
+		}
+	}
+	return existing, nil
+}
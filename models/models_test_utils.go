@@ -85,6 +85,62 @@ func DeletePublicSharesForTest(t *testing.T) {
 	}
 }
 
+func DeleteDelegatedKeysForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteDelegatedKeysForTest method on test database")
+	} else {
+		DB.Exec("DELETE from delegated_keys;")
+	}
+}
+
+func DeleteFileOwnersForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteFileOwnersForTest method on test database")
+	} else {
+		DB.Exec("DELETE from file_owners;")
+	}
+}
+
+func DeleteMetadataOwnersForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteMetadataOwnersForTest method on test database")
+	} else {
+		DB.Exec("DELETE from metadata_owners;")
+	}
+}
+
+func DeleteIntegrityReportsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteIntegrityReportsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from integrity_reports;")
+	}
+}
+
+func DeleteExpiryPropagationsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteExpiryPropagationsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from expiry_propagations;")
+	}
+}
+
+func DeleteEgressUsagesForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteEgressUsagesForTest method on test database")
+	} else {
+		DB.Exec("DELETE from egress_usages;")
+	}
+}
+
+func DeleteAccessLogOffsetsForTest(t *testing.T) {
+	if utils.Env.DatabaseURL != utils.Env.TestDatabaseURL {
+		t.Fatalf("should only be calling DeleteAccessLogOffsetsForTest method on test database")
+	} else {
+		DB.Exec("DELETE from access_log_offsets;")
+	}
+}
+
 func CreateTestPublicShare(t *testing.T) PublicShare {
 	ps := CreatePublicShareObj()
 	assert.Nil(t, DB.Create(&ps).Error)
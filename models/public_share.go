@@ -2,25 +2,29 @@ package models
 
 import (
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/meirf/gopart"
 	"github.com/opacity/storage-node/utils"
 	"github.com/teris-io/shortid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // PublicShare ...
 type PublicShare struct {
-	PublicID      string    `gorm:"UNIQUE_INDEX:idx_publicshare;primary_key;autoIncrement:false" json:"public_id" validate:"required"`
-	CreatedAt     time.Time `json:"createdAt"`
-	ViewsCount    int       `gorm:"not null" json:"views_count"`
-	Title         string    `gorm:"not null;size:65535" json:"title"`
-	Description   string    `gorm:"not null;size:65535" json:"description"`
-	MimeType      string    `gorm:"not null;size:255" json:"mimeType"`
-	FileExtension string    `gorm:"not null;size:255" json:"fileExtension"`
-	FileID        string    `gorm:"not null" json:"file_id" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	PublicID      string     `gorm:"UNIQUE_INDEX:idx_publicshare;primary_key;autoIncrement:false" json:"public_id" validate:"required"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ViewsCount    int        `gorm:"not null" json:"views_count"`
+	Title         string     `gorm:"not null;size:65535" json:"title"`
+	Description   string     `gorm:"not null;size:65535" json:"description"`
+	MimeType      string     `gorm:"not null;size:255" json:"mimeType"`
+	FileExtension string     `gorm:"not null;size:255" json:"fileExtension"`
+	FileID        string     `gorm:"not null" json:"file_id" validate:"required,len=64" minLength:"64" maxLength:"64"`
+	EgressBytes   int64      `gorm:"not null;default:0" json:"egress_bytes"` // bytes of the public file the bucket served
+	PasswordHash  string     `gorm:"not null;default:''" json:"-"`           // bcrypt hash, empty when the share is open to anyone
+	ExpiresAt     *time.Time `gorm:"index" json:"expiresAt,omitempty"`       // the share stops working at that time, never when nil
+	MaxViews      int        `gorm:"not null;default:0" json:"maxViews"`     // the share stops working after that many views, 0 for no limit
 }
 
 // CreateShortlinkObj...
@@ -30,6 +34,9 @@ type CreateShortlinkObj struct {
 	MimeType      string `json:"mimeType" minLength:"1" maxLength:"255" example:"image/png"`
 	FileExtension string `json:"fileExtension" minLength:"1" maxLength:"255" example:"png"`
 	Description   string `json:"description" binding:"required" minLength:"1" maxLength:"65535" example:"lorem ipsum"`
+	Password      string `json:"password,omitempty" validate:"omitempty,max=72" maxLength:"72" example:"an optional password to open the shortlink"`
+	ExpiresAt     int64  `json:"expiresAt,omitempty" validate:"omitempty,gt=0" example:"1557346389"`
+	MaxViews      int    `json:"maxViews,omitempty" validate:"omitempty,gte=1" example:"10"`
 }
 
 /*BeforeCreate - callback called before the row is created*/
@@ -49,9 +56,146 @@ func GetPublicShareByID(publicID string) (PublicShare, error) {
 	return publicShare, err
 }
 
-// UpdateViewsCount increments the views count of a PublicShare by 1
+/*ErrPublicShareExhausted is returned for a public share that expired or was viewed as many times as allowed*/
+var ErrPublicShareExhausted = errors.New("this public share expired or reached its maximum number of views")
+
+// UpdateViewsCount increments the views count of a PublicShare by 1, unless it reached its maximum number of views
 func (publicShare *PublicShare) UpdateViewsCount() error {
-	return DB.Model(&publicShare).Update("views_count", publicShare.ViewsCount+1).Error
+	result := DB.Model(&PublicShare{}).
+		Where("public_id = ? AND (max_views = 0 OR views_count < max_views)", publicShare.PublicID).
+		UpdateColumn("views_count", gorm.Expr("views_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPublicShareExhausted
+	}
+	publicShare.ViewsCount++
+	return nil
+}
+
+/*IsExhausted returns whether the public share expired or was viewed as many times as allowed*/
+func (publicShare *PublicShare) IsExhausted() bool {
+	if publicShare.ExpiresAt != nil && !time.Now().Before(*publicShare.ExpiresAt) {
+		return true
+	}
+	return publicShare.MaxViews > 0 && publicShare.ViewsCount >= publicShare.MaxViews
+}
+
+/*IsPublicFileExhausted returns whether the file is shared and every one of its public shares is exhausted*/
+func IsPublicFileExhausted(fileID string) (bool, error) {
+	publicShares := []PublicShare{}
+	if err := DB.Where("file_id = ?", fileID).Find(&publicShares).Error; err != nil {
+		return false, err
+	}
+	for _, publicShare := range publicShares {
+		if !publicShare.IsExhausted() {
+			return false, nil
+		}
+	}
+	return len(publicShares) > 0, nil
+}
+
+/*GetExhaustedPublicShares returns up to limit public shares that expired or were viewed as many times as allowed*/
+func GetExhaustedPublicShares(limit int) ([]PublicShare, error) {
+	publicShares := []PublicShare{}
+	err := DB.Where("(expires_at IS NOT NULL AND expires_at <= ?) OR (max_views > 0 AND views_count >= max_views)", time.Now()).
+		Order("public_id").Limit(limit).Find(&publicShares).Error
+	return publicShares, err
+}
+
+/*SetPassword protects the public share with the password, an empty password opens it to anyone again*/
+func (publicShare *PublicShare) SetPassword(password string) error {
+	passwordHash, err := hashPublicSharePassword(password)
+	if err != nil {
+		return err
+	}
+	publicShare.PasswordHash = passwordHash
+	return DB.Model(publicShare).UpdateColumn("password_hash", passwordHash).Error
+}
+
+/*IsProtected returns whether a password is needed to open the public share*/
+func (publicShare *PublicShare) IsProtected() bool {
+	return publicShare.PasswordHash != ""
+}
+
+/*CheckPassword returns whether the password opens the public share*/
+func (publicShare *PublicShare) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(publicShare.PasswordHash), []byte(password)) == nil
+}
+
+func hashPublicSharePassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) > 72 {
+		return "", errors.New("the password can't be longer than 72 bytes")
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(passwordHash), err
+}
+
+/*IsPublicFileProtected returns whether the file is shared and every one of its public shares needs a
+password, its public object must then only be handed out through presigned URLs*/
+func IsPublicFileProtected(fileID string) (bool, error) {
+	var counts struct {
+		Shares    int
+		Protected int
+	}
+	err := DB.Model(&PublicShare{}).Where("file_id = ?", fileID).
+		Select("COUNT(*) AS shares, COALESCE(SUM(password_hash <> ''), 0) AS protected").Scan(&counts).Error
+	return counts.Shares > 0 && counts.Shares == counts.Protected, err
+}
+
+/*IsPublicFileLimited returns whether one of the public shares of the file expires or has a maximum number of
+views, which only hold while its public object is handed out through presigned URLs*/
+func IsPublicFileLimited(fileID string) (bool, error) {
+	count := 0
+	err := DB.Model(&PublicShare{}).Where("file_id = ? AND (expires_at IS NOT NULL OR max_views > 0)", fileID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+/*IsPublicFilePrivate returns whether the public objects of the file must be private, because every one of its
+public shares is protected or one of them is limited*/
+func IsPublicFilePrivate(fileID string) (bool, error) {
+	protected, err := IsPublicFileProtected(fileID)
+	if err != nil || protected {
+		return protected, err
+	}
+	return IsPublicFileLimited(fileID)
+}
+
+/*SyncPublicFileAcl makes the public objects of the file private while IsPublicFilePrivate, and readable by
+anyone otherwise*/
+func SyncPublicFileAcl(fileID string) error {
+	private, err := IsPublicFilePrivate(fileID)
+	if err != nil {
+		return err
+	}
+	cannedAcl := utils.CannedAcl_PublicRead
+	if private {
+		cannedAcl = utils.CannedAcl_Private
+	}
+	for _, key := range []string{GetFileDataPublicKey(fileID), GetPublicThumbnailKey(fileID)} {
+		if !utils.DoesDefaultBucketObjectExist(key) {
+			continue
+		}
+		if err := utils.SetDefaultObjectCannedAcl(key, cannedAcl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*GetLeastLimitedOpenPublicShare returns the public share of the file that needs no password, isn't exhausted,
+and limits the least: one without a maximum number of views first, then the one expiring last*/
+func GetLeastLimitedOpenPublicShare(fileID string) (PublicShare, error) {
+	publicShare := PublicShare{}
+	err := DB.Where("file_id = ? AND password_hash = '' AND (expires_at IS NULL OR expires_at > ?) AND "+
+		"(max_views = 0 OR views_count < max_views)", fileID, time.Now()).
+		Order("max_views > 0, expires_at IS NOT NULL, expires_at DESC").First(&publicShare).Error
+	return publicShare, err
 }
 
 // RemovePublicShare removes a public share (revokes it)
@@ -72,6 +216,18 @@ func RemovePublicSharesById(fileID string) error {
 	return DB.Where("file_id = ?", fileID).Delete(PublicShare{}).Error
 }
 
+/*GetPublicSharesAfter returns up to limit public shares whose public ID comes after publicID, in public ID order*/
+func GetPublicSharesAfter(publicID string, limit int) ([]PublicShare, error) {
+	publicShares := []PublicShare{}
+	err := DB.Where("public_id > ?", publicID).Order("public_id").Limit(limit).Find(&publicShares).Error
+	return publicShares, err
+}
+
+/*GetExistingPublicShareFileIDs returns which of the file IDs are shared publicly*/
+func GetExistingPublicShareFileIDs(fileIDs []string) (map[string]bool, error) {
+	return getExistingFileIDs(DB.Model(&PublicShare{}), fileIDs)
+}
+
 func CreatePublicShare(createShortlinkObj CreateShortlinkObj) (PublicShare, error) {
 	shortID, err := shortid.Generate()
 	if err != nil {
@@ -96,6 +252,14 @@ func CreatePublicShare(createShortlinkObj CreateShortlinkObj) (PublicShare, erro
 	if createShortlinkObj.FileExtension == "" {
 		publicShare.FileExtension = "png"
 	}
+	if publicShare.PasswordHash, err = hashPublicSharePassword(createShortlinkObj.Password); err != nil {
+		return PublicShare{}, err
+	}
+	if createShortlinkObj.ExpiresAt != 0 {
+		expiresAt := time.Unix(createShortlinkObj.ExpiresAt, 0)
+		publicShare.ExpiresAt = &expiresAt
+	}
+	publicShare.MaxViews = createShortlinkObj.MaxViews
 
 	if err := DB.Save(&publicShare).Error; err != nil {
 		return PublicShare{}, errors.New("error saving the public share")
@@ -104,9 +268,9 @@ func CreatePublicShare(createShortlinkObj CreateShortlinkObj) (PublicShare, erro
 	return publicShare, nil
 }
 
-// Gets S3 bucket URL
+// Gets S3 bucket URL, or the URL of the node itself when objects are stored locally
 func GetBucketUrl() string {
-	return fmt.Sprintf("https://s3.%s.amazonaws.com/%s/", utils.Env.AwsRegion, utils.Env.BucketName)
+	return utils.GetBlobStoreBaseURL()
 }
 
 func GetPublicFileDownloadData(fileID string) (fileURL, thumbnailURL string) {
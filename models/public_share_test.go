@@ -2,7 +2,9 @@ package models
 
 import (
 	"testing"
+	"time"
 
+	"github.com/jinzhu/gorm"
 	"github.com/opacity/storage-node/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -54,3 +56,122 @@ func Test_Public_Share_Empty_FileID_Fails(t *testing.T) {
 		ps.RemovePublicShare()
 	})
 }
+
+func Test_PublicShare_Password(t *testing.T) {
+	DeletePublicSharesForTest(t)
+	publicShare := CreateTestPublicShare(t)
+	assert.False(t, publicShare.IsProtected())
+
+	protected, err := IsPublicFileProtected(publicShare.FileID)
+	assert.Nil(t, err)
+	assert.False(t, protected)
+
+	assert.Nil(t, publicShare.SetPassword("secret"))
+	publicShare, err = GetPublicShareByID(publicShare.PublicID)
+	assert.Nil(t, err)
+	assert.True(t, publicShare.IsProtected())
+	assert.True(t, publicShare.CheckPassword("secret"))
+	assert.False(t, publicShare.CheckPassword("Secret"))
+
+	protected, err = IsPublicFileProtected(publicShare.FileID)
+	assert.Nil(t, err)
+	assert.True(t, protected)
+
+	// Another share of the file that is open leaves the file readable by anyone
+	openShare := CreatePublicShareObj()
+	openShare.FileID = publicShare.FileID
+	assert.Nil(t, DB.Create(&openShare).Error)
+	protected, err = IsPublicFileProtected(publicShare.FileID)
+	assert.Nil(t, err)
+	assert.False(t, protected)
+
+	assert.Nil(t, publicShare.SetPassword(""))
+	assert.False(t, publicShare.IsProtected())
+}
+
+func Test_PublicShare_Max_Views(t *testing.T) {
+	DeletePublicSharesForTest(t)
+	publicShare := CreatePublicShareObj()
+	publicShare.MaxViews = 2
+	assert.Nil(t, DB.Create(&publicShare).Error)
+
+	assert.Nil(t, publicShare.UpdateViewsCount())
+	assert.False(t, publicShare.IsExhausted())
+	assert.Nil(t, publicShare.UpdateViewsCount())
+	assert.True(t, publicShare.IsExhausted())
+	assert.Equal(t, ErrPublicShareExhausted, publicShare.UpdateViewsCount())
+
+	publicShare, err := GetPublicShareByID(publicShare.PublicID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, publicShare.ViewsCount)
+
+	exhausted, err := IsPublicFileExhausted(publicShare.FileID)
+	assert.Nil(t, err)
+	assert.True(t, exhausted)
+
+	publicShares, err := GetExhaustedPublicShares(10)
+	assert.Nil(t, err)
+	assert.Len(t, publicShares, 1)
+}
+
+func Test_PublicShare_Expires(t *testing.T) {
+	DeletePublicSharesForTest(t)
+	publicShare := CreatePublicShareObj()
+	expiresAt := time.Now().Add(time.Hour)
+	publicShare.ExpiresAt = &expiresAt
+	assert.Nil(t, DB.Create(&publicShare).Error)
+	assert.False(t, publicShare.IsExhausted())
+
+	exhausted, err := IsPublicFileExhausted(publicShare.FileID)
+	assert.Nil(t, err)
+	assert.False(t, exhausted)
+	publicShares, err := GetExhaustedPublicShares(10)
+	assert.Nil(t, err)
+	assert.Len(t, publicShares, 0)
+
+	expiresAt = time.Now().Add(-time.Minute)
+	assert.True(t, publicShare.IsExhausted())
+	assert.Nil(t, DB.Model(&publicShare).UpdateColumn("expires_at", expiresAt).Error)
+	publicShares, err = GetExhaustedPublicShares(10)
+	assert.Nil(t, err)
+	assert.Len(t, publicShares, 1)
+}
+
+func Test_PublicShare_Limits_Make_File_Private(t *testing.T) {
+	DeletePublicSharesForTest(t)
+	openShare := CreateTestPublicShare(t)
+	private, err := IsPublicFilePrivate(openShare.FileID)
+	assert.Nil(t, err)
+	assert.False(t, private)
+
+	expiresAt := time.Now().Add(time.Hour)
+	expiringShare := CreatePublicShareObj()
+	expiringShare.FileID = openShare.FileID
+	expiringShare.ExpiresAt = &expiresAt
+	assert.Nil(t, DB.Create(&expiringShare).Error)
+	cappedShare := CreatePublicShareObj()
+	cappedShare.FileID = openShare.FileID
+	cappedShare.MaxViews = 1
+	assert.Nil(t, DB.Create(&cappedShare).Error)
+
+	limited, err := IsPublicFileLimited(openShare.FileID)
+	assert.Nil(t, err)
+	assert.True(t, limited)
+	private, err = IsPublicFilePrivate(openShare.FileID)
+	assert.Nil(t, err)
+	assert.True(t, private)
+
+	leastLimited, err := GetLeastLimitedOpenPublicShare(openShare.FileID)
+	assert.Nil(t, err)
+	assert.Equal(t, openShare.PublicID, leastLimited.PublicID)
+
+	assert.Nil(t, openShare.RemovePublicShare())
+	leastLimited, err = GetLeastLimitedOpenPublicShare(openShare.FileID)
+	assert.Nil(t, err)
+	assert.Equal(t, expiringShare.PublicID, leastLimited.PublicID)
+
+	assert.Nil(t, expiringShare.RemovePublicShare())
+	assert.Nil(t, cappedShare.UpdateViewsCount())
+	_, err = GetLeastLimitedOpenPublicShare(openShare.FileID)
+	assert.True(t, gorm.IsRecordNotFoundError(err))
+}
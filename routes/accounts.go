@@ -59,6 +59,8 @@ type accountGetObj struct {
 	TotalMetadataSizeInMB float64                 `json:"totalMetadataSizeInMB" validate:"" example:"1.245765432"`
 	MaxFolders            int                     `json:"maxFolders" validate:"" example:"2000"`
 	MaxMetadataSizeInMB   int64                   `json:"maxMetadataSizeInMB" validate:"" example:"200"`
+	EgressUsedThisMonth   float64                 `json:"egressUsedThisMonth" validate:"" example:"12.5"` // how much they downloaded this month, in GB
+	MaxEgressGBPerMonth   int                     `json:"maxEgressGBPerMonth" validate:"" example:"256"`  // 0 when downloads are not limited
 }
 
 type accountGetReqObj struct {
@@ -214,6 +216,7 @@ func createAccount(c *gin.Context) error {
 		ApiVersion:           2,
 		MonthsInSubscription: request.accountCreateObj.DurationInMonths,
 		ExpiredAt:            time.Now().AddDate(0, request.accountCreateObj.DurationInMonths, 0),
+		OwnersIndexed:        true,
 	}
 
 	// Add account to DB
@@ -284,6 +287,11 @@ func checkAccountPaymentStatus(c *gin.Context) error {
 		}
 	}
 
+	egressUsage, egressErr := models.GetEgressUsage(account.AccountID, models.EgressMonth(time.Now()))
+	if egressErr != nil {
+		return InternalErrorResponse(c, egressErr)
+	}
+
 	accountStillActive := verifyAccountStillActive(account)
 
 	res.PaymentStatus = createPaymentStatusResponse(paid, pending, chargePaid, accountStillActive)
@@ -303,6 +311,8 @@ func checkAccountPaymentStatus(c *gin.Context) error {
 		TotalMetadataSizeInMB: float64(account.TotalMetadataSizeInBytes) / 1e6,
 		MaxFolders:            utils.Env.Plans[int(account.StorageLimit)].MaxFolders,
 		MaxMetadataSizeInMB:   utils.Env.Plans[int(account.StorageLimit)].MaxMetadataSizeInMB,
+		EgressUsedThisMonth:   float64(egressUsage.TotalBytes()) / 1e9,
+		MaxEgressGBPerMonth:   utils.Env.Plans[int(account.StorageLimit)].MaxEgressGBPerMonth,
 	}
 
 	if res.PaymentStatus == Paid {
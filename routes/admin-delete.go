@@ -38,6 +38,10 @@ func adminDeleteFile(c *gin.Context) error {
 		}
 	}
 
+	if err := models.DeleteFileOwners([]string{fileId}); err != nil {
+		utils.AppendIfError(err, &collectedErrors)
+	}
+
 	if err := utils.DeleteDefaultBucketObjectKeys(fileId); err != nil {
 		utils.AppendIfError(err, &collectedErrors)
 	}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+// The most open integrity reports shown on the admin page
+const adminIntegrityReportsLimit = 1000
+
+func AdminIntegrityReportsHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminIntegrityReports)
+}
+
+func adminIntegrityReports(c *gin.Context) error {
+	counts, err := models.CountOpenIntegrityReports()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	reports, err := models.GetOpenIntegrityReports(adminIntegrityReportsLimit)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.HTML(http.StatusOK, "integrity-reports.tmpl", gin.H{
+		"title":   "Integrity reports",
+		"counts":  counts,
+		"reports": reports,
+	})
+	return nil
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+)
+
+type kvBackupRes struct {
+	Backup utils.KvBackup `json:"backup"`
+}
+
+func AdminKvBackupsListHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminKvBackupsList)
+}
+
+func AdminKvBackupCreateHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminKvBackupCreate)
+}
+
+func AdminKvBackupExportHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminKvBackupExport)
+}
+
+func AdminKvBackupRestoreHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminKvBackupRestore)
+}
+
+func AdminKvBackupRestoreFileHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminKvBackupRestoreFile)
+}
+
+func adminKvBackupsList(c *gin.Context) error {
+	backups, err := utils.ListKvBackups()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.HTML(http.StatusOK, "kv-backups.tmpl", gin.H{
+		"title":   "KV store backups",
+		"backups": backups,
+	})
+	return nil
+}
+
+func adminKvBackupCreate(c *gin.Context) error {
+	backup, err := utils.BackupKvStoreToBlobStore()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	utils.SlackLog("Admin kv store backup written to " + backup.Key)
+
+	return OkResponse(c, kvBackupRes{Backup: backup})
+}
+
+/*adminKvBackupExport streams a backup of the running kv store. Entries older than the
+"since" query parameter are skipped, which gives an incremental export.*/
+func adminKvBackupExport(c *gin.Context) error {
+	var since uint64
+	if sinceParam := c.Query("since"); sinceParam != "" {
+		var err error
+		if since, err = strconv.ParseUint(sinceParam, 10, 64); err != nil {
+			return BadRequestResponse(c, errors.New("since must be a backup version"))
+		}
+	}
+
+	c.Header("Content-Type", utils.DefaultFileContentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="kv_export_%d_%d.bak"`, time.Now().Unix(), since))
+	c.Header("Trailer", "X-Backup-Next-Since")
+	c.Status(http.StatusOK)
+
+	nextSince, err := utils.BackupKvStore(c.Writer, since)
+	if err != nil {
+		utils.LogIfError(err, map[string]interface{}{"since": since})
+		return err
+	}
+	c.Writer.Header().Set("X-Backup-Next-Since", strconv.FormatUint(nextSince, 10))
+	return nil
+}
+
+func adminKvBackupRestore(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	key := c.Request.FormValue("key")
+	if key == "" {
+		return BadRequestResponse(c, errors.New("no backup key"))
+	}
+
+	if err := utils.RestoreKvStoreFromBlobStore(key); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	utils.SlackLog("Admin kv store restored from " + key)
+
+	return OkResponse(c, StatusRes{Status: "restore success"})
+}
+
+func adminKvBackupRestoreFile(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	fileHeader, err := c.FormFile("backup")
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	defer file.Close()
+
+	if err := utils.RestoreKvStore(file); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	utils.SlackLog("Admin kv store restored from the uploaded file " + fileHeader.Filename)
+
+	return OkResponse(c, StatusRes{Status: "restore success"})
+}
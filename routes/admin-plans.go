@@ -123,6 +123,10 @@ func adminPlanChange(c *gin.Context) error {
 	if err != nil {
 		return InternalErrorResponse(c, err)
 	}
+	maxEgressGBPerMonth, err := strconv.Atoi(c.Request.PostForm["maxEgressGBPerMonth"][0])
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	planInfo.Name = c.Request.PostForm["name"][0]
 	planInfo.Cost = cost
@@ -130,6 +134,7 @@ func adminPlanChange(c *gin.Context) error {
 	planInfo.StorageInGB = storageInGB
 	planInfo.MaxFolders = maxFolders
 	planInfo.MaxMetadataSizeInMB = maxMetadataSizeInMB
+	planInfo.MaxEgressGBPerMonth = maxEgressGBPerMonth
 
 	if err := models.DB.Save(&planInfo).Error; err == nil {
 		if err != nil {
@@ -179,6 +184,10 @@ func adminPlanAdd(c *gin.Context) error {
 	if err != nil {
 		return InternalErrorResponse(c, err)
 	}
+	maxEgressGBPerMonth, err := strconv.Atoi(c.Request.PostForm["maxEgressGBPerMonth"][0])
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	planInfo.Name = c.Request.PostForm["name"][0]
 	planInfo.Cost = cost
@@ -186,6 +195,7 @@ func adminPlanAdd(c *gin.Context) error {
 	planInfo.StorageInGB = storageInGB
 	planInfo.MaxFolders = maxFolders
 	planInfo.MaxMetadataSizeInMB = maxMetadataSizeInMB
+	planInfo.MaxEgressGBPerMonth = maxEgressGBPerMonth
 
 	if err := models.DB.Save(&planInfo).Error; err == nil {
 		if err != nil {
@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+func AdminAccountUsageGetHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminAccountUsageGet)
+}
+
+func AdminAccountUsageFixHandler() gin.HandlerFunc {
+	return ginHandlerFunc(adminAccountUsageFix)
+}
+
+/*adminAccountUsageGet compares the storage counters of the account with what it owns*/
+func adminAccountUsageGet(c *gin.Context) error {
+	report, err := computeAccountUsageForAdmin(c)
+	if err != nil {
+		return err
+	}
+	return OkResponse(c, report)
+}
+
+/*adminAccountUsageFix sets the storage counters of the account to what it owns*/
+func adminAccountUsageFix(c *gin.Context) error {
+	report, err := computeAccountUsageForAdmin(c)
+	if err != nil {
+		return err
+	}
+
+	if report.Drifted() {
+		if err := models.FixAccountUsage(report); err == models.ErrOwnersNotIndexed {
+			return BadRequestResponse(c, err)
+		} else if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		utils.SlackLog("Admin fixed the storage counters of account " + report.AccountID)
+	}
+
+	return OkResponse(c, report)
+}
+
+func computeAccountUsageForAdmin(c *gin.Context) (models.AccountUsageReport, error) {
+	account, err := models.GetAccountById(c.Param("accountID"))
+	if err != nil {
+		return models.AccountUsageReport{}, NotFoundResponse(c, err)
+	}
+
+	report, err := models.ComputeAccountUsage(account)
+	if err != nil {
+		return report, InternalErrorResponse(c, err)
+	}
+	return report, nil
+}
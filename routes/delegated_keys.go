@@ -0,0 +1,314 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const delegatedKeyContextKey = "delegatedKey"
+
+/*
+delegatedKeyScopesByPath is the scope a delegated key needs for each route it may call.
+Delegated keys are rejected on every other route.
+*/
+var delegatedKeyScopesByPath = map[string]string{
+	V1Path + MetadataGetPath:     models.DelegatedScopeMetadataRead,
+	V1Path + MetadataHistoryPath: models.DelegatedScopeMetadataRead,
+	V2Path + MetadataV2GetPath:   models.DelegatedScopeMetadataRead,
+
+	V1Path + InitUploadPath:                      models.DelegatedScopeUpload,
+	V1Path + UploadPath:                          models.DelegatedScopeUpload,
+	V1Path + UploadStatusPath:                    models.DelegatedScopeUpload,
+	V2Path + InitUploadPublicPath:                models.DelegatedScopeUpload,
+	V2Path + UploadPublicPath:                    models.DelegatedScopeUpload,
+	V2Path + UploadStatusPublicPath:              models.DelegatedScopeUpload,
+	V2Path + InitUploadDirectPath:                models.DelegatedScopeUpload,
+	V2Path + UploadCompleteDirectPath:            models.DelegatedScopeUpload,
+	V2Path + "/" + TusPathPrefix:                 models.DelegatedScopeUpload,
+	V2Path + "/" + TusPathPrefix + TusUploadPath: models.DelegatedScopeUpload,
+
+	V2Path + "/" + PublicSharePathPrefix + PrivateToPublicConvertPath: models.DelegatedScopePublicShare,
+	V2Path + "/" + PublicSharePathPrefix + CreateShortLinkPath:        models.DelegatedScopePublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicShareViewsCountPath:  models.DelegatedScopePublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicShareRevokePath:      models.DelegatedScopePublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicSharePasswordPath:    models.DelegatedScopePublicShare,
+}
+
+type delegatedKeyAddObj struct {
+	DelegatedPublicKey string   `json:"delegatedPublicKey" validate:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
+	Scopes             []string `json:"scopes" validate:"required,min=1" example:"metadata:read,upload"`
+	ExpiresAt          int64    `json:"expiresAt" validate:"required" example:"1659325302"`
+	Timestamp          int64    `json:"timestamp" validate:"required"`
+}
+
+type delegatedKeyAddReq struct {
+	verification
+	requestBody
+	delegatedKeyAddObj delegatedKeyAddObj
+}
+
+type delegatedKeyRemoveObj struct {
+	DelegatedPublicKey string `json:"delegatedPublicKey" validate:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
+	Timestamp          int64  `json:"timestamp" validate:"required"`
+}
+
+type delegatedKeyRemoveReq struct {
+	verification
+	requestBody
+	delegatedKeyRemoveObj delegatedKeyRemoveObj
+}
+
+type delegatedKeysListReq struct {
+	verification
+	requestBody
+	accountGetReqObj accountGetReqObj
+}
+
+type delegatedKeysListRes struct {
+	DelegatedKeys []models.DelegatedKey `json:"delegatedKeys"`
+}
+
+func (v *delegatedKeyAddReq) getObjectRef() interface{} {
+	return &v.delegatedKeyAddObj
+}
+
+func (v *delegatedKeyRemoveReq) getObjectRef() interface{} {
+	return &v.delegatedKeyRemoveObj
+}
+
+func (v *delegatedKeysListReq) getObjectRef() interface{} {
+	return &v.accountGetReqObj
+}
+
+// AddDelegatedKeyHandler godoc
+// @Summary register a delegated key on an account
+// @Description register another public key that can act on the account's files and metadata within its scopes
+// @Description until it expires. Scopes are "metadata:read", "upload" and "public-share". Only the account's
+// @Description own key can manage delegated keys. A delegated key stops working if an account is created with it.
+// @Accept  json
+// @Produce  json
+// @Param delegatedKeyAddReq body routes.delegatedKeyAddReq true "delegated key creation object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"delegatedPublicKey": "a 66-character public key",
+// @description 	"scopes": ["metadata:read", "upload"],
+// @description 	"expiresAt": 1659325302,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} models.DelegatedKey
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Router /api/v2/delegated-keys/add [post]
+/*AddDelegatedKeyHandler is a handler for registering a delegated key on an account*/
+func AddDelegatedKeyHandler() gin.HandlerFunc {
+	return ginHandlerFunc(addDelegatedKey)
+}
+
+// ListDelegatedKeysHandler godoc
+// @Summary list the delegated keys of an account
+// @Description list the delegated keys registered on an account, expired ones included
+// @Accept  json
+// @Produce  json
+// @Param delegatedKeysListReq body routes.delegatedKeysListReq true "delegated keys list object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.delegatedKeysListRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Router /api/v2/delegated-keys/list [post]
+/*ListDelegatedKeysHandler is a handler for listing the delegated keys of an account*/
+func ListDelegatedKeysHandler() gin.HandlerFunc {
+	return ginHandlerFunc(listDelegatedKeys)
+}
+
+// RemoveDelegatedKeyHandler godoc
+// @Summary remove a delegated key from an account
+// @Description remove a delegated key, it can't be used anymore
+// @Accept  json
+// @Produce  json
+// @Param delegatedKeyRemoveReq body routes.delegatedKeyRemoveReq true "delegated key removal object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"delegatedPublicKey": "a 66-character public key",
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "delegated key does not exist"
+// @Router /api/v2/delegated-keys/remove [post]
+/*RemoveDelegatedKeyHandler is a handler for removing a delegated key from an account*/
+func RemoveDelegatedKeyHandler() gin.HandlerFunc {
+	return ginHandlerFunc(removeDelegatedKey)
+}
+
+func addDelegatedKey(c *gin.Context) error {
+	request := delegatedKeyAddReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range request.delegatedKeyAddObj.Scopes {
+		if !models.IsValidDelegatedScope(scope) {
+			return BadRequestResponse(c, fmt.Errorf("unknown scope %q, valid scopes are %s", scope,
+				strings.Join(models.DelegatedScopes, ", ")))
+		}
+	}
+
+	expiresAt := time.Unix(request.delegatedKeyAddObj.ExpiresAt, 0)
+	if !expiresAt.After(time.Now()) {
+		return BadRequestResponse(c, errors.New("expiresAt must be in the future"))
+	}
+
+	delegatedPublicKey := request.delegatedKeyAddObj.DelegatedPublicKey
+	keyID, err := utils.HashString(delegatedPublicKey)
+	if err != nil {
+		return BadRequestResponse(c, fmt.Errorf("bad request, unable to parse hex: %v", err))
+	}
+
+	// A key can't be both an account and a delegated key
+	if existingAccount, err := models.GetAccountById(keyID); err == nil && existingAccount.AccountID != "" {
+		return BadRequestResponse(c, errors.New("the key is the key of an account"))
+	}
+
+	delegatedKey := models.DelegatedKey{
+		KeyID:            keyID,
+		AccountID:        account.AccountID,
+		PublicKey:        delegatedPublicKey,
+		AccountPublicKey: request.PublicKey,
+		Scopes:           strings.Join(request.delegatedKeyAddObj.Scopes, ","),
+		ExpiresAt:        expiresAt,
+	}
+	if _, err := models.GetDelegatedKeyByID(keyID); err == nil {
+		return BadRequestResponse(c, errors.New("the key is already delegated"))
+	}
+	if err := models.DB.Create(&delegatedKey).Error; err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, delegatedKey)
+}
+
+func listDelegatedKeys(c *gin.Context) error {
+	request := delegatedKeysListReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	delegatedKeys, err := models.GetDelegatedKeysByAccountID(account.AccountID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, delegatedKeysListRes{DelegatedKeys: delegatedKeys})
+}
+
+func removeDelegatedKey(c *gin.Context) error {
+	request := delegatedKeyRemoveReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	keyID, err := utils.HashString(request.delegatedKeyRemoveObj.DelegatedPublicKey)
+	if err != nil {
+		return BadRequestResponse(c, fmt.Errorf("bad request, unable to parse hex: %v", err))
+	}
+
+	delegatedKey, err := models.GetDelegatedKeyByID(keyID)
+	if err != nil || delegatedKey.AccountID != account.AccountID {
+		return NotFoundResponse(c, errors.New("delegated key does not exist"))
+	}
+
+	if err := models.DeleteDelegatedKey(account.AccountID, keyID); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, StatusRes{Status: "delegated key removed"})
+}
+
+/*
+resolveDelegatedKey returns the delegated key registered for publicKey, and false if publicKey isn't
+one. A delegated key that expired or lacks the scope of the route is rejected with a response.
+*/
+func resolveDelegatedKey(publicKey string, c *gin.Context) (models.DelegatedKey, bool, error) {
+	if delegatedKey, ok := getResolvedDelegatedKey(publicKey, c); ok {
+		return delegatedKey, true, nil
+	}
+
+	keyID, err := utils.HashString(publicKey)
+	if err != nil {
+		return models.DelegatedKey{}, false, nil
+	}
+	delegatedKey, err := models.GetDelegatedKeyByID(keyID)
+	if gorm.IsRecordNotFoundError(err) {
+		return delegatedKey, false, nil
+	}
+	if err != nil {
+		return delegatedKey, false, InternalErrorResponse(c, err)
+	}
+
+	if delegatedKey.IsExpired() {
+		return delegatedKey, false, ForbiddenResponse(c, errors.New("delegated key expired"))
+	}
+	// An account may have been created with the key since it was registered, it only acts as that account then
+	if existingAccount, err := models.GetAccountById(keyID); err == nil && existingAccount.AccountID != "" {
+		return delegatedKey, false, ForbiddenResponse(c, errors.New("the delegated key is the key of an account"))
+	}
+	scope, ok := delegatedKeyScopesByPath[c.FullPath()]
+	if !ok || !delegatedKey.HasScope(scope) {
+		return delegatedKey, false, ForbiddenResponse(c, errors.New("delegated key is not allowed to do this"))
+	}
+
+	c.Set(delegatedKeyContextKey, delegatedKey)
+	return delegatedKey, true, nil
+}
+
+/*
+getResolvedDelegatedKey returns the delegated key resolveDelegatedKey already accepted for publicKey
+while handling this request
+*/
+func getResolvedDelegatedKey(publicKey string, c *gin.Context) (models.DelegatedKey, bool) {
+	value, ok := c.Get(delegatedKeyContextKey)
+	if !ok {
+		return models.DelegatedKey{}, false
+	}
+	delegatedKey, ok := value.(models.DelegatedKey)
+	return delegatedKey, ok && strings.EqualFold(delegatedKey.PublicKey, publicKey)
+}
+
+/*
+getOwnerPublicKey returns the key that files and metadata created by this request must be bound to:
+the account's own key when the request comes from one of its delegated keys
+*/
+func getOwnerPublicKey(publicKey string, c *gin.Context) string {
+	if delegatedKey, ok := getResolvedDelegatedKey(publicKey, c); ok {
+		return delegatedKey.AccountPublicKey
+	}
+	return publicKey
+}
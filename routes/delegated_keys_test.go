@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Delegated_Keys(t *testing.T) {
+	setupTests(t)
+}
+
+func addDelegatedKeyForTest(t *testing.T, accountPrivateKey *ecdsa.PrivateKey, scopes []string, expiresAt time.Time) (*ecdsa.PrivateKey, models.DelegatedKey) {
+	delegatedPrivateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	addObj := delegatedKeyAddObj{
+		DelegatedPublicKey: utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey),
+		Scopes:             scopes,
+		ExpiresAt:          expiresAt.Unix(),
+		Timestamp:          time.Now().Unix(),
+	}
+	v, b := returnValidVerificationAndRequestBody(t, addObj, accountPrivateKey)
+	post := delegatedKeyAddReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	w := httpPostRequestHelperForTest(t, "/"+DelegatedKeysV2PathPrefix+DelegatedKeyAddPath, "v2", post)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	delegatedKey := models.DelegatedKey{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &delegatedKey))
+	return delegatedPrivateKey, delegatedKey
+}
+
+func getMetadataWithKeyForTest(t *testing.T, privateKey *ecdsa.PrivateKey) (int, string) {
+	testMetadataKey := utils.GenerateFileHandle()
+	testMetadataValue := utils.GenerateFileHandle()
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{testMetadataKey: testMetadataValue}, utils.TestValueTimeToLive))
+
+	v, b := returnValidVerificationAndRequestBody(t, metadataKeyObject{
+		MetadataKey: testMetadataKey,
+		Timestamp:   time.Now().Unix(),
+	}, privateKey)
+	get := metadataKeyReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	w := httpPostRequestHelperForTest(t, MetadataGetPath, "v1", get)
+	return w.Code, testMetadataValue
+}
+
+func Test_DelegatedKey_Can_Act_Within_Its_Scopes(t *testing.T) {
+	accountID, accountPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	delegatedPrivateKey, delegatedKey := addDelegatedKeyForTest(t, accountPrivateKey,
+		[]string{models.DelegatedScopeMetadataRead}, time.Now().Add(time.Hour))
+	assert.Equal(t, accountID, delegatedKey.AccountID)
+	assert.Equal(t, models.DelegatedScopeMetadataRead, delegatedKey.Scopes)
+
+	code, _ := getMetadataWithKeyForTest(t, delegatedPrivateKey)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func Test_DelegatedKey_Rejected_Outside_Its_Scopes(t *testing.T) {
+	accountID, accountPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	delegatedPrivateKey, _ := addDelegatedKeyForTest(t, accountPrivateKey,
+		[]string{models.DelegatedScopeUpload}, time.Now().Add(time.Hour))
+
+	code, _ := getMetadataWithKeyForTest(t, delegatedPrivateKey)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Delegated keys can't manage delegated keys
+	v, b := returnValidVerificationAndRequestBody(t, accountGetReqObj{Timestamp: time.Now().Unix()}, delegatedPrivateKey)
+	post := delegatedKeysListReq{
+		verification: v,
+		requestBody:  b,
+	}
+	w := httpPostRequestHelperForTest(t, "/"+DelegatedKeysV2PathPrefix+DelegatedKeysListPath, "v2", post)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_DelegatedKey_Rejected_Once_Expired(t *testing.T) {
+	accountID, accountPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	delegatedPrivateKey, delegatedKey := addDelegatedKeyForTest(t, accountPrivateKey,
+		[]string{models.DelegatedScopeMetadataRead}, time.Now().Add(time.Hour))
+	assert.Nil(t, models.DB.Model(&delegatedKey).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	code, _ := getMetadataWithKeyForTest(t, delegatedPrivateKey)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func Test_DelegatedKey_Rejected_Once_It_Has_An_Account(t *testing.T) {
+	accountID, accountPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	delegatedPrivateKey, delegatedKey := addDelegatedKeyForTest(t, accountPrivateKey,
+		[]string{models.DelegatedScopeMetadataRead}, time.Now().Add(time.Hour))
+	CreatePaidAccountForTest(t, delegatedKey.KeyID)
+
+	// Metadata only the account delegating the key can read
+	testMetadataKey := utils.GenerateFileHandle()
+	permissionHash, err := utils.HashString(utils.PubkeyCompressedToHex(accountPrivateKey.PublicKey) + testMetadataKey)
+	assert.Nil(t, err)
+	assert.Nil(t, utils.BatchSet(&utils.KVPairs{
+		testMetadataKey: utils.GenerateFileHandle(),
+		getPermissionHashKeyForBadger(testMetadataKey): permissionHash,
+	}, utils.TestValueTimeToLive))
+
+	v, b := returnValidVerificationAndRequestBody(t, metadataKeyObject{
+		MetadataKey: testMetadataKey,
+		Timestamp:   time.Now().Unix(),
+	}, delegatedPrivateKey)
+	w := httpPostRequestHelperForTest(t, MetadataGetPath, "v1", metadataKeyReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "the delegated key is the key of an account")
+}
+
+func Test_DelegatedKey_List_And_Remove(t *testing.T) {
+	accountID, accountPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	delegatedPrivateKey, _ := addDelegatedKeyForTest(t, accountPrivateKey,
+		[]string{models.DelegatedScopeMetadataRead, models.DelegatedScopePublicShare}, time.Now().Add(time.Hour))
+
+	v, b := returnValidVerificationAndRequestBody(t, accountGetReqObj{Timestamp: time.Now().Unix()}, accountPrivateKey)
+	list := delegatedKeysListReq{
+		verification: v,
+		requestBody:  b,
+	}
+	w := httpPostRequestHelperForTest(t, "/"+DelegatedKeysV2PathPrefix+DelegatedKeysListPath, "v2", list)
+	assert.Equal(t, http.StatusOK, w.Code)
+	res := delegatedKeysListRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Len(t, res.DelegatedKeys, 1)
+
+	v, b = returnValidVerificationAndRequestBody(t, delegatedKeyRemoveObj{
+		DelegatedPublicKey: utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey),
+		Timestamp:          time.Now().Unix(),
+	}, accountPrivateKey)
+	remove := delegatedKeyRemoveReq{
+		verification: v,
+		requestBody:  b,
+	}
+	w = httpPostRequestHelperForTest(t, "/"+DelegatedKeysV2PathPrefix+DelegatedKeyRemovePath, "v2", remove)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	code, _ := getMetadataWithKeyForTest(t, delegatedPrivateKey)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func Test_DelegatedKey_Rejects_Unknown_Scope(t *testing.T) {
+	accountID, accountPrivateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	delegatedPrivateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	v, b := returnValidVerificationAndRequestBody(t, delegatedKeyAddObj{
+		DelegatedPublicKey: utils.PubkeyCompressedToHex(delegatedPrivateKey.PublicKey),
+		Scopes:             []string{"metadata:write"},
+		ExpiresAt:          time.Now().Add(time.Hour).Unix(),
+		Timestamp:          time.Now().Unix(),
+	}, accountPrivateKey)
+	post := delegatedKeyAddReq{
+		verification: v,
+		requestBody:  b,
+	}
+	w := httpPostRequestHelperForTest(t, "/"+DelegatedKeysV2PathPrefix+DelegatedKeyAddPath, "v2", post)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
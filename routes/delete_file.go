@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/utils"
@@ -25,7 +27,7 @@ func (v *deleteFileReq) getObjectRef() interface{} {
 
 // DeleteFileHandler godoc
 // @Summary delete a file
-// @Description delete a file
+// @Description move a file to the trash bin, it can be restored until it is purged after the trash retention
 // @Accept  json
 // @Produce  json
 // @Param deleteFileReq body routes.deleteFileReq true "file deletion object"
@@ -79,25 +81,27 @@ func DeleteFileByID(fileID, publicKey string, account models.Account, c *gin.Con
 		return err
 	}
 
-	err = account.UseStorageSpaceInByte(int64(-1) * completedFile.FileSizeInByte)
-	if err != nil && err.Error() == models.StorageUsedTooLow {
-		err = models.DB.Model(&account).Update("storage_used_in_byte", int64(0)).Error
+	if completedFile.TrashedAt != nil {
+		return errors.New("file is already in the trash bin")
 	}
-	if err != nil && err.Error() != models.StorageUsedTooLow {
-		return err
+
+	if utils.Env.TrashRetentionDays == 0 {
+		return models.PurgeCompletedFile(completedFile, account.AccountID)
 	}
 
-	if err := utils.DeleteDefaultBucketObjectKeys(fileID); err != nil {
+	// The objects downloads made public would still be readable by anyone while the file is in the trash bin
+	if err := models.MakeCompletedFileObjectsPrivate(fileID); err != nil {
 		return err
 	}
-
-	if err := models.DB.Delete(&completedFile).Error; err != nil {
+	if err := completedFile.Trash(account.AccountID); err != nil {
 		return err
 	}
 
+	// Public shares are revoked right away, restoring the file doesn't bring them back
 	if err := models.RemovePublicSharesById(fileID); err != nil {
 		return err
 	}
-
+	utils.DeleteDefaultBucketObject(models.GetFileDataPublicKey(fileID))
+	utils.DeleteDefaultBucketObject(models.GetPublicThumbnailKey(fileID))
 	return nil
 }
@@ -20,6 +20,8 @@ func Test_Init_Delete_Files(t *testing.T) {
 
 func Test_Successful_File_Deletion_Request(t *testing.T) {
 	cleanUpBeforeTest(t)
+	// With the trash bin off, files are deleted right away
+	defer setTrashRetentionDaysForTest(0)()
 	account, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
 	fileID := fileIDs[0]
 	checkPrerequisites(t, account, fileID)
@@ -52,6 +54,8 @@ func Test_Successful_File_Deletion_Request(t *testing.T) {
 
 func Test_Successful_Multiple_File_Deletion_Request(t *testing.T) {
 	cleanUpBeforeTest(t)
+	// With the trash bin off, files are deleted right away
+	defer setTrashRetentionDaysForTest(0)()
 	account, fileIDs, privateKey := createAccountAndUploadFile(t, 3)
 
 	for _, fileID := range fileIDs {
@@ -95,6 +99,15 @@ func Test_Successful_Multiple_File_Deletion_Request(t *testing.T) {
 	}
 }
 
+/*setTrashRetentionDaysForTest sets the trash retention and returns what puts it back*/
+func setTrashRetentionDaysForTest(days int) func() {
+	previous := utils.Env.TrashRetentionDays
+	utils.Env.TrashRetentionDays = days
+	return func() {
+		utils.Env.TrashRetentionDays = previous
+	}
+}
+
 func checkPrerequisites(t *testing.T, account models.Account, fileID string) {
 	// check that StorageUsedInByte has increased after the upload
 	assert.True(t, account.StorageUsedInByte > defaultStorageUsedInByteForTest)
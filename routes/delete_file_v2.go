@@ -25,7 +25,7 @@ func (v *deleteFilesReq) getObjectRef() interface{} {
 
 // DeleteFilesHandler godoc
 // @Summary deletes files
-// @Description deletes files
+// @Description move files to the trash bin, they can be restored until they are purged after the trash retention
 // @Accept json
 // @Produce json
 // @Param deleteFilesReq body routes.deleteFilesReq true "file(s) deletion object"
@@ -3,6 +3,7 @@ package routes
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
@@ -15,17 +16,41 @@ type DownloadFileObj struct {
 
 type downloadFileRes struct {
 	// Url should point to S3, thus client does not need to download it from this node.
+	// The data and the metadata of the file are at this url followed by /file and /metadata.
 	FileDownloadUrl string `json:"fileDownloadUrl" example:"a URL to use to download the file"`
+	// Digests to verify the downloaded file with, when the uploader gave them or the parts went through the node
+	FileSha256 string `json:"fileSha256,omitempty" example:"the hex SHA-256 of the whole file"`
+	// Whether the node checked the data has FileSha256, a file that doesn't is deleted
+	FileSha256Verified bool                `json:"fileSha256Verified,omitempty" example:"true"`
+	PartDigests        []models.PartDigest `json:"partDigests,omitempty"`
+}
+
+type downloadFileV3Res struct {
+	// The urls are presigned urls of the objects in S3, thus client does not need to download them from this node.
+	// They stop working at UrlsExpireAt, the objects themselves stay private.
+	FileDataUrl     string `json:"fileDataUrl" example:"a URL to use to download the data of the file"`
+	FileMetadataUrl string `json:"fileMetadataUrl" example:"a URL to use to download the metadata of the file"`
+	UrlsExpireAt    int64  `json:"urlsExpireAt" example:"1557346389"`
+	// Digests to verify the downloaded file with, when the uploader gave them or the parts went through the node
+	FileSha256 string `json:"fileSha256,omitempty" example:"the hex SHA-256 of the whole file"`
+	// Whether the node checked the data has FileSha256, a file that doesn't is deleted
+	FileSha256Verified bool                `json:"fileSha256Verified,omitempty" example:"true"`
+	PartDigests        []models.PartDigest `json:"partDigests,omitempty"`
 }
 
 // DownloadFileHandler godoc
 // @Summary download a file without cryptographic verification
-// @Description download a file without cryptographic verification
+// @Description download a file without cryptographic verification.
+// @Description The data and the metadata of the file are made public, at fileDownloadUrl followed by /file and /metadata.
+// @Description The SHA-256 of the file and of each of its parts are returned when the node knows them.
+// @Description The egress quota of the owner of the file is only checked when the URL is returned.
+// @Description Deprecated, /api/v3/download/private keeps the objects private.
 // @Param routes.DownloadFileObj body routes.DownloadFileObj true "download object for non-signed requests"
 // @Accept json
 // @Produce json
 // @Success 200 {object} routes.downloadFileRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "the account owning the file used its downloads for the month"
 // @Failure 404 {string} string "such data does not exist"
 // @Failure 500 {string} string "some information about the internal error"
 // @Router /api/v2/download/private [post]
@@ -35,27 +60,101 @@ func DownloadFileHandler() gin.HandlerFunc {
 	return ginHandlerFunc(downloadFile)
 }
 
+// DownloadFileV3Handler godoc
+// @Summary download a file without cryptographic verification
+// @Description download a file without cryptographic verification.
+// @Description Returns presigned URLs of the data and of the metadata of the file that can be used until urlsExpireAt.
+// @Description The egress quota of the owner of the file is checked when the URLs are returned, what is downloaded
+// @Description with them counts towards it once the node read the access logs of the bucket.
+// @Description The SHA-256 of the file and of each of its parts are returned when the node knows them.
+// @Param routes.DownloadFileObj body routes.DownloadFileObj true "download object for non-signed requests"
+// @Accept json
+// @Produce json
+// @Success 200 {object} routes.downloadFileV3Res
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "the account owning the file used its downloads for the month"
+// @Failure 404 {string} string "such data does not exist"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v3/download/private [post]
+/*DownloadFileV3Handler returns presigned URLs of the objects of the file*/
+func DownloadFileV3Handler() gin.HandlerFunc {
+	return ginHandlerFunc(downloadFileV3)
+}
+
 func downloadFile(c *gin.Context) error {
+	request, completedFile, err := parseDownloadFileRequest(c)
+	if err != nil {
+		return err
+	}
+
+	res := downloadFileRes{}
+	if res.FileDownloadUrl, err = GetBaseFileDownloadURL(request.FileID); err != nil {
+		return downloadFileErrorResponse(c, err)
+	}
+	if res.FileSha256, res.FileSha256Verified, res.PartDigests, err = getFileDigests(completedFile); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, res)
+}
+
+func downloadFileV3(c *gin.Context) error {
+	request, completedFile, err := parseDownloadFileRequest(c)
+	if err != nil {
+		return err
+	}
+
+	res := downloadFileV3Res{}
+	if res.FileDataUrl, res.FileMetadataUrl, res.UrlsExpireAt, err = GetFileDownloadURLs(request.FileID); err != nil {
+		return downloadFileErrorResponse(c, err)
+	}
+	if res.FileSha256, res.FileSha256Verified, res.PartDigests, err = getFileDigests(completedFile); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, res)
+}
+
+/*parseDownloadFileRequest parses a download request and checks the file can be downloaded. The completed file
+is nil for files that don't have one.*/
+func parseDownloadFileRequest(c *gin.Context) (DownloadFileObj, *models.CompletedFile, error) {
 	request := DownloadFileObj{}
 
 	if err := utils.ParseRequestBody(c.Request, &request); err != nil {
 		err = fmt.Errorf("bad request, unable to parse request body:  %v", err)
-		return BadRequestResponse(c, err)
+		return request, nil, BadRequestResponse(c, err)
 	}
 
-	fileURL, err := GetBaseFileDownloadURL(request.FileID)
-	if err != nil {
-		if err.Error() == "such data does not exist" {
-			return NotFoundResponse(c, err)
-		}
-		return InternalErrorResponse(c, err)
+	completedFile, completedErr := models.GetCompletedFileByFileID(request.FileID)
+	if completedErr == nil && completedFile.TrashedAt != nil {
+		return request, nil, NotFoundResponse(c, errors.New("such data does not exist"))
+	}
+	if err := verifyFileOwnerEgressQuota(request.FileID, c); err != nil {
+		return request, nil, err
 	}
 
-	return OkResponse(c, downloadFileRes{
-		FileDownloadUrl: fileURL,
-	})
+	if completedErr != nil {
+		return request, nil, nil
+	}
+	return request, &completedFile, nil
+}
+
+func downloadFileErrorResponse(c *gin.Context, err error) error {
+	if err.Error() == "such data does not exist" {
+		return NotFoundResponse(c, err)
+	}
+	return InternalErrorResponse(c, err)
+}
+
+func getFileDigests(completedFile *models.CompletedFile) (string, bool, []models.PartDigest, error) {
+	if completedFile == nil {
+		return "", false, nil, nil
+	}
+	partDigests, err := completedFile.GetPartDigests()
+	return completedFile.Sha256, completedFile.Sha256Verified, partDigests, err
 }
 
+/*GetBaseFileDownloadURL makes the objects of the file public and returns the URL they are under*/
 func GetBaseFileDownloadURL(fileID string) (string, error) {
 	fileDataKey := models.GetFileDataKey(fileID)
 	if !utils.DoesDefaultBucketObjectExist(fileDataKey) {
@@ -72,3 +171,25 @@ func GetBaseFileDownloadURL(fileID string) (string, error) {
 
 	return models.GetBucketUrl() + fileID, nil
 }
+
+/*GetFileDownloadURLs returns presigned URLs of the data and of the metadata of the file, and when they expire*/
+func GetFileDownloadURLs(fileID string) (string, string, int64, error) {
+	fileDataKey := models.GetFileDataKey(fileID)
+	if !utils.DoesDefaultBucketObjectExist(fileDataKey) {
+		return "", "", 0, errors.New("such data does not exist")
+	}
+
+	ttl := time.Duration(utils.Env.DownloadURLTTLMinutes) * time.Minute
+	expiresAt := time.Now().Add(ttl).Unix()
+
+	fileURL, err := utils.PresignDefaultBucketObjectURL(fileDataKey, ttl)
+	if err != nil {
+		return "", "", 0, err
+	}
+	metadataURL, err := utils.PresignDefaultBucketObjectURL(models.GetFileMetadataKey(fileID), ttl)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	return fileURL, metadataURL, expiresAt, nil
+}
@@ -6,10 +6,13 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/utils"
 )
 
+const defaultThumbnailURL = "https://s3.us-east-2.amazonaws.com/opacity-public/thumbnail_default.png"
+
 type downloadPublicFileRes struct {
 	FileDownloadUrl          string `json:"fileDownloadUrl" example:"a URL to use to download the public file"`
 	FileDownloadThumbnailUrl string `json:"fileDownloadThumbnailUrl" example:"a URL to use to download the public file thumbnail"`
@@ -17,13 +20,20 @@ type downloadPublicFileRes struct {
 
 // DownloadPublicFileHandler godoc
 // @Summary returns the URLs for a public file and it's thumbnail
-// @Description returns the URLs for a public file and it's thumbnail, if no thumbnail is present, return a default one
+// @Description returns the URLs for a public file and it's thumbnail, if no thumbnail is present, return a default one.
+// @Description When every public share of the file is password protected, the X-Share-Access-Token header must hold
+// @Description the access token of one of them. When a public share of the file expires or has a maximum number of views,
+// @Description the file is opened through the share without password that limits it the least, or the one the access token
+// @Description unlocked: a view of that share is counted and the URLs are presigned ones that stop working when it expires.
+// @Param X-Share-Access-Token header string false "access token of a password protected public share of the file"
 // @Param routes.DownloadFileObj body routes.DownloadFileObj true "download object for non-signed requests"
 // @Accept json
 // @Produce json
 // @Success 200 {object} routes.downloadPublicFileRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "the public share is password protected, or the account owning the file used its downloads for the month"
 // @Failure 404 {string} string "such data does not exist"
+// @Failure 410 {string} string "every public share of the file expired or reached its maximum number of views"
 // @Failure 500 {string} string "some information about the internal error"
 // @Router /api/v2/download/public [post]
 /*DownloadPublicFileHandler returns the URLs for a public file and it's thumbnail, if no thumbnail is present, return a default one*/
@@ -42,9 +52,56 @@ func downloadPublicFile(c *gin.Context) error {
 	if !utils.DoesDefaultBucketObjectExist(models.GetFileDataPublicKey(request.FileID)) {
 		return NotFoundResponse(c, errors.New("such data does not exist"))
 	}
+	exhausted, err := models.IsPublicFileExhausted(request.FileID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if exhausted {
+		return GoneResponse(c, models.ErrPublicShareExhausted)
+	}
+
+	protected, publicShare, err := verifyPublicFileAccess(request.FileID, c)
+	if err != nil {
+		return err
+	}
+	if err := verifyFileOwnerEgressQuota(request.FileID, c); err != nil {
+		return err
+	}
 
-	fileURL, thumbnailURL := models.GetPublicFileDownloadData(request.FileID)
-	thumbnailURL = checkFileThumbnail(thumbnailURL)
+	private, err := models.IsPublicFilePrivate(request.FileID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if private {
+		// The file is opened through one of its shares, whose limits apply as if its shortlink was
+		if !protected {
+			publicShare, err = models.GetLeastLimitedOpenPublicShare(request.FileID)
+			if gorm.IsRecordNotFoundError(err) {
+				return GoneResponse(c, models.ErrPublicShareExhausted)
+			}
+			if err != nil {
+				return InternalErrorResponse(c, err)
+			}
+		}
+		if publicShare.IsExhausted() {
+			return GoneResponse(c, models.ErrPublicShareExhausted)
+		}
+		if publicShare.MaxViews > 0 {
+			if err := publicShare.UpdateViewsCount(); err == models.ErrPublicShareExhausted {
+				return GoneResponse(c, err)
+			} else if err != nil {
+				return InternalErrorResponse(c, err)
+			}
+		}
+	}
+
+	fileURL, thumbnailURL, err := getPublicFileDownloadURLs(request.FileID, private, publicShare.ExpiresAt)
+	if err == models.ErrPublicShareExhausted {
+		return GoneResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	return OkResponse(c, downloadPublicFileRes{
 		FileDownloadUrl:          fileURL,
@@ -59,5 +116,5 @@ func checkFileThumbnail(thumbnailURL string) string {
 		return thumbnailURL
 	}
 
-	return "https://s3.us-east-2.amazonaws.com/opacity-public/thumbnail_default.png"
+	return defaultThumbnailURL
 }
@@ -0,0 +1,212 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+// How much of an object is read from the bucket before it is written to the client
+const downloadStreamBufferSize = 256 * 1024
+
+type downloadStreamObj struct {
+	FileID string `json:"fileID" validate:"required,len=64" minLength:"64" maxLength:"64" example:"the handle of the file"`
+	/*Metadata streams the metadata object of the file instead of its data*/
+	Metadata  bool  `json:"metadata" example:"false"`
+	Timestamp int64 `json:"timestamp" validate:"required"`
+}
+
+type downloadStreamReq struct {
+	verification
+	requestBody
+	downloadStreamObj downloadStreamObj
+}
+
+func (v *downloadStreamReq) getObjectRef() interface{} {
+	return &v.downloadStreamObj
+}
+
+// DownloadStreamHandler godoc
+// @Summary download a file through the node
+// @Description stream the data, or the metadata, of a file of the account through the node, for clients that
+// @Description can't reach the bucket. Supports a single HTTP byte range and If-None-Match. The bytes sent count
+// @Description towards the egress usage of the account.
+// @Accept  json
+// @Produce octet-stream
+// @Param downloadStreamReq body routes.downloadStreamReq true "download stream object"
+// @Param Range header string false "byte range, e.g. bytes=0-99"
+// @Param If-None-Match header string false "the ETag of a copy the client already has"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"fileID": "the handle of the file",
+// @description 	"metadata": false,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {string} string "the object data"
+// @Success 206 {string} string "the requested range of the object data"
+// @Success 304 {string} string "the object still has the ETag given in If-None-Match"
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match, or the account used its downloads for the month"
+// @Failure 404 {string} string "such data does not exist"
+// @Failure 416 {string} string "the requested range is not satisfiable"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/download/stream [post]
+/*DownloadStreamHandler streams the objects of a file from the bucket to the client*/
+func DownloadStreamHandler() gin.HandlerFunc {
+	return ginHandlerFunc(downloadStream)
+}
+
+func downloadStream(c *gin.Context) error {
+	request := downloadStreamReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	fileID := request.downloadStreamObj.FileID
+	completedFile, err := models.GetCompletedFileByFileID(fileID)
+	if err != nil || completedFile.TrashedAt != nil {
+		return NotFoundResponse(c, errors.New("such data does not exist"))
+	}
+	if err := verifyPermissions(request.PublicKey, fileID, completedFile.ModifierHash, c); err != nil {
+		return err
+	}
+	if err := verifyEgressQuota(account, c); err != nil {
+		return err
+	}
+
+	key := models.GetFileDataKey(fileID)
+	if request.downloadStreamObj.Metadata {
+		key = models.GetFileMetadataKey(fileID)
+	}
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		head, err := utils.HeadBucketObject(key)
+		if err != nil {
+			return downloadStreamErrorResponse(c, err)
+		}
+		if head != nil && ifNoneMatchMatches(ifNoneMatch, aws.StringValue(head.ETag)) {
+			c.Header("ETag", aws.StringValue(head.ETag))
+			c.Status(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	output, err := utils.GetBucketObject(key, c.GetHeader("Range"), false)
+	if err != nil {
+		return downloadStreamErrorResponse(c, err)
+	}
+	defer output.Body.Close()
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Type", aws.StringValue(output.ContentType))
+	c.Header("Content-Length", strconv.FormatInt(aws.Int64Value(output.ContentLength), 10))
+	c.Header("ETag", aws.StringValue(output.ETag))
+
+	status := http.StatusOK
+	if output.ContentRange != nil {
+		c.Header("Content-Range", aws.StringValue(output.ContentRange))
+		status = http.StatusPartialContent
+	}
+	c.Status(status)
+
+	// The client reads at its own pace: each write blocks until it took the previous ones, so at most
+	// one buffer of the object is held in memory
+	written, err := io.CopyBuffer(flushWriter{c.Writer}, output.Body, make([]byte, downloadStreamBufferSize))
+
+	utils.Metrics_Download_Proxy_Bytes_Counter.Add(float64(written))
+	utils.LogIfError(models.AddProxiedEgress(account.AccountID, written), map[string]interface{}{"accountID": account.AccountID})
+
+	// The status and headers are already sent, the client most likely went away and there is no response left to give
+	if err != nil {
+		getLogger(c).Warnf("download stream of %s stopped after %d bytes: %v", key, written, err)
+	}
+	return nil
+}
+
+/*ifNoneMatchMatches tells if an If-None-Match header matches the ETag of an object, as in RFC 7232 3.2: the header is
+either "*" or a comma-separated list of entity tags, which are compared weakly*/
+func ifNoneMatchMatches(header string, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return true
+	}
+	for header != "" {
+		// Empty elements of the list are allowed
+		if header[0] == ',' {
+			header = strings.TrimLeft(header[1:], " \t")
+			continue
+		}
+		var tag string
+		tag, header = scanETag(header)
+		if tag == "" {
+			return false
+		}
+		if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+		header = strings.TrimLeft(header, " \t")
+		if header != "" && header[0] != ',' {
+			return false
+		}
+	}
+	return false
+}
+
+/*scanETag returns the entity tag at the start of s, W/ included, and the rest of s. The tag is empty if s doesn't
+start with a valid one*/
+func scanETag(s string) (string, string) {
+	start := 0
+	if strings.HasPrefix(s, "W/") {
+		start = 2
+	}
+	if len(s[start:]) < 2 || s[start] != '"' {
+		return "", ""
+	}
+	for i := start + 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return s[:i+1], s[i+1:]
+		case c == 0x21 || (c >= 0x23 && c <= 0x7E) || c >= 0x80:
+		default:
+			return "", ""
+		}
+	}
+	return "", ""
+}
+
+/*flushWriter sends what is written to the client right away instead of buffering it*/
+type flushWriter struct {
+	writer gin.ResponseWriter
+}
+
+func (w flushWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.writer.Flush()
+	return n, err
+}
+
+func downloadStreamErrorResponse(c *gin.Context, err error) error {
+	if aerr, ok := err.(awserr.RequestFailure); ok {
+		switch aerr.StatusCode() {
+		case http.StatusNotFound:
+			return NotFoundResponse(c, errors.New("such data does not exist"))
+		case http.StatusRequestedRangeNotSatisfiable:
+			c.AbortWithStatusJSON(http.StatusRequestedRangeNotSatisfiable, err.Error())
+			return err
+		}
+	}
+	return InternalErrorResponse(c, err)
+}
@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Download_Stream(t *testing.T) {
+	setupTests(t)
+}
+
+func streamDownloadForTest(t *testing.T, fileID string, metadata bool, headers map[string]string,
+	privateKey *ecdsa.PrivateKey) *httptest.ResponseRecorder {
+	v, b := returnValidVerificationAndRequestBody(t, downloadStreamObj{
+		FileID:    fileID,
+		Metadata:  metadata,
+		Timestamp: time.Now().Unix(),
+	}, privateKey)
+	return httpPostRequestWithHeadersHelperForTest(t, DownloadStreamV2Path, "v2",
+		downloadStreamReq{verification: v, requestBody: b}, headers)
+}
+
+func Test_Download_Stream(t *testing.T) {
+	cleanUpBeforeTest(t)
+	models.DeleteEgressUsagesForTest(t)
+	account, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	fileID := fileIDs[0]
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataKey(fileID), "hello world!", ""))
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileMetadataKey(fileID), "metadata", ""))
+
+	w := streamDownloadForTest(t, fileID, false, nil, privateKey)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello world!", w.Body.String())
+	assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	assert.Equal(t, "12", w.Header().Get("Content-Length"))
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = streamDownloadForTest(t, fileID, false, map[string]string{"Range": "bytes=6-10"}, privateKey)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "world", w.Body.String())
+	assert.Equal(t, "bytes 6-10/12", w.Header().Get("Content-Range"))
+
+	w = streamDownloadForTest(t, fileID, false, map[string]string{"Range": "bytes=100-200"}, privateKey)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+
+	w = streamDownloadForTest(t, fileID, false, map[string]string{"If-None-Match": etag}, privateKey)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = streamDownloadForTest(t, fileID, false, map[string]string{"If-None-Match": `"other", W/` + etag}, privateKey)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = streamDownloadForTest(t, fileID, false, map[string]string{"If-None-Match": `"other"`}, privateKey)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello world!", w.Body.String())
+
+	w = streamDownloadForTest(t, fileID, true, nil, privateKey)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "metadata", w.Body.String())
+
+	egressUsage, err := models.GetEgressUsage(account.AccountID, models.EgressMonth(time.Now()))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12+5+12+8), egressUsage.ProxiedBytes)
+}
+
+func Test_If_None_Match_Matches(t *testing.T) {
+	etag := `"abc"`
+	assert.True(t, ifNoneMatchMatches(`"abc"`, etag))
+	assert.True(t, ifNoneMatchMatches(`*`, etag))
+	assert.True(t, ifNoneMatchMatches(`W/"abc"`, etag))
+	assert.True(t, ifNoneMatchMatches(`"x", "abc"`, etag))
+	assert.True(t, ifNoneMatchMatches(`"x",W/"abc"`, etag))
+	assert.True(t, ifNoneMatchMatches(`"a,b", "abc"`, etag))
+	assert.True(t, ifNoneMatchMatches(`"abc"`, `W/"abc"`))
+	assert.False(t, ifNoneMatchMatches(`"x", "y"`, etag))
+	assert.False(t, ifNoneMatchMatches(`abc`, etag))
+	assert.False(t, ifNoneMatchMatches(`"ab`, etag))
+	assert.False(t, ifNoneMatchMatches(`"x" "abc"`, etag))
+}
+
+func Test_Download_Stream_Of_Another_Account_Is_Forbidden(t *testing.T) {
+	cleanUpBeforeTest(t)
+	_, fileIDs, _ := createAccountAndUploadFile(t, 1)
+	_, _, otherPrivateKey := createAccountAndUploadFile(t, 1)
+
+	w := streamDownloadForTest(t, fileIDs[0], false, nil, otherPrivateKey)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Download_Stream_Missing_File(t *testing.T) {
+	cleanUpBeforeTest(t)
+	_, _, privateKey := createAccountAndUploadFile(t, 1)
+
+	w := streamDownloadForTest(t, utils.GenerateFileHandle(), false, nil, privateKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
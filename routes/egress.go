@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+/*verifyEgressQuota rejects the request when the account downloaded as much as its plan allows this month*/
+func verifyEgressQuota(account models.Account, c *gin.Context) error {
+	exceeded, err := models.IsEgressQuotaExceeded(account)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if exceeded {
+		return ForbiddenResponse(c, fmt.Errorf("the account used its %d GB of downloads for %s, they resume next month",
+			account.MaxEgressInBytes()/1e9, models.EgressMonth(time.Now())))
+	}
+	return nil
+}
+
+/*
+verifyFileOwnerEgressQuota checks the egress quota of the account owning the file before a URL of the bucket is
+handed out, files without a known owner are not limited. The bucket serves the URL on its own afterwards, until it
+expires or for as long as the object is public, and its downloads only count once the egress log ingester read them
+from the access logs, which it doesn't when S3_ACCESS_LOG_PATH is unset. The quota of direct downloads is a soft one.
+*/
+func verifyFileOwnerEgressQuota(fileID string, c *gin.Context) error {
+	accountID, err := models.GetFileOwnerAccountID(fileID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if accountID == "" {
+		return nil
+	}
+	account, err := models.GetAccountById(accountID)
+	if err != nil {
+		// The account is gone, its files are about to be
+		return nil
+	}
+	return verifyEgressQuota(account, c)
+}
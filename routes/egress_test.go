@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Egress(t *testing.T) {
+	setupTests(t)
+}
+
+func Test_Downloads_Rejected_Over_Egress_Quota(t *testing.T) {
+	cleanUpBeforeTest(t)
+	models.DeleteEgressUsagesForTest(t)
+	account, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	fileID := fileIDs[0]
+
+	w := httpPostRequestHelperForTest(t, DownloadPath, "v1", DownloadFileObj{FileID: fileID})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.True(t, account.MaxEgressInBytes() > 0)
+	assert.Nil(t, models.AddProxiedEgress(account.AccountID, account.MaxEgressInBytes()))
+
+	w = httpPostRequestHelperForTest(t, DownloadPath, "v1", DownloadFileObj{FileID: fileID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "downloads for "+models.EgressMonth(time.Now()))
+
+	w = streamDownloadForTest(t, fileID, false, nil, privateKey)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Account_Data_Shows_Egress_Usage(t *testing.T) {
+	cleanUpBeforeTest(t)
+	models.DeleteEgressUsagesForTest(t)
+	account, privateKey := returnValidAccountAndPrivateKey(t)
+	assert.Nil(t, models.DB.Create(&account).Error)
+	assert.Nil(t, models.AddProxiedEgress(account.AccountID, 1500000000))
+
+	models.BackendManager.CheckIfPaid = func(address common.Address, amount *big.Int) (bool, uint, error) {
+		return true, utils.TestNetworkID, nil
+	}
+
+	validReq := returnValidGetAccountReq(t, accountGetReqObj{Timestamp: time.Now().Unix()}, privateKey)
+	w := httpPostRequestHelperForTest(t, AccountDataPath, "v1", validReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"egressUsedThisMonth":1.5`)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"maxEgressGBPerMonth":%d`,
+		utils.Env.Plans[int(account.StorageLimit)].MaxEgressGBPerMonth))
+}
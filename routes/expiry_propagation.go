@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"encoding/base64"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type expiryPropagationRes struct {
+	Completed        bool `json:"completed" example:"false"`
+	FilesUpdated     int  `json:"filesUpdated" example:"120"`
+	MetadatasUpdated int  `json:"metadatasUpdated" example:"8"`
+}
+
+/*propagateAccountExpiration starts extending the expiration of everything the account owns to its new
+expiration date, which the expiryPropagator job carries out in the background. The file handles and
+metadata keys the client still sends are updated right away and added to the owner indexes, so the ones
+from before the indexes existed get picked up by the next propagations. Once they all are, the account
+is marked as indexed and its counters can be recomputed from the indexes.*/
+func propagateAccountExpiration(account models.Account, fileHandles, metadataKeys []string, key string,
+	metadataApiVersion int, c *gin.Context) error {
+	filesExpiredAt := account.ExpirationDate()
+	// Setting ttls on metadata to 2 months post account expiration date so the metadatas won't
+	// be deleted too soon
+	metadatasExpiredAt := filesExpiredAt.Add(MetadataExpirationOffset)
+
+	propagationErr := models.StartExpiryPropagation(account.AccountID, filesExpiredAt, metadatasExpiredAt)
+
+	var filesErr error
+	if len(fileHandles) > 0 {
+		filesErr = models.UpdateExpiredAt(fileHandles, key, filesExpiredAt)
+		for i := 0; filesErr == nil && i < len(fileHandles); i++ {
+			filesErr = models.SetFileOwner(fileHandles[i], account.AccountID)
+		}
+	}
+
+	var metadatasErr error
+	if len(metadataKeys) > 0 {
+		if metadataApiVersion == 2 {
+			metadatasErr = updateMetadataExpirationV2(metadataKeys, key, metadatasExpiredAt, c)
+		} else {
+			metadatasErr = updateMetadataExpiration(metadataKeys, key, metadatasExpiredAt, c)
+		}
+		for i := 0; metadatasErr == nil && i < len(metadataKeys); i++ {
+			metadatasErr = setMetadataOwnerFromRequest(metadataKeys[i], account.AccountID, metadataApiVersion)
+		}
+	}
+
+	// Only lists the client sent, even empty, are everything it has
+	var indexedErr error
+	if fileHandles != nil && metadataKeys != nil && filesErr == nil && metadatasErr == nil && !account.OwnersIndexed {
+		indexedErr = models.SetOwnersIndexed(account.AccountID)
+	}
+
+	return utils.CollectErrors([]error{propagationErr, filesErr, metadatasErr, indexedErr})
+}
+
+/*setMetadataOwnerFromRequest records the owner of a metadata key as the expiration updates take it, v2
+keys are kept padded like the metadata routes send them*/
+func setMetadataOwnerFromRequest(metadataKey, accountID string, apiVersion int) error {
+	if apiVersion == 2 {
+		metadataKeyBytes, err := base64.RawURLEncoding.DecodeString(metadataKey)
+		if err != nil {
+			return err
+		}
+		metadataKey = base64.URLEncoding.EncodeToString(metadataKeyBytes)
+	}
+	return models.SetMetadataOwner(metadataKey, accountID, apiVersion)
+}
+
+/*getExpiryPropagationProgress returns how far the last expiry propagation of the account went, nil when
+it never had one*/
+func getExpiryPropagationProgress(accountID string) (*expiryPropagationRes, error) {
+	expiryPropagation, err := models.GetExpiryPropagation(accountID)
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &expiryPropagationRes{
+		Completed:        expiryPropagation.Status == models.ExpiryPropagationCompleted,
+		FilesUpdated:     expiryPropagation.FilesUpdated,
+		MetadatasUpdated: expiryPropagation.MetadatasUpdated,
+	}, nil
+}
+
+/*isExpiryPropagationInProgress is whether the files and metadatas of the account are still getting the
+expiration of its last renewal or upgrade*/
+func isExpiryPropagationInProgress(accountID string) (bool, error) {
+	progress, err := getExpiryPropagationProgress(accountID)
+	return progress != nil && !progress.Completed, err
+}
+
+/*isPaidUpgradePropagating is whether the account was upgraded to the storage limit, with a payment that
+went through, and its files and metadatas are still getting the new expiration*/
+func isPaidUpgradePropagating(account models.Account, storageLimit int) (bool, error) {
+	if int(account.StorageLimit) != storageLimit {
+		return false, nil
+	}
+	upgrades, err := models.GetUpgradesFromAccountID(account.AccountID)
+	if err != nil {
+		return false, err
+	}
+	for _, upgrade := range upgrades {
+		if int(upgrade.NewStorageLimit) == storageLimit && upgrade.PaymentStatus >= models.InitialPaymentReceived {
+			return isExpiryPropagationInProgress(account.AccountID)
+		}
+	}
+	return false, nil
+}
+
+/*successWithExpiryPropagationResponse answers that the payment went through, along with how far the
+expiry propagation went*/
+func successWithExpiryPropagationResponse(c *gin.Context, accountID string) error {
+	progress, err := getExpiryPropagationProgress(accountID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	return OkResponse(c, StatusRes{
+		Status:            "Success with OPCT",
+		ExpiryPropagation: progress,
+	})
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+)
+
+// How many files a page lists when the request doesn't say
+const filesListDefaultLimit = 100
+
+type filesListObj struct {
+	After     string `json:"after" validate:"omitempty,len=64" minLength:"64" maxLength:"64" example:"the last file handle of the previous page"`
+	Limit     int    `json:"limit" validate:"omitempty,gte=1,lte=1000" example:"100"`
+	Timestamp int64  `json:"timestamp" validate:"required"`
+}
+
+type filesListReq struct {
+	verification
+	requestBody
+	filesListObj filesListObj
+}
+
+type listedFile struct {
+	FileHandle     string `json:"fileHandle" example:"a 64-char file handle"`
+	FileSizeInByte int64  `json:"fileSizeInByte" example:"200000000000006"`
+	CreatedAt      int64  `json:"createdAt" example:"1557346389"`
+	ExpiredAt      int64  `json:"expiredAt" example:"1659325302"`
+}
+
+type filesListRes struct {
+	Files []listedFile `json:"files"`
+	/*NextAfter is the after of the next page, empty on the last page*/
+	NextAfter string `json:"nextAfter,omitempty" example:"the last file handle of this page"`
+}
+
+func (v *filesListReq) getObjectRef() interface{} {
+	return &v.filesListObj
+}
+
+// ListFilesHandler godoc
+// @Summary list the files of an account
+// @Description list the completed files an account owns, by file handle. Pass the nextAfter of a page as after
+// @Description to get the next one. Files uploaded before the node kept track of their owner are not listed,
+// @Description nor are the files in the trash bin.
+// @Accept  json
+// @Produce  json
+// @Param filesListReq body routes.filesListReq true "files list object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"after": "the last file handle of the previous page, optional",
+// @description 	"limit": 100,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.filesListRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/files/list [post]
+/*ListFilesHandler is a handler for listing the files of an account*/
+func ListFilesHandler() gin.HandlerFunc {
+	return ginHandlerFunc(listFiles)
+}
+
+func listFiles(c *gin.Context) error {
+	request := filesListReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	limit := request.filesListObj.Limit
+	if limit == 0 {
+		limit = filesListDefaultLimit
+	}
+
+	completedFiles, err := models.GetUntrashedCompletedFilesByOwner(account.AccountID, request.filesListObj.After, limit)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	res := filesListRes{Files: []listedFile{}}
+	for _, completedFile := range completedFiles {
+		res.Files = append(res.Files, listedFile{
+			FileHandle:     completedFile.FileID,
+			FileSizeInByte: completedFile.FileSizeInByte,
+			CreatedAt:      completedFile.CreatedAt.Unix(),
+			ExpiredAt:      completedFile.ExpiredAt.Unix(),
+		})
+	}
+	if len(completedFiles) == limit {
+		res.NextAfter = completedFiles[len(completedFiles)-1].FileID
+	}
+
+	return OkResponse(c, res)
+}
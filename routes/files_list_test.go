@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Files_List(t *testing.T) {
+	setupTests(t)
+}
+
+func listFilesForTest(t *testing.T, after string, limit int, privateKey *ecdsa.PrivateKey) filesListRes {
+	v, b := returnValidVerificationAndRequestBody(t, filesListObj{
+		After:     after,
+		Limit:     limit,
+		Timestamp: time.Now().Unix(),
+	}, privateKey)
+	post := filesListReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	w := httpPostRequestHelperForTest(t, FilesListV2Path, "v2", post)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := filesListRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return res
+}
+
+func Test_List_Files(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	var fileIDs []string
+	for i := 0; i < 3; i++ {
+		completedFile := models.CompletedFile{
+			FileID:         utils.GenerateFileHandle(),
+			ModifierHash:   utils.GenerateFileHandle(),
+			FileSizeInByte: int64(100 * (i + 1)),
+			ExpiredAt:      time.Now().Add(time.Hour),
+		}
+		assert.Nil(t, models.DB.Create(&completedFile).Error)
+		assert.Nil(t, models.SetFileOwner(completedFile.FileID, accountID))
+		fileIDs = append(fileIDs, completedFile.FileID)
+	}
+	sort.Strings(fileIDs)
+
+	otherAccountFile := models.CompletedFile{FileID: utils.GenerateFileHandle(), ModifierHash: utils.GenerateFileHandle()}
+	assert.Nil(t, models.DB.Create(&otherAccountFile).Error)
+	assert.Nil(t, models.SetFileOwner(otherAccountFile.FileID, utils.GenerateFileHandle()))
+
+	res := listFilesForTest(t, "", 2, privateKey)
+	assert.Len(t, res.Files, 2)
+	assert.Equal(t, fileIDs[0], res.Files[0].FileHandle)
+	assert.Equal(t, fileIDs[1], res.NextAfter)
+
+	res = listFilesForTest(t, res.NextAfter, 2, privateKey)
+	assert.Len(t, res.Files, 1)
+	assert.Equal(t, fileIDs[2], res.Files[0].FileHandle)
+	assert.Empty(t, res.NextAfter)
+}
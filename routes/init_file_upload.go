@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/utils"
@@ -10,6 +12,8 @@ type InitFileUploadObj struct {
 	FileHandle     string `form:"fileHandle" validate:"required,len=64" minLength:"64" maxLength:"64" example:"a deterministically created file handle"`
 	FileSizeInByte int64  `form:"fileSizeInByte" validate:"required" example:"200000000000006"`
 	EndIndex       int    `form:"endIndex" validate:"required" example:"2"`
+	/*FileSha256 is checked against the data after the upload completed and returned by the download endpoints when given*/
+	FileSha256 string `form:"fileSha256" validate:"omitempty,len=64,hexadecimal" example:"the hex SHA-256 of the whole file"`
 }
 
 type InitFileUploadReq struct {
@@ -34,7 +38,8 @@ func (v *InitFileUploadReq) getObjectRef() interface{} {
 // @description {
 // @description 	"fileHandle": "a deterministically created file handle",
 // @description 	"fileSizeInByte": "200000000000006",
-// @description 	"endIndex": 2
+// @description 	"endIndex": 2,
+// @description 	"fileSha256": "the hex SHA-256 of the whole file, optional. The file is deleted if the data doesn't match"
 // @description }
 // @Success 200 {object} routes.StatusRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
@@ -61,49 +66,60 @@ func initFileUploadWithContext(c *gin.Context) error {
 }
 
 func initFileUploadWithRequest(request InitFileUploadReq, c *gin.Context) error {
+	if _, err := startFileUpload(request, c); err != nil {
+		return err
+	}
+
+	return OkResponse(c, StatusRes{
+		Status: "File is init. Please continue to upload",
+	})
+}
+
+/*startFileUpload checks the account has room for the file, stores its metadata and starts its multipart upload*/
+func startFileUpload(request InitFileUploadReq, c *gin.Context) (models.File, error) {
 	account, err := request.getAccount(c)
 	if err != nil {
-		return err
+		return models.File{}, err
 	}
 
 	if err := verifyIfPaidWithContext(account, c); err != nil {
-		return err
+		return models.File{}, err
 	}
 
 	if err := checkHaveEnoughStorageSpace(account, request.initFileUploadObj.FileSizeInByte, c); err != nil {
-		return err
+		return models.File{}, err
 	}
 
 	objKey, uploadID, err := utils.CreateMultiPartUpload(models.GetFileDataKey(request.initFileUploadObj.FileHandle), "")
 	if err != nil {
-		return InternalErrorResponse(c, err)
+		return models.File{}, InternalErrorResponse(c, err)
 	}
 
 	if err := utils.SetDefaultBucketObject(models.GetFileMetadataKey(request.initFileUploadObj.FileHandle), request.MetadataAsFile, ""); err != nil {
-		return InternalErrorResponse(c, err)
+		return models.File{}, InternalErrorResponse(c, err)
 	}
 
-	modifierHash, err := getPermissionHash(request.PublicKey, request.initFileUploadObj.FileHandle, c)
+	modifierHash, err := getPermissionHash(getOwnerPublicKey(request.PublicKey, c), request.initFileUploadObj.FileHandle, c)
 	if err != nil {
-		return err
+		return models.File{}, err
 	}
 
 	file := models.File{
-		FileID:       request.initFileUploadObj.FileHandle,
-		EndIndex:     request.initFileUploadObj.EndIndex,
-		AwsUploadID:  uploadID,
-		AwsObjectKey: objKey,
-		ExpiredAt:    account.ExpirationDate(),
-		ModifierHash: modifierHash,
+		FileID:         request.initFileUploadObj.FileHandle,
+		EndIndex:       request.initFileUploadObj.EndIndex,
+		AwsUploadID:    uploadID,
+		AwsObjectKey:   objKey,
+		ExpiredAt:      account.ExpirationDate(),
+		ModifierHash:   modifierHash,
+		FileSizeInByte: request.initFileUploadObj.FileSizeInByte,
+		Sha256:         strings.ToLower(request.initFileUploadObj.FileSha256),
 	}
 
 	if err := models.DB.Create(&file).Error; err != nil {
-		return InternalErrorResponse(c, err)
+		return models.File{}, InternalErrorResponse(c, err)
 	}
 
-	return OkResponse(c, StatusRes{
-		Status: "File is init. Please continue to upload",
-	})
+	return file, nil
 }
 
 func checkHaveEnoughStorageSpace(account models.Account, fileSizeInByte int64, c *gin.Context) error {
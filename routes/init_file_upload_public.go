@@ -57,7 +57,7 @@ func initFileUploadPublicWithRequest(request InitFileUploadReq, c *gin.Context)
 		return InternalErrorResponse(c, err)
 	}
 
-	modifierHash, err := getPermissionHash(request.PublicKey, request.initFileUploadObj.FileHandle, c)
+	modifierHash, err := getPermissionHash(getOwnerPublicKey(request.PublicKey, c), request.initFileUploadObj.FileHandle, c)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+)
+
+// LocalBlobHandler godoc
+// @Summary download an object from the local blob store
+// @Description serves the public objects that GetBucketUrl points at when the node stores its objects on the local filesystem,
+// @Description and private ones through the presigned URLs the download route returns. Supports a single HTTP byte range.
+// @Param key path string true "the object key, e.g. the file handle followed by /public"
+// @Param expires query int false "when the presigned URL expires"
+// @Param signature query string false "the signature of the presigned URL"
+// @Param Range header string false "byte range, e.g. bytes=0-99"
+// @Produce octet-stream
+// @Success 200 {string} string "the object data"
+// @Success 206 {string} string "the requested range of the object data"
+// @Failure 403 {string} string "access denied, object is not public or the URL signature is invalid or expired"
+// @Failure 404 {string} string "the object does not exist"
+// @Failure 416 {string} string "the requested range is not satisfiable"
+// @Router /blobs/{key} [get]
+/*LocalBlobHandler serves public objects out of the local blob store*/
+func LocalBlobHandler() gin.HandlerFunc {
+	return ginHandlerFunc(serveLocalBlob)
+}
+
+func serveLocalBlob(c *gin.Context) error {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	output, err := utils.GetLocalBlobObject(key, c.GetHeader("Range"), c.Request.URL.Query())
+	if err != nil {
+		if err == utils.ErrLocalBlobNotPublic || err == utils.ErrLocalBlobBadSignature {
+			return ForbiddenResponse(c, err)
+		}
+		if aerr, ok := err.(awserr.RequestFailure); ok {
+			switch aerr.StatusCode() {
+			case http.StatusNotFound:
+				return NotFoundResponse(c, err)
+			case http.StatusRequestedRangeNotSatisfiable:
+				c.AbortWithStatusJSON(http.StatusRequestedRangeNotSatisfiable, err.Error())
+				return err
+			default:
+				return BadRequestResponse(c, err)
+			}
+		}
+		return InternalErrorResponse(c, err)
+	}
+	defer output.Body.Close()
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Type", aws.StringValue(output.ContentType))
+	c.Header("Content-Length", strconv.FormatInt(aws.Int64Value(output.ContentLength), 10))
+	c.Header("ETag", aws.StringValue(output.ETag))
+	c.Header("Last-Modified", aws.TimeValue(output.LastModified).UTC().Format(http.TimeFormat))
+
+	status := http.StatusOK
+	if output.ContentRange != nil {
+		c.Header("Content-Range", aws.StringValue(output.ContentRange))
+		status = http.StatusPartialContent
+	}
+	c.Status(status)
+
+	if c.Request.Method == http.MethodHead {
+		return nil
+	}
+	_, err = io.Copy(c.Writer, output.Body)
+	return err
+}
+
+// LocalBlobUploadPartHandler godoc
+// @Summary upload a part to the local blob store
+// @Description takes a part of a multipart upload PUT to a presigned URL returned by init-upload-direct,
+// @Description when the node stores its objects on the local filesystem.
+// @Param key path string true "the object key, e.g. the file handle followed by /file"
+// @Param uploadId query string true "the multipart upload"
+// @Param partNumber query int true "the part number"
+// @Param expires query int true "when the URL expires"
+// @Param signature query string true "the signature of the URL"
+// @Param Content-MD5 header string false "the base64 MD5 of the part, the part is rejected if it doesn't match"
+// @Accept octet-stream
+// @Success 200 {string} string ""
+// @Failure 403 {string} string "access denied, the URL signature is invalid or expired"
+// @Failure 404 {string} string "the upload does not exist"
+// @Router /blobs/{key} [put]
+/*LocalBlobUploadPartHandler stores parts sent to presigned URLs of the local blob store*/
+func LocalBlobUploadPartHandler() gin.HandlerFunc {
+	return ginHandlerFunc(uploadLocalBlobPart)
+}
+
+func uploadLocalBlobPart(c *gin.Context) error {
+	defer c.Request.Body.Close()
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, utils.MaxMultiPartSize)
+	part, err := utils.UploadLocalBlobPart(key, c.Request.URL.Query(), body, c.GetHeader("Content-MD5"))
+	if err != nil {
+		if err == utils.ErrLocalBlobBadSignature {
+			return ForbiddenResponse(c, err)
+		}
+		if aerr, ok := err.(awserr.RequestFailure); ok {
+			if aerr.StatusCode() == http.StatusNotFound {
+				return NotFoundResponse(c, err)
+			}
+			return BadRequestResponse(c, err)
+		}
+		return InternalErrorResponse(c, err)
+	}
+
+	c.Header("ETag", aws.StringValue(part.ETag))
+	c.Status(http.StatusOK)
+	return nil
+}
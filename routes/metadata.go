@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/dgraph-io/badger"
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/utils"
@@ -405,6 +404,10 @@ func createMetadata(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
+	if err := models.SetMetadataOwner(requestBodyParsed.MetadataKey, account.AccountID, 1); err != nil {
+		utils.LogIfError(err, map[string]interface{}{"metadataKey": requestBodyParsed.MetadataKey})
+	}
+
 	return OkResponse(c, createMetadataRes{
 		ExpirationDate: account.ExpirationDate(),
 	})
@@ -472,6 +475,10 @@ func deleteMetadata(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
+	if err := models.DeleteMetadataOwners([]string{requestBodyParsed.MetadataKey}); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
 	return OkResponse(c, metadataDeletedRes)
 }
 
@@ -489,7 +496,7 @@ func storeMetadataHistory(metadataKey string, oldMetadata string, ttl time.Durat
 		}, ttl); err != nil {
 			return InternalErrorResponse(c, err)
 		}
-		if err == badger.ErrKeyNotFound {
+		if err == utils.ErrKeyNotFound {
 			stopOnNextKey = true
 		}
 		newValue = oldValue
@@ -501,7 +508,7 @@ func getMetadataHistoryWithoutContext(metadataKey string) ([]string, error) {
 	metadataHistory := []string{}
 	for i := 0; i < numMetadatasToRetain; i++ {
 		oldMetadata, _, err := utils.GetValueFromKV(getVersionKeyForBadger(metadataKey, i))
-		if err == badger.ErrKeyNotFound {
+		if err == utils.ErrKeyNotFound {
 			break
 		}
 		if err != nil {
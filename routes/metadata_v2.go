@@ -486,6 +486,10 @@ func updateSetMetadata(metadataKey string, publicKeyBin []byte, ttl time.Duratio
 			return
 		}
 
+		if ownerErr := models.SetMetadataOwner(metadataKey, account.AccountID, 2); ownerErr != nil {
+			utils.LogIfError(ownerErr, map[string]interface{}{"metadataKey": metadataKey})
+		}
+
 		oldMetadataV2 = base64.URLEncoding.EncodeToString(d.Binary())
 	}
 
@@ -762,6 +766,10 @@ func deleteMetadataV2(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
+	if err := models.DeleteMetadataOwners([]string{requestBodyParsed.MetadataV2Key}); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
 	return OkResponse(c, metadataV2DeletedRes)
 }
 
@@ -854,5 +862,9 @@ func deleteMetadataMultipleV2(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
+	if err := models.DeleteMetadataOwners(requestBodyParsed.MetadataV2Keys); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
 	return OkResponse(c, metadataV2DeletedRes)
 }
@@ -0,0 +1,269 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	publicShareAccessTokenSizeInBytes = 32
+
+	/*PublicShareAccessTokenHeader is the header the access token of a protected public share is sent in*/
+	PublicShareAccessTokenHeader = "X-Share-Access-Token"
+
+	publicShareAccessTokenInvalidResponse = "this public share is password protected, unlock it to get a valid access token"
+)
+
+type publicSharePasswordObj struct {
+	Shortlink string `json:"shortlink" validate:"required" example:"the short link of the completed file"`
+	Password  string `json:"password" validate:"omitempty,max=72" maxLength:"72" example:"the new password, empty to remove it"`
+}
+
+type publicSharePasswordReq struct {
+	verification
+	requestBody
+	publicSharePasswordObj publicSharePasswordObj
+}
+
+type publicShareUnlockReq struct {
+	Shortlink string `json:"shortlink" validate:"required" example:"the short link of the completed file"`
+	Password  string `json:"password" validate:"required,max=72" maxLength:"72" example:"the password of the public share"`
+}
+
+type publicShareUnlockRes struct {
+	AccessToken string    `json:"accessToken" example:"a 64 character hex string to send in the X-Share-Access-Token header"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+func (v *publicSharePasswordReq) getObjectRef() interface{} {
+	return &v.publicSharePasswordObj
+}
+
+// SetPublicSharePasswordHandler godoc
+// @Summary set the password of a public share
+// @Description protect a public share with a password, or remove its password. While every public share of a file
+// @Description is protected the public file is only handed out through presigned URLs.
+// @Accept  json
+// @Produce  json
+// @Param publicSharePasswordReq body routes.publicSharePasswordReq true "public share password object"
+// @description requestBody should be a stringified version of:
+// @description {
+// @description 	"shortlink": "the shortlink of the completed file",
+// @description 	"password": "the new password, empty to remove it"
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "public share or file does not exist"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/public-share/password [post]
+/*SetPublicSharePasswordHandler is a handler for the owner to set or remove the password of a public share*/
+func SetPublicSharePasswordHandler() gin.HandlerFunc {
+	return ginHandlerFunc(setPublicSharePassword)
+}
+
+// UnlockPublicShareHandler godoc
+// @Summary unlock a password protected public share
+// @Description exchange the password of a public share for a short-lived access token to send in the
+// @Description X-Share-Access-Token header when getting the shortlink or downloading the public file.
+// @Description Wrong passwords are throttled for each public share.
+// @Accept  json
+// @Produce  json
+// @Param publicShareUnlockReq body routes.publicShareUnlockReq true "public share unlock object"
+// @Success 200 {object} routes.publicShareUnlockRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "wrong password"
+// @Failure 404 {string} string "public share does not exist"
+// @Failure 429 {string} string "too many wrong passwords, try again later"
+// @Router /api/v2/public-share/unlock [post]
+/*UnlockPublicShareHandler is a handler for exchanging the password of a public share for an access token*/
+func UnlockPublicShareHandler() gin.HandlerFunc {
+	return ginHandlerFunc(unlockPublicShare)
+}
+
+func setPublicSharePassword(c *gin.Context) error {
+	request := publicSharePasswordReq{}
+
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	publicShare, err := models.GetPublicShareByID(request.publicSharePasswordObj.Shortlink)
+	if err != nil {
+		return NotFoundResponse(c, errors.New("public share does not exist"))
+	}
+
+	completedFile, err := models.GetCompletedFileByFileID(publicShare.FileID)
+	if err != nil {
+		return NotFoundResponse(c, errors.New("file does not exist"))
+	}
+
+	if err := verifyPermissions(request.PublicKey, publicShare.FileID, completedFile.ModifierHash, c); err != nil {
+		return err
+	}
+
+	if err := publicShare.SetPassword(request.publicSharePasswordObj.Password); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if err := models.SyncPublicFileAcl(publicShare.FileID); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	if publicShare.IsProtected() {
+		return OkResponse(c, StatusRes{Status: "public share is password protected"})
+	}
+	return OkResponse(c, StatusRes{Status: "public share is open to anyone"})
+}
+
+func unlockPublicShare(c *gin.Context) error {
+	request := publicShareUnlockReq{}
+
+	if err := utils.ParseRequestBody(c.Request, &request); err != nil {
+		return BadRequestResponse(c, err)
+	}
+
+	publicShare, err := models.GetPublicShareByID(request.Shortlink)
+	if err != nil {
+		return NotFoundResponse(c, errors.New("public share does not exist"))
+	}
+
+	if publicShare.IsProtected() {
+		// The attempt is charged before the password is checked so concurrent guesses can't all get through,
+		// and in the KV store so every node counts against the same limit
+		now := time.Now().Unix()
+		attemptsKey := getPublicSharePasswordAttemptsKeyForBadger(publicShare.PublicID, now/60)
+		attempts, err := utils.Increment(attemptsKey, 1, time.Minute)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		if attempts > int64(utils.Env.PublicSharePasswordAttemptsPerMinute) {
+			return TooManyRequestsResponse(c, time.Duration(60-now%60)*time.Second)
+		}
+		if !publicShare.CheckPassword(request.Password) {
+			return ForbiddenResponse(c, errors.New("wrong password"))
+		}
+		// Only wrong passwords count
+		_, err = utils.Increment(attemptsKey, -1, time.Minute)
+		utils.LogIfError(err, map[string]interface{}{"publicID": publicShare.PublicID})
+	}
+
+	tokenBytes := make([]byte, publicShareAccessTokenSizeInBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	ttl := time.Duration(utils.Env.PublicShareAccessTokenTTLMinutes) * time.Minute
+	if err := utils.BatchSet(&utils.KVPairs{getPublicShareAccessKeyForBadger(token): publicShare.PublicID}, ttl); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, publicShareUnlockRes{
+		AccessToken: token,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+}
+
+/*getUnlockedPublicShare returns the public share the access token in the request was given for*/
+func getUnlockedPublicShare(c *gin.Context) (models.PublicShare, bool, error) {
+	token := c.GetHeader(PublicShareAccessTokenHeader)
+	if len(token) != 2*publicShareAccessTokenSizeInBytes {
+		return models.PublicShare{}, false, nil
+	}
+
+	publicID, _, err := utils.GetValueFromKV(getPublicShareAccessKeyForBadger(token))
+	if err == utils.ErrKeyNotFound {
+		return models.PublicShare{}, false, nil
+	}
+	if err != nil {
+		return models.PublicShare{}, false, err
+	}
+
+	publicShare, err := models.GetPublicShareByID(publicID)
+	if err != nil {
+		// The public share was revoked since
+		return models.PublicShare{}, false, nil
+	}
+	return publicShare, true, nil
+}
+
+/*verifyPublicShareAccess checks the request carries an access token of the public share when it is protected*/
+func verifyPublicShareAccess(publicShare models.PublicShare, c *gin.Context) error {
+	if !publicShare.IsProtected() {
+		return nil
+	}
+	unlocked, ok, err := getUnlockedPublicShare(c)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if !ok || unlocked.PublicID != publicShare.PublicID {
+		return ForbiddenResponse(c, errors.New(publicShareAccessTokenInvalidResponse))
+	}
+	return nil
+}
+
+/*verifyPublicFileAccess checks the request carries an access token of one of the public shares of the file
+when all of them are protected, and returns that share*/
+func verifyPublicFileAccess(fileID string, c *gin.Context) (bool, models.PublicShare, error) {
+	protected, err := models.IsPublicFileProtected(fileID)
+	if err != nil {
+		return false, models.PublicShare{}, InternalErrorResponse(c, err)
+	}
+	if !protected {
+		return false, models.PublicShare{}, nil
+	}
+	unlocked, ok, err := getUnlockedPublicShare(c)
+	if err != nil {
+		return true, models.PublicShare{}, InternalErrorResponse(c, err)
+	}
+	if !ok || unlocked.FileID != fileID {
+		return true, models.PublicShare{}, ForbiddenResponse(c, errors.New(publicShareAccessTokenInvalidResponse))
+	}
+	return true, unlocked, nil
+}
+
+/*getPublicFileDownloadURLs returns the URLs of the public file and of its thumbnail, presigned ones when the
+public objects are private. Presigned URLs stop working at expiresAt at the latest, the expiration of the share
+they are handed out for*/
+func getPublicFileDownloadURLs(fileID string, private bool, expiresAt *time.Time) (string, string, error) {
+	if !private {
+		fileURL, thumbnailURL := models.GetPublicFileDownloadData(fileID)
+		return fileURL, checkFileThumbnail(thumbnailURL), nil
+	}
+
+	ttl := time.Duration(utils.Env.DownloadURLTTLMinutes) * time.Minute
+	if expiresAt != nil && time.Until(*expiresAt) < ttl {
+		ttl = time.Until(*expiresAt)
+	}
+	// Presigned URLs expire to the second
+	if ttl < time.Second {
+		return "", "", models.ErrPublicShareExhausted
+	}
+	fileURL, err := utils.PresignDefaultBucketObjectURL(models.GetFileDataPublicKey(fileID), ttl)
+	if err != nil {
+		return "", "", err
+	}
+	thumbnailURL := defaultThumbnailURL
+	if thumbnailKey := models.GetPublicThumbnailKey(fileID); utils.DoesDefaultBucketObjectExist(thumbnailKey) {
+		if thumbnailURL, err = utils.PresignDefaultBucketObjectURL(thumbnailKey, ttl); err != nil {
+			return "", "", err
+		}
+	}
+	return fileURL, thumbnailURL, nil
+}
+
+func getPublicShareAccessKeyForBadger(token string) string {
+	// Like session tokens, only a hash of the token is stored
+	return utils.HashStringV2([]byte(token)) + "_share_access"
+}
+
+func getPublicSharePasswordAttemptsKeyForBadger(publicID string, minute int64) string {
+	return publicID + "_" + strconv.FormatInt(minute, 10) + "_share_password_attempts"
+}
@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Public_Share_Password(t *testing.T) {
+	setupTests(t)
+}
+
+/*createProtectedPublicShareForTest shares a file of a new account and protects the share with the password*/
+func createProtectedPublicShareForTest(t *testing.T, password string) (models.PublicShare, *ecdsa.PrivateKey) {
+	_, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	fileID := fileIDs[0]
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataPublicKey(fileID), "public data", ""))
+	assert.Nil(t, utils.SetDefaultObjectCannedAcl(models.GetFileDataPublicKey(fileID), utils.CannedAcl_PublicRead))
+
+	obj := models.CreateShortlinkObj{FileID: fileID, Title: "title", Description: "description", Password: password}
+	v, b := returnValidVerificationAndRequestBody(t, obj, privateKey)
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+CreateShortLinkPath, "v2",
+		CreateShortlinkReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := CreateShortlinkResp{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	publicShare, err := models.GetPublicShareByID(res.ShortID)
+	assert.Nil(t, err)
+	return publicShare, privateKey
+}
+
+func unlockPublicShareForTest(t *testing.T, shortlink, password string) *publicShareUnlockRes {
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareUnlockPath, "v2",
+		publicShareUnlockReq{Shortlink: shortlink, Password: password})
+	if w.Code != http.StatusOK {
+		return nil
+	}
+	res := publicShareUnlockRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return &res
+}
+
+func getShortlinkForTest(t *testing.T, shortlink, accessToken string) int {
+	w := httpGetRequestWithHeadersHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareShortlinkPath, "v2",
+		map[string]string{":shortlink": shortlink}, map[string]string{PublicShareAccessTokenHeader: accessToken})
+	return w.Code
+}
+
+func Test_Protected_Public_Share_Needs_Access_Token(t *testing.T) {
+	cleanUpBeforeTest(t)
+	publicShare, _ := createProtectedPublicShareForTest(t, "correct horse")
+	assert.True(t, publicShare.IsProtected())
+	assert.NotEqual(t, "correct horse", publicShare.PasswordHash)
+
+	acl, err := utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(models.GetFileDataPublicKey(publicShare.FileID))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.CannedAcl_Private, acl)
+
+	assert.Equal(t, http.StatusForbidden, getShortlinkForTest(t, publicShare.PublicID, ""))
+	w := httpPostRequestHelperForTest(t, DownloadPublicV2Path, "v2", DownloadFileObj{FileID: publicShare.FileID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.Nil(t, unlockPublicShareForTest(t, publicShare.PublicID, "wrong"))
+	unlocked := unlockPublicShareForTest(t, publicShare.PublicID, "correct horse")
+	assert.NotNil(t, unlocked)
+
+	assert.Equal(t, http.StatusOK, getShortlinkForTest(t, publicShare.PublicID, unlocked.AccessToken))
+	w = httpPostRequestWithHeadersHelperForTest(t, DownloadPublicV2Path, "v2", DownloadFileObj{FileID: publicShare.FileID},
+		map[string]string{PublicShareAccessTokenHeader: unlocked.AccessToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "X-Amz-Expires")
+}
+
+func Test_Public_Share_Password_Removed(t *testing.T) {
+	cleanUpBeforeTest(t)
+	publicShare, privateKey := createProtectedPublicShareForTest(t, "correct horse")
+
+	obj := publicSharePasswordObj{Shortlink: publicShare.PublicID}
+	v, b := returnValidVerificationAndRequestBody(t, obj, privateKey)
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicSharePasswordPath, "v2",
+		publicSharePasswordReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "open to anyone")
+
+	acl, err := utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(models.GetFileDataPublicKey(publicShare.FileID))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.CannedAcl_PublicRead, acl)
+	assert.Equal(t, http.StatusOK, getShortlinkForTest(t, publicShare.PublicID, ""))
+}
+
+func Test_Public_Share_Password_Set_By_Another_Account_Is_Forbidden(t *testing.T) {
+	cleanUpBeforeTest(t)
+	publicShare, _ := createProtectedPublicShareForTest(t, "correct horse")
+	_, otherPrivateKey := generateValidateAccountId(t)
+
+	obj := publicSharePasswordObj{Shortlink: publicShare.PublicID}
+	v, b := returnValidVerificationAndRequestBody(t, obj, otherPrivateKey)
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicSharePasswordPath, "v2",
+		publicSharePasswordReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Public_Share_Wrong_Passwords_Are_Throttled(t *testing.T) {
+	cleanUpBeforeTest(t)
+	publicShare, _ := createProtectedPublicShareForTest(t, "correct horse")
+
+	for i := 0; i < utils.Env.PublicSharePasswordAttemptsPerMinute; i++ {
+		assert.Nil(t, unlockPublicShareForTest(t, publicShare.PublicID, "wrong"))
+	}
+
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareUnlockPath, "v2",
+		publicShareUnlockReq{Shortlink: publicShare.PublicID, Password: "correct horse"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func Test_Public_Share_Concurrent_Wrong_Passwords_Are_Throttled(t *testing.T) {
+	cleanUpBeforeTest(t)
+	publicShare, _ := createProtectedPublicShareForTest(t, "correct horse")
+
+	var wg sync.WaitGroup
+	var forbidden int32
+	for i := 0; i < 3*utils.Env.PublicSharePasswordAttemptsPerMinute; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareUnlockPath, "v2",
+				publicShareUnlockReq{Shortlink: publicShare.PublicID, Password: "wrong"})
+			if w.Code == http.StatusForbidden {
+				atomic.AddInt32(&forbidden, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Only the guesses within the limit got their password checked
+	assert.True(t, int(forbidden) <= utils.Env.PublicSharePasswordAttemptsPerMinute)
+}
+
+func Test_Public_Share_Right_Passwords_Are_Not_Throttled(t *testing.T) {
+	cleanUpBeforeTest(t)
+	publicShare, _ := createProtectedPublicShareForTest(t, "correct horse")
+
+	for i := 0; i < utils.Env.PublicSharePasswordAttemptsPerMinute+1; i++ {
+		assert.NotNil(t, unlockPublicShareForTest(t, publicShare.PublicID, "correct horse"))
+	}
+}
+
+func Test_Revoking_The_Open_Share_Makes_The_Public_File_Private(t *testing.T) {
+	cleanUpBeforeTest(t)
+	protectedShare, privateKey := createProtectedPublicShareForTest(t, "correct horse")
+	openShare := models.CreatePublicShareObj()
+	openShare.FileID = protectedShare.FileID
+	assert.Nil(t, models.DB.Create(&openShare).Error)
+	assert.Nil(t, models.SyncPublicFileAcl(protectedShare.FileID))
+
+	obj := PublicShareObj{Shortlink: openShare.PublicID}
+	v, b := returnValidVerificationAndRequestBody(t, obj, privateKey)
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareRevokePath, "v2",
+		PublicShareOpsReq{verification: v, requestBody: b, publicShareObj: obj})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The protected share still needs the object, only through presigned URLs
+	acl, err := utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(models.GetFileDataPublicKey(protectedShare.FileID))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.CannedAcl_Private, acl)
+
+	obj = PublicShareObj{Shortlink: protectedShare.PublicID}
+	v, b = returnValidVerificationAndRequestBody(t, obj, privateKey)
+	w = httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareRevokePath, "v2",
+		PublicShareOpsReq{verification: v, requestBody: b, publicShareObj: obj})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataPublicKey(protectedShare.FileID)))
+}
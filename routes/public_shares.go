@@ -2,6 +2,7 @@ package routes
 
 import (
 	"errors"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
@@ -44,7 +45,8 @@ type CreateShortlinkReq struct {
 }
 
 type viewsCountResp struct {
-	Count int `json:"count"`
+	Count       int   `json:"count"`
+	EgressBytes int64 `json:"egressBytes" example:"1024"`
 }
 
 func (v *PublicShareOpsReq) getObjectRef() interface{} {
@@ -67,7 +69,10 @@ func (v *CreateShortlinkReq) getObjectRef() interface{} {
 // @description 	"title": "the title of the file",
 // @description 	"description": "a description of the file",
 // @description 	"mimeType": "the file mimeType example: image/png",
-// @description 	"fileExtension": "the file extension, example: png"
+// @description 	"fileExtension": "the file extension, example: png",
+// @description 	"password": "optional, a password needed to open the shortlink",
+// @description 	"expiresAt": "optional, the unix time at which the shortlink stops working",
+// @description 	"maxViews": "optional, how many times the shortlink can be opened"
 // @description }
 // @Success 200 {object} routes.CreateShortlinkResp
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
@@ -81,12 +86,17 @@ func CreateShortlinkHandler() gin.HandlerFunc {
 
 // ShortlinkFileHandler godoc
 // @Summary get S3 url for a publicly shared file
-// @Description get the S3 URL for a publicly shared file
+// @Description get the S3 URL for a publicly shared file.
+// @Description While every public share of the file is password protected, or one of them expires or has a maximum number of
+// @Description views, the public objects are private and the URLs are presigned ones that stop working when the share expires.
 // @Accept  json
 // @Produce  json
 // @Param shortlink path string true "shortlink ID"
 // @Success 200 {object} PublicFileDownloadResp
+// @Param X-Share-Access-Token header string false "access token of the public share, when it is password protected"
+// @Failure 403 {string} string "the public share is password protected, or the account owning the file used its downloads for the month"
 // @Failure 404 {string} string "file does not exist"
+// @Failure 410 {string} string "this public share expired or reached its maximum number of views"
 // @Failure 500 {string} string "there was an error parsing your request"
 // @Router /api/v2/public-share/:shortlink [get]
 /*ShortlinkFileHandler is a handler for the user get the S3 url of a public file*/
@@ -116,7 +126,7 @@ func ViewsCountHandler() gin.HandlerFunc {
 
 // RevokePublicShareHandler godoc
 // @Summary revokes public share
-// @Description remove a public share entry, revoke the share
+// @Description remove a public share entry, revoke the share. The public objects of the file are deleted with its last share
 // @Accept  json
 // @Produce  json
 // @Param PublicShareOpsReq body routes.PublicShareOpsReq true "an object to do operations on a public share"
@@ -142,6 +152,10 @@ func createShortLinkWithContext(c *gin.Context) error {
 		return err
 	}
 
+	if expiresAt := request.createShortlinkObj.ExpiresAt; expiresAt != 0 && expiresAt <= time.Now().Unix() {
+		return BadRequestResponse(c, errors.New("expiresAt must be in the future"))
+	}
+
 	awsKey := models.GetFileDataPublicKey(request.createShortlinkObj.FileID)
 	if !utils.DoesDefaultBucketObjectExist(awsKey) {
 		return NotFoundResponse(c, errors.New("file does not exist"))
@@ -154,6 +168,9 @@ func createShortLinkWithContext(c *gin.Context) error {
 		}
 		return InternalErrorResponse(c, err)
 	}
+	if err := models.SyncPublicFileAcl(publicShare.FileID); err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	return OkResponse(c, CreateShortlinkResp{
 		ShortID: publicShare.PublicID,
@@ -168,13 +185,35 @@ func shortlinkURL(c *gin.Context) error {
 	if err != nil || !utils.DoesDefaultBucketObjectExist(fileDataPublicKey) {
 		return NotFoundResponse(c, errors.New("file does not exist"))
 	}
+	if publicShare.IsExhausted() {
+		return GoneResponse(c, models.ErrPublicShareExhausted)
+	}
+	if err := verifyPublicShareAccess(publicShare, c); err != nil {
+		return err
+	}
+	if err := verifyFileOwnerEgressQuota(publicShare.FileID, c); err != nil {
+		return err
+	}
 
 	err = publicShare.UpdateViewsCount()
+	if err == models.ErrPublicShareExhausted {
+		return GoneResponse(c, err)
+	}
 	if err != nil {
 		return InternalErrorResponse(c, errors.New("there was an error parsing your request"))
 	}
 
-	fileURL, thumbnailURL := models.GetPublicFileDownloadData(publicShare.FileID)
+	private, err := models.IsPublicFilePrivate(publicShare.FileID)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	fileURL, thumbnailURL, err := getPublicFileDownloadURLs(publicShare.FileID, private, publicShare.ExpiresAt)
+	if err == models.ErrPublicShareExhausted {
+		return GoneResponse(c, err)
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
 
 	return OkResponse(c, PublicFileDownloadResp{
 		S3URL:          fileURL,
@@ -204,7 +243,8 @@ func viewsCount(c *gin.Context) error {
 	}
 
 	return OkResponse(c, viewsCountResp{
-		Count: publicShare.ViewsCount,
+		Count:       publicShare.ViewsCount,
+		EgressBytes: publicShare.EgressBytes,
 	})
 }
 
@@ -220,13 +260,24 @@ func revokePublicShare(c *gin.Context) error {
 		return NotFoundResponse(c, errors.New("public share does not exist"))
 	}
 
-	utils.DeleteDefaultBucketObject(models.GetFileDataPublicKey(publicShare.FileID))
-	utils.DeleteDefaultBucketObject(models.GetPublicThumbnailKey(publicShare.FileID))
-
 	if err = publicShare.RemovePublicShare(); err != nil {
 		return InternalErrorResponse(c, err)
 	}
 
+	// Like the public share expirer, the objects stay for the other shares of the file, with the ACL they need now
+	stillShared, err := models.GetExistingPublicShareFileIDs([]string{publicShare.FileID})
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if stillShared[publicShare.FileID] {
+		if err := models.SyncPublicFileAcl(publicShare.FileID); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	} else {
+		utils.DeleteDefaultBucketObject(models.GetFileDataPublicKey(publicShare.FileID))
+		utils.DeleteDefaultBucketObject(models.GetPublicThumbnailKey(publicShare.FileID))
+	}
+
 	return OkResponse(c, StatusRes{
 		Status: "Public share revoked",
 	})
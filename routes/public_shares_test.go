@@ -1,9 +1,13 @@
 package routes
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
@@ -99,3 +103,94 @@ func requestTestGetPublicShareUrl(t *testing.T, ps models.PublicShare) *httptest
 
 	return w
 }
+
+func Test_PublicShare_Gone_After_Max_Views(t *testing.T) {
+	models.DeletePublicSharesForTest(t)
+	ps := createTestPublicShareWithS3Files(t, "")
+	assert.Nil(t, models.DB.Model(&ps).UpdateColumn("max_views", 2).Error)
+
+	requestTestGetPublicShareUrl(t, ps)
+	requestTestGetPublicShareUrl(t, ps)
+
+	params := map[string]string{":shortlink": ps.PublicID}
+	w := httpGetRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareShortlinkPath, "v2", params)
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	w = httpPostRequestHelperForTest(t, DownloadPublicV2Path, "v2", DownloadFileObj{FileID: ps.FileID})
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	t.Cleanup(func() {
+		cleanUpBeforeTest(t)
+		ps.RemovePublicShare()
+		utils.DeleteDefaultBucketObjectKeys(ps.FileID)
+	})
+}
+
+func Test_PublicShare_Gone_After_Expiration(t *testing.T) {
+	models.DeletePublicSharesForTest(t)
+	ps := createTestPublicShareWithS3Files(t, "")
+	assert.Nil(t, models.DB.Model(&ps).UpdateColumn("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	params := map[string]string{":shortlink": ps.PublicID}
+	w := httpGetRequestHelperForTest(t, "/"+PublicSharePathPrefix+PublicShareShortlinkPath, "v2", params)
+	assert.Equal(t, http.StatusGone, w.Code)
+
+	t.Cleanup(func() {
+		cleanUpBeforeTest(t)
+		ps.RemovePublicShare()
+		utils.DeleteDefaultBucketObjectKeys(ps.FileID)
+	})
+}
+
+func Test_Create_Shortlink_With_Expiration_In_The_Past(t *testing.T) {
+	cleanUpBeforeTest(t)
+	_, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataPublicKey(fileIDs[0]), "public data", ""))
+
+	obj := models.CreateShortlinkObj{FileID: fileIDs[0], Title: "title", Description: "description",
+		ExpiresAt: time.Now().Add(-time.Hour).Unix()}
+	v, b := returnValidVerificationAndRequestBody(t, obj, privateKey)
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+CreateShortLinkPath, "v2",
+		CreateShortlinkReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "expiresAt must be in the future")
+}
+
+func Test_Limited_PublicShare_Hands_Out_Presigned_Urls(t *testing.T) {
+	cleanUpBeforeTest(t)
+	_, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	fileID := fileIDs[0]
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataPublicKey(fileID), "public data", ""))
+	assert.Nil(t, utils.SetDefaultObjectCannedAcl(models.GetFileDataPublicKey(fileID), utils.CannedAcl_PublicRead))
+
+	obj := models.CreateShortlinkObj{FileID: fileID, Title: "title", Description: "description",
+		ExpiresAt: time.Now().Add(10 * time.Minute).Unix(), MaxViews: 5}
+	v, b := returnValidVerificationAndRequestBody(t, obj, privateKey)
+	w := httpPostRequestHelperForTest(t, "/"+PublicSharePathPrefix+CreateShortLinkPath, "v2",
+		CreateShortlinkReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	shortlinkRes := CreateShortlinkResp{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &shortlinkRes))
+
+	acl, err := utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(models.GetFileDataPublicKey(fileID))
+	assert.Nil(t, err)
+	assert.Equal(t, utils.CannedAcl_Private, acl)
+
+	// The URLs stop working when the share expires, before the usual TTL
+	ps := models.PublicShare{PublicID: shortlinkRes.ShortID}
+	res := PublicFileDownloadResp{}
+	assert.Nil(t, json.Unmarshal(requestTestGetPublicShareUrl(t, ps).Body.Bytes(), &res))
+	fileURL, err := url.Parse(res.S3URL)
+	assert.Nil(t, err)
+	expires, err := strconv.Atoi(fileURL.Query().Get("X-Amz-Expires"))
+	assert.Nil(t, err)
+	assert.True(t, expires > 0 && expires <= 600)
+
+	w = httpPostRequestHelperForTest(t, DownloadPublicV2Path, "v2", DownloadFileObj{FileID: fileID})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "X-Amz-Expires")
+
+	ps, err = models.GetPublicShareByID(shortlinkRes.ShortID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, ps.ViewsCount)
+}
@@ -0,0 +1,215 @@
+package routes
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	rateLimitGroupDefault     = "default"
+	rateLimitGroupMetadata    = "metadata"
+	rateLimitGroupUpload      = "upload"
+	rateLimitGroupDownload    = "download"
+	rateLimitGroupPublicShare = "public-share"
+
+	rateLimitKeyAccount = "account"
+	rateLimitKeyIP      = "ip"
+
+	// Set by the middleware to the group of the route, and once the account of the request was charged
+	rateLimitGroupContextKey   = "rateLimitGroup"
+	rateLimitChargedContextKey = "rateLimitCharged"
+)
+
+/*rateLimitGroupsByPath is the group of limits of each route, routes not listed use the default group*/
+var rateLimitGroupsByPath = map[string]string{
+	V1Path + MetadataGetPath:              rateLimitGroupMetadata,
+	V1Path + MetadataHistoryPath:          rateLimitGroupMetadata,
+	V1Path + MetadataSetPath:              rateLimitGroupMetadata,
+	V1Path + MetadataCreatePath:           rateLimitGroupMetadata,
+	V1Path + MetadataDeletePath:           rateLimitGroupMetadata,
+	V2Path + MetadataV2GetPath:            rateLimitGroupMetadata,
+	V2Path + MetadataV2GetPublicPath:      rateLimitGroupMetadata,
+	V2Path + MetadataV2AddPath:            rateLimitGroupMetadata,
+	V2Path + MetadataMultipleV2AddPath:    rateLimitGroupMetadata,
+	V2Path + MetadataV2DeletePath:         rateLimitGroupMetadata,
+	V2Path + MetadataMultipleV2DeletePath: rateLimitGroupMetadata,
+
+	V1Path + InitUploadPath:                      rateLimitGroupUpload,
+	V1Path + UploadPath:                          rateLimitGroupUpload,
+	V1Path + UploadStatusPath:                    rateLimitGroupUpload,
+	V2Path + InitUploadPublicPath:                rateLimitGroupUpload,
+	V2Path + UploadPublicPath:                    rateLimitGroupUpload,
+	V2Path + UploadStatusPublicPath:              rateLimitGroupUpload,
+	V2Path + InitUploadDirectPath:                rateLimitGroupUpload,
+	V2Path + UploadCompleteDirectPath:            rateLimitGroupUpload,
+	V2Path + "/" + TusPathPrefix:                 rateLimitGroupUpload,
+	V2Path + "/" + TusPathPrefix + TusUploadPath: rateLimitGroupUpload,
+
+	V1Path + DownloadPath:         rateLimitGroupDownload,
+	V2Path + DownloadV2Path:       rateLimitGroupDownload,
+	V2Path + DownloadPublicV2Path: rateLimitGroupDownload,
+	V2Path + DownloadStreamV2Path: rateLimitGroupDownload,
+	V3Path + DownloadV3Path:       rateLimitGroupDownload,
+
+	V2Path + "/" + PublicSharePathPrefix + PublicShareShortlinkPath:   rateLimitGroupPublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PrivateToPublicConvertPath: rateLimitGroupPublicShare,
+	V2Path + "/" + PublicSharePathPrefix + CreateShortLinkPath:        rateLimitGroupPublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicShareViewsCountPath:  rateLimitGroupPublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicShareRevokePath:      rateLimitGroupPublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicSharePasswordPath:    rateLimitGroupPublicShare,
+	V2Path + "/" + PublicSharePathPrefix + PublicShareUnlockPath:      rateLimitGroupPublicShare,
+}
+
+type rateLimitGroup struct {
+	name           string
+	accountLimiter *utils.RateLimiter
+}
+
+/*
+rateLimitMiddleware throttles the api routes with a token bucket per client IP and per group of routes.
+The per account bucket of the group is only charged once the request is verified, see chargeAccountRateLimit.
+*/
+func rateLimitMiddleware() gin.HandlerFunc {
+	ipLimiters := make(map[string]*utils.RateLimiter)
+	accountLimiters := make(map[string]*utils.RateLimiter)
+	for group, perMinute := range map[string]int{
+		rateLimitGroupDefault:     utils.Env.RateLimitDefaultPerMinute,
+		rateLimitGroupMetadata:    utils.Env.RateLimitMetadataPerMinute,
+		rateLimitGroupUpload:      utils.Env.RateLimitUploadPerMinute,
+		rateLimitGroupDownload:    utils.Env.RateLimitDownloadPerMinute,
+		rateLimitGroupPublicShare: utils.Env.RateLimitPublicSharePerMinute,
+	} {
+		if perMinute > 0 {
+			accountLimiters[group] = utils.NewRateLimiter(perMinute)
+			if utils.Env.RateLimitPerIPMultiplier > 0 {
+				ipLimiters[group] = utils.NewRateLimiter(perMinute * utils.Env.RateLimitPerIPMultiplier)
+			}
+		}
+	}
+	trustedProxies, err := parseTrustedProxies(getTrustedProxies())
+	utils.LogIfError(err, nil)
+
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if !isRateLimitedPath(path) {
+			c.Next()
+			return
+		}
+
+		group, ok := rateLimitGroupsByPath[path]
+		if !ok {
+			group = rateLimitGroupDefault
+		}
+		if limiter, ok := accountLimiters[group]; ok {
+			c.Set(rateLimitGroupContextKey, rateLimitGroup{name: group, accountLimiter: limiter})
+		}
+
+		if limiter, ok := ipLimiters[group]; ok {
+			if allowed, retryAfter := limiter.Allow(rateLimitKeyIP + ":" + getClientIP(c, trustedProxies)); !allowed {
+				utils.Metrics_Rate_Limited_Counter.WithLabelValues(group, rateLimitKeyIP).Inc()
+				TooManyRequestsResponse(c, retryAfter)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+/*isRateLimitedPath returns whether the route is one of the api ones, of any version*/
+func isRateLimitedPath(path string) bool {
+	for _, prefix := range []string{V1Path, V2Path, V3Path} {
+		if strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+/*getTrustedProxies returns the IPs and CIDRs of the proxies whose X-Forwarded-For header is trusted*/
+func getTrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(utils.Env.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return networks, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+/*
+getClientIP returns the IP the request came from. When it came through trusted proxies, that is the last
+address of X-Forwarded-For which isn't one of them: the client can put anything before it, so unlike
+gin's ClientIP the first address is never used.
+*/
+func getClientIP(c *gin.Context, trustedProxies []*net.IPNet) string {
+	remoteIP, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+
+	ip := remoteIP
+	forwardedFor := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+	for i := len(forwardedFor); isTrustedProxy(ip, trustedProxies) && i > 0; i-- {
+		forwarded := strings.TrimSpace(forwardedFor[i-1])
+		if net.ParseIP(forwarded) == nil {
+			break
+		}
+		ip = forwarded
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	for _, network := range trustedProxies {
+		if parsed != nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+chargeAccountRateLimit takes a token from the bucket of the account whose public key the request was
+verified for. Only the first verification of a request is charged.
+*/
+func chargeAccountRateLimit(publicKey string, c *gin.Context) error {
+	value, ok := c.Get(rateLimitGroupContextKey)
+	if !ok || c.GetBool(rateLimitChargedContextKey) {
+		return nil
+	}
+	c.Set(rateLimitChargedContextKey, true)
+
+	accountID, err := utils.HashString(publicKey)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	group := value.(rateLimitGroup)
+	if allowed, retryAfter := group.accountLimiter.Allow(rateLimitKeyAccount + ":" + accountID); !allowed {
+		utils.Metrics_Rate_Limited_Counter.WithLabelValues(group.name, rateLimitKeyAccount).Inc()
+		return TooManyRequestsResponse(c, retryAfter)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Rate_Limit(t *testing.T) {
+	setupTests(t)
+}
+
+func returnRateLimitedEngineForTest(t *testing.T, metadataPerMinute, perIPMultiplier int) *gin.Engine {
+	abortIfNotTesting(t)
+
+	defaultPerMinute, defaultMultiplier := utils.Env.RateLimitMetadataPerMinute, utils.Env.RateLimitPerIPMultiplier
+	utils.Env.RateLimitMetadataPerMinute, utils.Env.RateLimitPerIPMultiplier = metadataPerMinute, perIPMultiplier
+	defer func() {
+		utils.Env.RateLimitMetadataPerMinute, utils.Env.RateLimitPerIPMultiplier = defaultPerMinute, defaultMultiplier
+	}()
+
+	router := returnEngine()
+	verified := func(c *gin.Context) {
+		request := getAccountDataReq{}
+		if err := verifyAndParseBodyRequest(&request, c); err != nil {
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	router.POST(V2Path+MetadataV2GetPath, verified)
+	router.POST(V2Path+DeleteV2Path, verified)
+	return router
+}
+
+func rateLimitedRequestForTest(router *gin.Engine, path string, post interface{}) *httptest.ResponseRecorder {
+	marshalledReq, _ := json.Marshal(post)
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(marshalledReq))
+	req.Header.Set("Content-Type", gin.MIMEJSON)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// Signatures are deterministic, every request gets its own timestamp so none of them is seen as replayed
+var rateLimitRequestsForTest int64
+
+func signedRateLimitRequestForTest(t *testing.T, privateKey *ecdsa.PrivateKey) getAccountDataReq {
+	rateLimitRequestsForTest++
+	timestamp := time.Now().UnixNano()/int64(time.Millisecond) + rateLimitRequestsForTest
+	v, b := returnValidVerificationAndRequestBody(t, accountGetReqObj{Timestamp: timestamp}, privateKey)
+	return getAccountDataReq{verification: v, requestBody: b}
+}
+
+func Test_RateLimit_Per_Account(t *testing.T) {
+	router := returnRateLimitedEngineForTest(t, 2, 10)
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		w := rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, signedRateLimitRequestForTest(t, privateKey))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	w := rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, signedRateLimitRequestForTest(t, privateKey))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// other groups have their own limits
+	w = rateLimitedRequestForTest(router, V2Path+DeleteV2Path, signedRateLimitRequestForTest(t, privateKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// other accounts too
+	otherPrivateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	w = rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, signedRateLimitRequestForTest(t, otherPrivateKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_RateLimit_Unverified_Requests_Do_Not_Charge_The_Account(t *testing.T) {
+	router := returnRateLimitedEngineForTest(t, 1, 10)
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+
+	// Someone else sending the public key of the account with a bad signature
+	forged := signedRateLimitRequestForTest(t, privateKey)
+	forged.Signature = strings.Repeat("0", 128)
+	for i := 0; i < 3; i++ {
+		w := rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, forged)
+		assert.NotEqual(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, http.StatusTooManyRequests, w.Code)
+	}
+
+	w := rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, signedRateLimitRequestForTest(t, privateKey))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_RateLimit_Per_IP(t *testing.T) {
+	router := returnRateLimitedEngineForTest(t, 1, 2)
+
+	// Rotating public keys does not get around the limit of the IP
+	for i := 0; i < 2; i++ {
+		privateKey, err := utils.GenerateKey()
+		assert.Nil(t, err)
+		w := rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, signedRateLimitRequestForTest(t, privateKey))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	privateKey, err := utils.GenerateKey()
+	assert.Nil(t, err)
+	w := rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, signedRateLimitRequestForTest(t, privateKey))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	w = rateLimitedRequestForTest(router, V2Path+MetadataV2GetPath, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func Test_RateLimit_V3_Download(t *testing.T) {
+	abortIfNotTesting(t)
+	defaultPerMinute, defaultMultiplier := utils.Env.RateLimitDownloadPerMinute, utils.Env.RateLimitPerIPMultiplier
+	utils.Env.RateLimitDownloadPerMinute, utils.Env.RateLimitPerIPMultiplier = 1, 1
+	router := returnEngine()
+	utils.Env.RateLimitDownloadPerMinute, utils.Env.RateLimitPerIPMultiplier = defaultPerMinute, defaultMultiplier
+	router.POST(V3Path+DownloadV3Path, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := rateLimitedRequestForTest(router, V3Path+DownloadV3Path, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = rateLimitedRequestForTest(router, V3Path+DownloadV3Path, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func returnIPRateLimitedEngineForTest(t *testing.T, trustedProxies string) *gin.Engine {
+	abortIfNotTesting(t)
+
+	defaultPerMinute, defaultMultiplier, defaultProxies := utils.Env.RateLimitDownloadPerMinute, utils.Env.RateLimitPerIPMultiplier, utils.Env.TrustedProxies
+	utils.Env.RateLimitDownloadPerMinute, utils.Env.RateLimitPerIPMultiplier, utils.Env.TrustedProxies = 1, 1, trustedProxies
+	defer func() {
+		utils.Env.RateLimitDownloadPerMinute, utils.Env.RateLimitPerIPMultiplier, utils.Env.TrustedProxies = defaultPerMinute, defaultMultiplier, defaultProxies
+	}()
+
+	router := returnEngine()
+	router.POST(V3Path+DownloadV3Path, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func forwardedRequestForTest(router *gin.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, V3Path+DownloadV3Path, nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_RateLimit_Spoofed_Forwarded_For_Does_Not_Change_The_IP(t *testing.T) {
+	router := returnIPRateLimitedEngineForTest(t, "")
+
+	w := forwardedRequestForTest(router, "203.0.113.7:4000", "198.51.100.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = forwardedRequestForTest(router, "203.0.113.7:4000", "198.51.100.2")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func Test_RateLimit_Forwarded_For_From_Trusted_Proxies(t *testing.T) {
+	router := returnIPRateLimitedEngineForTest(t, "10.0.0.0/8, 192.0.2.10")
+
+	// The client behind the proxies is the one limited
+	w := forwardedRequestForTest(router, "10.0.0.5:4000", "198.51.100.1, 192.0.2.10")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = forwardedRequestForTest(router, "10.0.0.5:4000", "198.51.100.2, 192.0.2.10")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// What the client put in front of the header it sent is not trusted
+	w = forwardedRequestForTest(router, "10.0.0.5:4000", "203.0.113.1, 198.51.100.1, 192.0.2.10")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -76,6 +76,9 @@ func GetAccountRenewalInvoiceHandler() gin.HandlerFunc {
 // @description 	"metadataKeys": "["someKey", "someOtherKey]",
 // @description 	"fileHandles": "["someHandle", "someOtherHandle]",
 // @description }
+// @description the node extends the expiration of what the account owns in the background and the response tells how far
+// @description it went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what
+// @description it owned before the node kept track of it
 // @Success 200 {object} routes.StatusRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 404 {string} string "no account with that id: (with your accountID)"
@@ -158,10 +161,6 @@ func checkRenewalStatus(c *gin.Context) error {
 		return err
 	}
 
-	if err := verifyRenewEligible(account, c); err != nil {
-		return err
-	}
-
 	renewals, err := models.GetRenewalsFromAccountID(account.AccountID)
 	if err != nil {
 		return InternalErrorResponse(c, err)
@@ -170,6 +169,19 @@ func checkRenewalStatus(c *gin.Context) error {
 		return NotFoundResponse(c, errors.New("no renewals found"))
 	}
 
+	// The renewal made the account ineligible, the client can still poll how far the propagation went
+	if renewals[0].PaymentStatus >= models.InitialPaymentReceived {
+		if inProgress, err := isExpiryPropagationInProgress(account.AccountID); err != nil {
+			return InternalErrorResponse(c, err)
+		} else if inProgress {
+			return successWithExpiryPropagationResponse(c, account.AccountID)
+		}
+	}
+
+	if err := verifyRenewEligible(account, c); err != nil {
+		return err
+	}
+
 	paid, networkID, err := models.BackendManager.CheckIfPaid(services.StringToAddress(renewals[0].EthAddress),
 		services.ConvertToWeiUnit(big.NewFloat(renewals[0].OpctCost)))
 	if err != nil {
@@ -182,9 +194,7 @@ func checkRenewalStatus(c *gin.Context) error {
 	}
 
 	if renewals[0].PaymentStatus >= models.InitialPaymentReceived {
-		return OkResponse(c, StatusRes{
-			Status: "Success with OPCT",
-		})
+		return successWithExpiryPropagationResponse(c, account.AccountID)
 	}
 
 	if err := models.DB.Model(&renewals[0]).Update("payment_status", models.InitialPaymentReceived).Error; err != nil {
@@ -198,22 +208,13 @@ func checkRenewalStatus(c *gin.Context) error {
 	if err := renewalAccountAndUpdateExpireDates(account, request, c); err != nil {
 		return InternalErrorResponse(c, err)
 	}
-	return OkResponse(c, StatusRes{
-		Status: "Success with OPCT",
-	})
+	return successWithExpiryPropagationResponse(c, account.AccountID)
 }
 
 func renewalAccountAndUpdateExpireDates(account models.Account, request checkRenewalStatusReq, c *gin.Context) error {
 	if err := account.RenewAccount(); err != nil {
 		return err
 	}
-	filesErr := models.UpdateExpiredAt(request.checkRenewalStatusObject.FileHandles,
-		request.verification.PublicKey, account.ExpirationDate())
-
-	// Setting ttls on metadata to 2 months post account expiration date so the metadatas won't
-	// be deleted too soon
-	metadatasErr := updateMetadataExpiration(request.checkRenewalStatusObject.MetadataKeys,
-		request.verification.PublicKey, account.ExpirationDate().Add(MetadataExpirationOffset), c)
-
-	return utils.CollectErrors([]error{filesErr, metadatasErr})
+	return propagateAccountExpiration(account, request.checkRenewalStatusObject.FileHandles,
+		request.checkRenewalStatusObject.MetadataKeys, request.verification.PublicKey, 1, c)
 }
@@ -136,6 +136,101 @@ func Test_CheckRenewalStatusHandler_Returns_Status_OPCT_Renew_Success(t *testing
 	assert.Equal(t, models.InitialPaymentReceived, renewals[0].PaymentStatus)
 }
 
+func Test_CheckRenewalStatusHandler_Propagates_Expiration_Of_Owned_Files(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteRenewalsForTest(t)
+	models.DeleteExpiryPropagationsForTest(t)
+
+	checkRenewalStatusObj := checkRenewalStatusObject{
+		MetadataKeys: []string{},
+		FileHandles:  []string{utils.GenerateFileHandle()},
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, checkRenewalStatusObj)
+
+	checkRenewalStatusReq := checkRenewalStatusReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+
+	account.CreatedAt = time.Now().Add(time.Hour * 24 * 360 * -1)
+	account.PaymentStatus = models.PaymentRetrievalComplete
+	models.DB.Save(&account)
+
+	CreateRenewalForTest(t, account)
+
+	// Only the index knows about this one, the client didn't send it
+	ownedFileHandle := utils.GenerateFileHandle()
+	makeCompletedFileForTest(ownedFileHandle, account.ExpirationDate(), v.PublicKey)
+	assert.Nil(t, models.SetFileOwner(ownedFileHandle, account.AccountID))
+	makeCompletedFileForTest(checkRenewalStatusObj.FileHandles[0], account.ExpirationDate(), v.PublicKey)
+
+	models.BackendManager.CheckIfPaid = func(address common.Address, amount *big.Int) (bool, uint, error) {
+		return true, utils.TestNetworkID, nil
+	}
+
+	w := httpPostRequestHelperForTest(t, AccountRenewPath, "v1", checkRenewalStatusReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"expiryPropagation":{"completed":false`)
+
+	expiryPropagation, err := models.GetExpiryPropagation(account.AccountID)
+	assert.Nil(t, err)
+	done, err := expiryPropagation.PropagateBatch(10)
+	assert.Nil(t, err)
+	assert.True(t, done)
+	// The file sent by the client was added to the index
+	assert.Equal(t, 2, expiryPropagation.FilesUpdated)
+
+	account, err = models.GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	completedFile, err := models.GetCompletedFileByFileID(ownedFileHandle)
+	assert.Nil(t, err)
+	assert.Equal(t, account.ExpirationDate(), completedFile.ExpiredAt)
+	// The client sent everything it has
+	assert.True(t, account.OwnersIndexed)
+}
+
+func Test_CheckRenewalStatusHandler_Unpaid_Renewal_During_Expiry_Propagation(t *testing.T) {
+	models.DeleteAccountsForTest(t)
+	models.DeleteRenewalsForTest(t)
+	models.DeleteExpiryPropagationsForTest(t)
+
+	checkRenewalStatusObj := checkRenewalStatusObject{
+		MetadataKeys: []string{},
+		FileHandles:  []string{},
+	}
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, checkRenewalStatusObj)
+
+	checkRenewalStatusReq := checkRenewalStatusReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	accountID, _ := utils.HashString(v.PublicKey)
+	account := CreatePaidAccountForTest(t, accountID)
+
+	account.CreatedAt = time.Now().Add(time.Hour * 24 * 360 * -1)
+	account.PaymentStatus = models.PaymentRetrievalComplete
+	models.DB.Save(&account)
+
+	CreateRenewalForTest(t, account)
+
+	// Left over from an upgrade, it must not stand for the payment of the renewal
+	assert.Nil(t, models.StartExpiryPropagation(account.AccountID, account.ExpirationDate(), account.ExpirationDate()))
+
+	models.BackendManager.CheckIfPaid = func(address common.Address, amount *big.Int) (bool, uint, error) {
+		return false, utils.TestNetworkID, nil
+	}
+
+	w := httpPostRequestHelperForTest(t, AccountRenewPath, "v1", checkRenewalStatusReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `Incomplete`)
+}
+
 func Test_CheckRenewalStatusHandler_Returns_Status_OPCT_Renew_Still_Pending(t *testing.T) {
 	models.DeleteAccountsForTest(t)
 	models.DeleteRenewalsForTest(t)
@@ -76,6 +76,9 @@ func GetAccountRenewalV2InvoiceHandler() gin.HandlerFunc {
 // @description 	"metadataKeys": "["someKey", "someOtherKey]",
 // @description 	"fileHandles": "["someHandle", "someOtherHandle]",
 // @description }
+// @description the node extends the expiration of what the account owns in the background and the response tells how far
+// @description it went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what
+// @description it owned before the node kept track of it
 // @Success 200 {object} routes.StatusRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 404 {string} string "no account with that id: (with your accountID)"
@@ -158,10 +161,6 @@ func checkRenewalV2Status(c *gin.Context) error {
 		return err
 	}
 
-	if err := verifyRenewEligible(account, c); err != nil {
-		return err
-	}
-
 	renewalV2s, err := models.GetRenewalsFromAccountID(account.AccountID)
 	if err != nil {
 		return InternalErrorResponse(c, err)
@@ -170,6 +169,19 @@ func checkRenewalV2Status(c *gin.Context) error {
 		return NotFoundResponse(c, errors.New("no renewalV2s found"))
 	}
 
+	// The renewal made the account ineligible, the client can still poll how far the propagation went
+	if renewalV2s[0].PaymentStatus >= models.InitialPaymentReceived {
+		if inProgress, err := isExpiryPropagationInProgress(account.AccountID); err != nil {
+			return InternalErrorResponse(c, err)
+		} else if inProgress {
+			return successWithExpiryPropagationResponse(c, account.AccountID)
+		}
+	}
+
+	if err := verifyRenewEligible(account, c); err != nil {
+		return err
+	}
+
 	paid, networkID, err := models.BackendManager.CheckIfPaid(services.StringToAddress(renewalV2s[0].EthAddress),
 		services.ConvertToWeiUnit(big.NewFloat(renewalV2s[0].OpctCost)))
 	if err != nil {
@@ -182,9 +194,7 @@ func checkRenewalV2Status(c *gin.Context) error {
 	}
 
 	if renewalV2s[0].PaymentStatus >= models.InitialPaymentReceived {
-		return OkResponse(c, StatusRes{
-			Status: "Success with OPCT",
-		})
+		return successWithExpiryPropagationResponse(c, account.AccountID)
 	}
 
 	if err := models.DB.Model(&renewalV2s[0]).Update("payment_status", models.InitialPaymentReceived).Error; err != nil {
@@ -196,22 +206,13 @@ func checkRenewalV2Status(c *gin.Context) error {
 	if err := renewalV2AccountAndUpdateExpireDates(account, request, c); err != nil {
 		return InternalErrorResponse(c, err)
 	}
-	return OkResponse(c, StatusRes{
-		Status: "Success with OPCT",
-	})
+	return successWithExpiryPropagationResponse(c, account.AccountID)
 }
 
 func renewalV2AccountAndUpdateExpireDates(account models.Account, request checkRenewalV2StatusReq, c *gin.Context) error {
 	if err := account.RenewAccount(); err != nil {
 		return err
 	}
-	filesErr := models.UpdateExpiredAt(request.checkRenewalV2StatusObject.FileHandles,
-		request.verification.PublicKey, account.ExpirationDate())
-
-	// Setting ttls on metadata to 2 months post account expiration date so the metadatas won't
-	// be deleted too soon
-	metadatasErr := updateMetadataExpirationV2(request.checkRenewalV2StatusObject.MetadataKeys,
-		request.verification.PublicKey, account.ExpirationDate().Add(MetadataExpirationOffset), c)
-
-	return utils.CollectErrors([]error{filesErr, metadatasErr})
+	return propagateAccountExpiration(account, request.checkRenewalV2StatusObject.FileHandles,
+		request.checkRenewalV2StatusObject.MetadataKeys, request.verification.PublicKey, 2, c)
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
 
 	"time"
@@ -63,6 +65,16 @@ func ServiceUnavailableResponse(c *gin.Context, err error) error {
 	return err
 }
 
+func TooManyRequestsResponse(c *gin.Context, retryAfter time.Duration) error {
+	retryAfterSeconds := int(math.Ceil(retryAfter.Seconds()))
+	err := fmt.Errorf("too many requests, retry in %d seconds", retryAfterSeconds)
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, err.Error())
+	utils.Metrics_429_Response_Counter.Inc()
+
+	return err
+}
+
 func AccountNotFoundResponse(c *gin.Context, id string) error {
 	return NotFoundResponse(c, fmt.Errorf(noAccountWithThatID+": %s", id))
 }
@@ -109,6 +121,12 @@ func NotFoundResponse(c *gin.Context, err error) error {
 	return err
 }
 
+func GoneResponse(c *gin.Context, err error) error {
+	c.AbortWithStatusJSON(http.StatusGone, err.Error())
+	utils.Metrics_410_Response_Counter.Inc()
+	return err
+}
+
 func OkResponse(c *gin.Context, response interface{}) error {
 	if err := utils.Validator.Struct(response); err != nil {
 		err = fmt.Errorf("could not create a valid response:  %v", err)
@@ -114,6 +114,9 @@ const (
 	/*DownloadPublicV2Path is the path for downloading public files*/
 	DownloadPublicV2Path = "/download/public"
 
+	/*DownloadStreamV2Path is the path for downloading files through the node*/
+	DownloadStreamV2Path = "/download/stream"
+
 	/*MetadataV2GetPath is the path for getting metadata*/
 	MetadataV2GetPath = "/metadata/get"
 
@@ -144,6 +147,12 @@ const (
 	/*UploadStatusPublicPath is the path for checking upload status*/
 	UploadStatusPublicPath = "/upload-status-public"
 
+	/*InitUploadDirectPath is the path for initiating uploads sent straight to the bucket*/
+	InitUploadDirectPath = "/init-upload-direct"
+
+	/*UploadCompleteDirectPath is the path for completing uploads sent straight to the bucket*/
+	UploadCompleteDirectPath = "/upload-complete-direct"
+
 	/*PublicSharePathPrefix is the base path public shared files*/
 	PublicSharePathPrefix = "public-share"
 
@@ -162,11 +171,58 @@ const (
 	/*PublicShareRevokePath is the path for revoking the share of a public file*/
 	PublicShareRevokePath = "/revoke"
 
+	/*PublicSharePasswordPath is the path for setting or removing the password of a public share*/
+	PublicSharePasswordPath = "/password"
+
+	/*PublicShareUnlockPath is the path for getting an access token of a password protected public share*/
+	PublicShareUnlockPath = "/unlock"
+
 	/*DeletePath is the path for deleting files, allowing multiple deletions*/
 	DeleteV2Path = "/delete"
 
 	/*SmartContractsV2Path is the path for getting smart contracts addresses and related data*/
 	SmartContractsV2Path = "/smart-contracts"
+
+	/*SessionV2Path is the path for creating and revoking session tokens*/
+	SessionV2Path = "/auth/session"
+
+	/*TusPathPrefix is the base path for tus resumable uploads*/
+	TusPathPrefix = "tus"
+
+	/*TusUploadPath is the path of a tus upload*/
+	TusUploadPath = "/:fileHandle"
+
+	/*DelegatedKeysV2PathPrefix is the base path for managing the delegated keys of an account*/
+	DelegatedKeysV2PathPrefix = "delegated-keys"
+
+	/*DelegatedKeyAddPath is the path for registering a delegated key*/
+	DelegatedKeyAddPath = "/add"
+
+	/*DelegatedKeysListPath is the path for listing the delegated keys of an account*/
+	DelegatedKeysListPath = "/list"
+
+	/*DelegatedKeyRemovePath is the path for removing a delegated key*/
+	DelegatedKeyRemovePath = "/remove"
+
+	/*FilesListV2Path is the path for listing the files of an account*/
+	FilesListV2Path = "/files/list"
+
+	/*TrashListV2Path is the path for listing the files in the trash bin of an account*/
+	TrashListV2Path = "/trash/list"
+
+	/*TrashRestoreV2Path is the path for taking files out of the trash bin*/
+	TrashRestoreV2Path = "/trash/restore"
+
+	/*V3Path is a router group for the v3 version of storage node*/
+	V3Path = "/api/v3"
+
+	/*DownloadV3Path is the path for getting presigned URLs of the objects of a file*/
+	DownloadV3Path = "/download/private"
+)
+
+const (
+	/*LocalBlobPath is the path the local blob store serves public objects from and takes presigned parts on*/
+	LocalBlobPath = "/blobs"
 )
 
 const MaxRequestSize = utils.MaxMultiPartSize + 1000
@@ -176,6 +232,8 @@ var errMaintenance = errors.New("maintenance in progress, currently rejecting wr
 // StatusRes ...
 type StatusRes struct {
 	Status string `json:"status" example:"status of the request"`
+	/*ExpiryPropagation is how far the expiration of a renewal or upgrade was carried to the files and metadatas*/
+	ExpiryPropagation *expiryPropagationRes `json:"expiryPropagation,omitempty"`
 }
 
 // PlanResponse ...
@@ -194,8 +252,13 @@ func CreateRoutes() {
 
 	setupV1Paths(returnV1Group(router))
 	setupV2Paths(returnV2Group(router))
+	setupV3Paths(returnV3Group(router))
 	setupAdminPaths(router)
 
+	if utils.Env.BlobStoreBackend == utils.BlobStoreBackendLocal {
+		setupLocalBlobPaths(router)
+	}
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Listen and Serve
@@ -205,6 +268,9 @@ func CreateRoutes() {
 
 func returnEngine() *gin.Engine {
 	router := gin.Default()
+	// gin trusts X-Forwarded-For from anyone by default
+	router.TrustedProxies = getTrustedProxies()
+	router.ForwardedByClientIP = len(router.TrustedProxies) > 0
 	if utils.Env.GoEnv == "production" || utils.Env.GoEnv == "dev2" {
 		router.Use(sentrygin.New(sentrygin.Options{
 			Repanic: false,
@@ -214,8 +280,11 @@ func returnEngine() *gin.Engine {
 
 	// TODO:  update to only allow our frontend and localhost
 	config.AllowAllOrigins = true
-	config.AllowHeaders = append(config.AllowHeaders, "sentry-trace")
+	config.AllowHeaders = append(config.AllowHeaders, "sentry-trace", "Authorization", PublicShareAccessTokenHeader)
+	config.AllowHeaders = append(config.AllowHeaders, tusRequestHeaders...)
+	config.ExposeHeaders = append(config.ExposeHeaders, tusResponseHeaders...)
 	router.Use(cors.New(config))
+	router.Use(rateLimitMiddleware())
 
 	// Test app is running
 	router.GET("/", func(c *gin.Context) {
@@ -239,6 +308,10 @@ func returnV2Group(router *gin.Engine) *gin.RouterGroup {
 	return router.Group(V2Path)
 }
 
+func returnV3Group(router *gin.Engine) *gin.RouterGroup {
+	return router.Group(V3Path)
+}
+
 func setupV1Paths(v1Router *gin.RouterGroup) {
 	v1Router.POST(AccountsPath, CreateAccountHandler())
 	v1Router.POST(AccountDataPath, CheckAccountPaymentStatusHandler())
@@ -285,10 +358,14 @@ func setupV2Paths(v2Router *gin.RouterGroup) {
 	v2Router.POST(UploadPublicPath, UploadFilePublicHandler())
 	v2Router.POST(UploadStatusPublicPath, CheckUploadStatusPublicHandler())
 
+	v2Router.POST(InitUploadDirectPath, InitFileUploadDirectHandler())
+	v2Router.POST(UploadCompleteDirectPath, CompleteFileUploadDirectHandler())
+
 	v2Router.POST(AccountUpdateApiVersion, AccountUpdateApiVersionHandler())
 
 	v2Router.POST(DownloadV2Path, DownloadFileHandler())
 	v2Router.POST(DownloadPublicV2Path, DownloadPublicFileHandler())
+	v2Router.POST(DownloadStreamV2Path, DownloadStreamHandler())
 
 	publicShareRouterGroup := v2Router.Group(PublicSharePathPrefix)
 	publicShareRouterGroup.GET(PublicShareShortlinkPath, ShortlinkFileHandler())
@@ -296,10 +373,41 @@ func setupV2Paths(v2Router *gin.RouterGroup) {
 	publicShareRouterGroup.POST(CreateShortLinkPath, CreateShortlinkHandler())
 	publicShareRouterGroup.POST(PublicShareViewsCountPath, ViewsCountHandler())
 	publicShareRouterGroup.POST(PublicShareRevokePath, RevokePublicShareHandler())
+	publicShareRouterGroup.POST(PublicSharePasswordPath, SetPublicSharePasswordHandler())
+	publicShareRouterGroup.POST(PublicShareUnlockPath, UnlockPublicShareHandler())
 
 	v2Router.POST(DeleteV2Path, DeleteFilesHandler())
+	v2Router.POST(TrashListV2Path, ListTrashHandler())
+	v2Router.POST(TrashRestoreV2Path, RestoreFilesHandler())
 
 	v2Router.GET(SmartContractsV2Path, SmartContractsHandler())
+
+	v2Router.POST(SessionV2Path, CreateSessionHandler())
+	v2Router.DELETE(SessionV2Path, RevokeSessionHandler())
+
+	tusRouterGroup := v2Router.Group(TusPathPrefix, tusMiddleware())
+	tusRouterGroup.OPTIONS("", TusOptionsHandler())
+	tusRouterGroup.POST("", TusCreateHandler())
+	tusRouterGroup.HEAD(TusUploadPath, TusHeadHandler())
+	tusRouterGroup.PATCH(TusUploadPath, TusPatchHandler())
+	tusRouterGroup.DELETE(TusUploadPath, TusDeleteHandler())
+
+	delegatedKeysRouterGroup := v2Router.Group(DelegatedKeysV2PathPrefix)
+	delegatedKeysRouterGroup.POST(DelegatedKeyAddPath, AddDelegatedKeyHandler())
+	delegatedKeysRouterGroup.POST(DelegatedKeysListPath, ListDelegatedKeysHandler())
+	delegatedKeysRouterGroup.POST(DelegatedKeyRemovePath, RemoveDelegatedKeyHandler())
+
+	v2Router.POST(FilesListV2Path, ListFilesHandler())
+}
+
+func setupV3Paths(v3Router *gin.RouterGroup) {
+	v3Router.POST(DownloadV3Path, DownloadFileV3Handler())
+}
+
+func setupLocalBlobPaths(router *gin.Engine) {
+	router.GET(LocalBlobPath+"/*key", LocalBlobHandler())
+	router.HEAD(LocalBlobPath+"/*key", LocalBlobHandler())
+	router.PUT(LocalBlobPath+"/*key", LocalBlobUploadPartHandler())
 }
 
 func setupAdminPaths(router *gin.Engine) {
@@ -322,6 +430,10 @@ func setupAdminPaths(router *gin.Engine) {
 
 	setupAdminPlansPaths(g)
 	setupAdminSmartContractPaths(g)
+	setupAdminKvBackupPaths(g)
+	g.GET("/integrity", AdminIntegrityReportsHandler())
+	g.GET("/usage/:accountID", AdminAccountUsageGetHandler())
+	g.POST("/usage/:accountID", AdminAccountUsageFixHandler())
 
 	// Load template file location relative to the current working directory
 	// Unable to find the file.
@@ -367,6 +479,16 @@ func setupAdminSmartContractPaths(adminGroup *gin.RouterGroup) {
 	smartContractGroup.POST("/add", AdminSmartContractAddHandler())
 }
 
+func setupAdminKvBackupPaths(adminGroup *gin.RouterGroup) {
+	kvBackupGroup := adminGroup.Group("/kv-backups")
+
+	kvBackupGroup.GET("", AdminKvBackupsListHandler())
+	kvBackupGroup.POST("", AdminKvBackupCreateHandler())
+	kvBackupGroup.GET("/export", AdminKvBackupExportHandler())
+	kvBackupGroup.POST("/restore", AdminKvBackupRestoreHandler())
+	kvBackupGroup.POST("/restore-file", AdminKvBackupRestoreFileHandler())
+}
+
 // GetPlansHandler godoc
 // @Summary get the plans we sell
 // @Description get the plans we sell
@@ -296,6 +296,10 @@ func httpPostFormRequestHelperForTest(t *testing.T, path string, post interface{
 		v2 := returnV2Group(router)
 		setupV2Paths(v2)
 		basePath = v2.BasePath()
+	case "v3":
+		v3 := returnV3Group(router)
+		setupV3Paths(v3)
+		basePath = v3.BasePath()
 	default:
 		assert.Fail(t, "could not init router")
 	}
@@ -317,6 +321,11 @@ func httpPostFormRequestHelperForTest(t *testing.T, path string, post interface{
 }
 
 func httpPostRequestHelperForTest(t *testing.T, path, routerVersion string, post interface{}) *httptest.ResponseRecorder {
+	return httpPostRequestWithHeadersHelperForTest(t, path, routerVersion, post, nil)
+}
+
+func httpPostRequestWithHeadersHelperForTest(t *testing.T, path, routerVersion string, post interface{},
+	headers map[string]string) *httptest.ResponseRecorder {
 	abortIfNotTesting(t)
 
 	router := returnEngine()
@@ -330,6 +339,10 @@ func httpPostRequestHelperForTest(t *testing.T, path, routerVersion string, post
 		v2 := returnV2Group(router)
 		setupV2Paths(v2)
 		basePath = v2.BasePath()
+	case "v3":
+		v3 := returnV3Group(router)
+		setupV3Paths(v3)
+		basePath = v3.BasePath()
 	default:
 		assert.Fail(t, "could not init router")
 	}
@@ -345,6 +358,9 @@ func httpPostRequestHelperForTest(t *testing.T, path, routerVersion string, post
 	if err != nil {
 		assert.Fail(t, "Couldn't create request: %v\n", err)
 	}
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
 
 	// Create a response recorder so you can inspect the response
 	w := httptest.NewRecorder()
@@ -356,6 +372,11 @@ func httpPostRequestHelperForTest(t *testing.T, path, routerVersion string, post
 }
 
 func httpGetRequestHelperForTest(t *testing.T, path, routerVersion string, params map[string]string) *httptest.ResponseRecorder {
+	return httpGetRequestWithHeadersHelperForTest(t, path, routerVersion, params, nil)
+}
+
+func httpGetRequestWithHeadersHelperForTest(t *testing.T, path, routerVersion string, params map[string]string,
+	headers map[string]string) *httptest.ResponseRecorder {
 	abortIfNotTesting(t)
 
 	router := returnEngine()
@@ -369,6 +390,10 @@ func httpGetRequestHelperForTest(t *testing.T, path, routerVersion string, param
 		v2 := returnV2Group(router)
 		setupV2Paths(v2)
 		basePath = v2.BasePath()
+	case "v3":
+		v3 := returnV3Group(router)
+		setupV3Paths(v3)
+		basePath = v3.BasePath()
 	default:
 		assert.Fail(t, "could not init router")
 	}
@@ -386,6 +411,9 @@ func httpGetRequestHelperForTest(t *testing.T, path, routerVersion string, param
 	if err != nil {
 		assert.Fail(t, "Couldn't create request: %v\n", err)
 	}
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
 
 	// Create a response recorder so you can inspect the response
 	w := httptest.NewRecorder()
@@ -0,0 +1,224 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	cmap "github.com/orcaman/concurrent-map"
+)
+
+const (
+	sessionTokenSizeInBytes = 32
+	bearerPrefix            = "Bearer "
+
+	sessionTokenInvalidResponse = "session token is invalid, expired or revoked"
+
+	// Set once the session token of the request was verified
+	sessionKeyContextKey = "sessionKey"
+
+	// How long the account of a session is served from memory before it is loaded again
+	sessionAccountMaxAge = time.Minute
+	// Past that many cached accounts, the stale ones are dropped
+	sessionAccountsMaxCount = 10000
+)
+
+var errSessionTokenInvalid = errors.New(sessionTokenInvalidResponse)
+
+/*sessionAccounts keeps the account of each session this node verified recently, so requests sent
+with a session token don't load the account from the database every time*/
+var sessionAccounts = cmap.New()
+
+type sessionAccount struct {
+	account  models.Account
+	loadedAt time.Time
+}
+
+type sessionCreateObj struct {
+	Timestamp int64 `json:"timestamp" validate:"required"`
+}
+
+type sessionCreateReq struct {
+	verification
+	requestBody
+	sessionCreateObj sessionCreateObj
+}
+
+type sessionCreateRes struct {
+	Token     string    `json:"token" example:"a 64 character hex string to send as 'Authorization: Bearer <token>'"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (v *sessionCreateReq) getObjectRef() interface{} {
+	return &v.sessionCreateObj
+}
+
+// CreateSessionHandler godoc
+// @Summary create a session token
+// @Description exchange a signed request for a short-lived bearer token. Requests sent with an
+// @Description "Authorization: Bearer <token>" header skip the signature check, they still need the publicKey
+// @Description the token was issued for and a requestBody.
+// @Accept  json
+// @Produce  json
+// @Param sessionCreateReq body routes.sessionCreateReq true "session creation object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.sessionCreateRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Router /api/v2/auth/session [post]
+/*CreateSessionHandler is a handler for exchanging a signed request for a session token*/
+func CreateSessionHandler() gin.HandlerFunc {
+	return ginHandlerFunc(createSession)
+}
+
+// RevokeSessionHandler godoc
+// @Summary revoke a session token
+// @Description revoke the session token sent in the "Authorization: Bearer <token>" header
+// @Produce  json
+// @Success 200 {object} routes.StatusRes
+// @Failure 403 {string} string "session token is invalid, expired or revoked"
+// @Router /api/v2/auth/session [delete]
+/*RevokeSessionHandler is a handler for revoking a session token before it expires*/
+func RevokeSessionHandler() gin.HandlerFunc {
+	return ginHandlerFunc(revokeSession)
+}
+
+func createSession(c *gin.Context) error {
+	// A session must not be able to extend itself
+	if getBearerToken(c) != "" {
+		return BadRequestResponse(c, errors.New("sign the request to create a session, without an Authorization header"))
+	}
+
+	request := sessionCreateReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	if _, err := request.getAccount(c); err != nil {
+		return err
+	}
+
+	tokenBytes := make([]byte, sessionTokenSizeInBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	ttl := time.Duration(utils.Env.SessionTokenTTLMinutes) * time.Minute
+	if err := utils.BatchSet(&utils.KVPairs{getSessionKeyForBadger(token): request.PublicKey}, ttl); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	return OkResponse(c, sessionCreateRes{
+		Token:     token,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+}
+
+func revokeSession(c *gin.Context) error {
+	token := getBearerToken(c)
+	if _, err := getSessionPublicKey(token); err == errSessionTokenInvalid {
+		return ForbiddenResponse(c, err)
+	} else if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	sessionKey := getSessionKeyForBadger(token)
+	if err := utils.BatchDelete(&utils.KVKeys{sessionKey}); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	sessionAccounts.Remove(sessionKey)
+
+	return OkResponse(c, StatusRes{Status: "session revoked"})
+}
+
+func getBearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix))
+}
+
+/*getSessionPublicKey returns the public key the session token was issued for*/
+func getSessionPublicKey(token string) (string, error) {
+	if len(token) != 2*sessionTokenSizeInBytes {
+		return "", errSessionTokenInvalid
+	}
+
+	publicKey, _, err := utils.GetValueFromKV(getSessionKeyForBadger(token))
+	if err == utils.ErrKeyNotFound {
+		return "", errSessionTokenInvalid
+	}
+	return publicKey, err
+}
+
+/*verifySessionToken checks the request is sent for the public key the token was issued for*/
+func verifySessionToken(token string, verificationData verification, c *gin.Context) error {
+	publicKey, err := getSessionPublicKey(token)
+	if err != nil {
+		if err == errSessionTokenInvalid {
+			return ForbiddenResponse(c, err)
+		}
+		return InternalErrorResponse(c, err)
+	}
+
+	if publicKey != verificationData.PublicKey {
+		return ForbiddenResponse(c, errSessionTokenInvalid)
+	}
+	c.Set(sessionKeyContextKey, getSessionKeyForBadger(token))
+	return nil
+}
+
+/*getSessionAccount returns the account cached for the verified session token of the request. Changes to
+the account show up once the cached copy is older than sessionAccountMaxAge.*/
+func getSessionAccount(c *gin.Context) (models.Account, bool) {
+	sessionKey := c.GetString(sessionKeyContextKey)
+	if sessionKey == "" {
+		return models.Account{}, false
+	}
+	cached, ok := sessionAccounts.Get(sessionKey)
+	if !ok {
+		return models.Account{}, false
+	}
+	entry := cached.(sessionAccount)
+	if time.Since(entry.loadedAt) > sessionAccountMaxAge {
+		sessionAccounts.Remove(sessionKey)
+		return models.Account{}, false
+	}
+	return entry.account, true
+}
+
+/*setSessionAccount caches the account loaded for the verified session token of the request*/
+func setSessionAccount(c *gin.Context, account models.Account) {
+	sessionKey := c.GetString(sessionKeyContextKey)
+	if sessionKey == "" {
+		return
+	}
+	if sessionAccounts.Count() >= sessionAccountsMaxCount {
+		removeStaleSessionAccounts()
+	}
+	sessionAccounts.Set(sessionKey, sessionAccount{account: account, loadedAt: time.Now()})
+}
+
+func removeStaleSessionAccounts() {
+	for item := range sessionAccounts.IterBuffered() {
+		if time.Since(item.Val.(sessionAccount).loadedAt) > sessionAccountMaxAge {
+			sessionAccounts.Remove(item.Key)
+		}
+	}
+}
+
+func getSessionKeyForBadger(token string) string {
+	// Only a hash of the token is stored, a dump of the kv store can't be used to impersonate anyone
+	return utils.HashStringV2([]byte(token)) + "_session"
+}
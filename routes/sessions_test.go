@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Sessions(t *testing.T) {
+	utils.SetTesting("../.env")
+	models.Connect(utils.Env.DatabaseURL)
+	gin.SetMode(gin.TestMode)
+}
+
+func createSessionForTest(t *testing.T) (string, verification) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	v, b := returnValidVerificationAndRequestBody(t, sessionCreateObj{Timestamp: time.Now().Unix()}, privateKey)
+	post := sessionCreateReq{
+		verification: v,
+		requestBody:  b,
+	}
+
+	w := httpPostRequestHelperForTest(t, SessionV2Path, "v2", post)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := sessionCreateRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, 2*sessionTokenSizeInBytes, len(res.Token))
+	assert.True(t, res.ExpiresAt.After(time.Now()))
+
+	return res.Token, v
+}
+
+func httpRequestWithSessionForTest(t *testing.T, method, path string, post interface{}, token string) *httptest.ResponseRecorder {
+	abortIfNotTesting(t)
+
+	router := returnEngine()
+	setupV1Paths(returnV1Group(router))
+	setupV2Paths(returnV2Group(router))
+
+	marshalledReq, _ := json.Marshal(post)
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(marshalledReq))
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", bearerPrefix+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_Session_Token_Replaces_Signature(t *testing.T) {
+	token, v := createSessionForTest(t)
+
+	b := returnValidRequestBodyWithoutVerification(t, accountGetReqObj{Timestamp: time.Now().Unix()})
+	post := getAccountDataReq{
+		verification: verification{PublicKey: v.PublicKey},
+		requestBody:  b,
+	}
+
+	w := httpRequestWithSessionForTest(t, http.MethodPost, V1Path+AccountDataPath, post, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The token is not single use
+	w = httpRequestWithSessionForTest(t, http.MethodPost, V1Path+AccountDataPath, post, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_Session_Token_Bound_To_Public_Key(t *testing.T) {
+	token, _ := createSessionForTest(t)
+	_, otherV := createSessionForTest(t)
+
+	b := returnValidRequestBodyWithoutVerification(t, accountGetReqObj{Timestamp: time.Now().Unix()})
+	post := getAccountDataReq{
+		verification: verification{PublicKey: otherV.PublicKey},
+		requestBody:  b,
+	}
+
+	w := httpRequestWithSessionForTest(t, http.MethodPost, V1Path+AccountDataPath, post, token)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), sessionTokenInvalidResponse)
+}
+
+func Test_Session_Revoke(t *testing.T) {
+	token, v := createSessionForTest(t)
+
+	w := httpRequestWithSessionForTest(t, http.MethodDelete, V2Path+SessionV2Path, nil, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	b := returnValidRequestBodyWithoutVerification(t, accountGetReqObj{Timestamp: time.Now().Unix()})
+	post := getAccountDataReq{
+		verification: verification{PublicKey: v.PublicKey},
+		requestBody:  b,
+	}
+	w = httpRequestWithSessionForTest(t, http.MethodPost, V1Path+AccountDataPath, post, token)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httpRequestWithSessionForTest(t, http.MethodDelete, V2Path+SessionV2Path, nil, token)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Session_Cannot_Be_Created_With_A_Session(t *testing.T) {
+	token, v := createSessionForTest(t)
+
+	b := returnValidRequestBodyWithoutVerification(t, sessionCreateObj{Timestamp: time.Now().Unix()})
+	post := sessionCreateReq{
+		verification: verification{PublicKey: v.PublicKey},
+		requestBody:  b,
+	}
+
+	w := httpRequestWithSessionForTest(t, http.MethodPost, V2Path+SessionV2Path, post, token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func Test_Session_Account_Is_Cached(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	account := models.Account{AccountID: utils.RandSeqFromRunes(64, []rune("abcdef01234567890"))}
+
+	// Requests without a session token are not cached
+	setSessionAccount(c, account)
+	_, ok := getSessionAccount(c)
+	assert.False(t, ok)
+
+	c.Set(sessionKeyContextKey, getSessionKeyForBadger(utils.RandHexString(2*sessionTokenSizeInBytes)))
+	setSessionAccount(c, account)
+	cached, ok := getSessionAccount(c)
+	assert.True(t, ok)
+	assert.Equal(t, account.AccountID, cached.AccountID)
+
+	// A stale copy is loaded again
+	sessionAccounts.Set(c.GetString(sessionKeyContextKey), sessionAccount{account: account, loadedAt: time.Now().Add(-2 * sessionAccountMaxAge)})
+	_, ok = getSessionAccount(c)
+	assert.False(t, ok)
+}
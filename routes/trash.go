@@ -0,0 +1,181 @@
+package routes
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+type trashListReq struct {
+	verification
+	requestBody
+	filesListObj filesListObj
+}
+
+type trashedFile struct {
+	listedFile
+	TrashedAt int64 `json:"trashedAt" example:"1557346389"`
+	/*PurgeAt is when the file is deleted for good, unless restored before*/
+	PurgeAt int64 `json:"purgeAt" example:"1559938389"`
+}
+
+type trashListRes struct {
+	Files []trashedFile `json:"files"`
+	/*NextAfter is the after of the next page, empty on the last page*/
+	NextAfter string `json:"nextAfter,omitempty" example:"the last file handle of this page"`
+}
+
+type restoreFilesObj struct {
+	FileIDs []string `json:"fileIDs" validate:"required,min=1" example:"the handles of the files"`
+}
+
+type restoreFilesReq struct {
+	verification
+	requestBody
+	restoreFilesObj restoreFilesObj
+}
+
+type restoreFilesRes struct {
+	UnsuccessfulRestores map[string]string `json:"unsuccessfulRestores"`
+}
+
+func (v *trashListReq) getObjectRef() interface{} {
+	return &v.filesListObj
+}
+
+func (v *restoreFilesReq) getObjectRef() interface{} {
+	return &v.restoreFilesObj
+}
+
+// ListTrashHandler godoc
+// @Summary list the trash bin of an account
+// @Description list the deleted files of an account that can still be restored, by file handle. Pass the
+// @Description nextAfter of a page as after to get the next one.
+// @Accept  json
+// @Produce  json
+// @Param trashListReq body routes.trashListReq true "trash list object"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"after": "the last file handle of the previous page, optional",
+// @description 	"limit": 100,
+// @description 	"timestamp": 1557346389
+// @description }
+// @Success 200 {object} routes.trashListRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/trash/list [post]
+/*ListTrashHandler is a handler for listing the files in the trash bin of an account*/
+func ListTrashHandler() gin.HandlerFunc {
+	return ginHandlerFunc(listTrash)
+}
+
+// RestoreFilesHandler godoc
+// @Summary restore files from the trash bin
+// @Description take deleted files out of the trash bin before they are purged. Their public shares are not restored.
+// @Accept  json
+// @Produce  json
+// @Param restoreFilesReq body routes.restoreFilesReq true "restore files object"
+// @description requestBody should be a stringified version of:
+// @description {
+// @description 	"fileIDs": [],
+// @description }
+// @Success 200 {object} routes.restoreFilesRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no account with that id: (with your accountID)"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/trash/restore [post]
+/*RestoreFilesHandler is a handler for taking files out of the trash bin*/
+func RestoreFilesHandler() gin.HandlerFunc {
+	return ginHandlerFunc(restoreFiles)
+}
+
+func listTrash(c *gin.Context) error {
+	request := trashListReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	limit := request.filesListObj.Limit
+	if limit == 0 {
+		limit = filesListDefaultLimit
+	}
+
+	completedFiles, err := models.GetTrashedCompletedFilesByOwner(account.AccountID, request.filesListObj.After, limit)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	retention := time.Duration(utils.Env.TrashRetentionDays) * 24 * time.Hour
+	res := trashListRes{Files: []trashedFile{}}
+	for _, completedFile := range completedFiles {
+		res.Files = append(res.Files, trashedFile{
+			listedFile: listedFile{
+				FileHandle:     completedFile.FileID,
+				FileSizeInByte: completedFile.FileSizeInByte,
+				CreatedAt:      completedFile.CreatedAt.Unix(),
+				ExpiredAt:      completedFile.ExpiredAt.Unix(),
+			},
+			TrashedAt: completedFile.TrashedAt.Unix(),
+			PurgeAt:   completedFile.TrashedAt.Add(retention).Unix(),
+		})
+	}
+	if len(completedFiles) == limit {
+		res.NextAfter = completedFiles[len(completedFiles)-1].FileID
+	}
+
+	return OkResponse(c, res)
+}
+
+func restoreFiles(c *gin.Context) error {
+	if !utils.WritesEnabled() {
+		return ServiceUnavailableResponse(c, errMaintenance)
+	}
+
+	request := restoreFilesReq{}
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	if _, err := request.getAccount(c); err != nil {
+		return err
+	}
+
+	res := restoreFilesRes{
+		UnsuccessfulRestores: make(map[string]string),
+	}
+	for _, fileID := range request.restoreFilesObj.FileIDs {
+		if err := restoreFileByID(fileID, request.PublicKey, c); err != nil {
+			res.UnsuccessfulRestores[fileID] = err.Error()
+		}
+	}
+
+	return OkResponse(c, res)
+}
+
+func restoreFileByID(fileID, publicKey string, c *gin.Context) error {
+	completedFile, err := models.GetCompletedFileByFileID(fileID)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyPermissions(publicKey, fileID, completedFile.ModifierHash, c); err != nil {
+		return err
+	}
+
+	if completedFile.TrashedAt == nil {
+		return errors.New("file is not in the trash bin")
+	}
+
+	return completedFile.Restore()
+}
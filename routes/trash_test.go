@@ -0,0 +1,104 @@
+package routes
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Trash(t *testing.T) {
+	setupTests(t)
+}
+
+func listTrashForTest(t *testing.T, privateKey *ecdsa.PrivateKey) trashListRes {
+	v, b := returnValidVerificationAndRequestBody(t, filesListObj{Timestamp: time.Now().Unix()}, privateKey)
+	w := httpPostRequestHelperForTest(t, TrashListV2Path, "v2", trashListReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := trashListRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return res
+}
+
+func restoreFilesForTest(t *testing.T, fileIDs []string, privateKey *ecdsa.PrivateKey) restoreFilesRes {
+	v, b := returnValidVerificationAndRequestBody(t, restoreFilesObj{FileIDs: fileIDs}, privateKey)
+	w := httpPostRequestHelperForTest(t, TrashRestoreV2Path, "v2", restoreFilesReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := restoreFilesRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return res
+}
+
+func Test_Delete_Moves_File_To_Trash_And_Restore(t *testing.T) {
+	cleanUpBeforeTest(t)
+	defer setTrashRetentionDaysForTest(30)()
+	account, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	fileID := fileIDs[0]
+
+	v, b := returnValidVerificationAndRequestBody(t, deleteFilesObj{FileIDs: fileIDs}, privateKey)
+	w := httpPostRequestHelperForTest(t, DeleteV2Path, "v2", deleteFilesReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"unsuccessfulDeletions":{}`)
+
+	// The file keeps its objects and its storage until it is purged
+	updatedAccount, err := models.GetAccountById(account.AccountID)
+	assert.Nil(t, err)
+	assert.Equal(t, account.StorageUsedInByte, updatedAccount.StorageUsedInByte)
+	assert.True(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(fileID)))
+	completedFile, err := models.GetCompletedFileByFileID(fileID)
+	assert.Nil(t, err)
+	assert.NotNil(t, completedFile.TrashedAt)
+
+	trash := listTrashForTest(t, privateKey)
+	assert.Len(t, trash.Files, 1)
+	assert.Equal(t, fileID, trash.Files[0].FileHandle)
+	assert.Equal(t, completedFile.TrashedAt.Add(30*24*time.Hour).Unix(), trash.Files[0].PurgeAt)
+	assert.Len(t, listFilesForTest(t, "", 10, privateKey).Files, 0)
+
+	w = httpPostRequestHelperForTest(t, DownloadPath, "v1", DownloadFileObj{FileID: fileID})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	res := restoreFilesForTest(t, fileIDs, privateKey)
+	assert.Equal(t, map[string]string{}, res.UnsuccessfulRestores)
+	completedFile, err = models.GetCompletedFileByFileID(fileID)
+	assert.Nil(t, err)
+	assert.Nil(t, completedFile.TrashedAt)
+	assert.Len(t, listTrashForTest(t, privateKey).Files, 0)
+	assert.Len(t, listFilesForTest(t, "", 10, privateKey).Files, 1)
+
+	res = restoreFilesForTest(t, fileIDs, privateKey)
+	assert.Contains(t, res.UnsuccessfulRestores[fileID], "not in the trash bin")
+}
+
+func Test_Trashed_File_Objects_Are_Not_Public(t *testing.T) {
+	cleanUpBeforeTest(t)
+	defer setTrashRetentionDaysForTest(30)()
+	_, fileIDs, privateKey := createAccountAndUploadFile(t, 1)
+	fileID := fileIDs[0]
+
+	// A v1 download makes the data and metadata readable by anyone
+	_, err := GetBaseFileDownloadURL(fileID)
+	assert.Nil(t, err)
+	publicShare := models.CreatePublicShareObj()
+	publicShare.FileID = fileID
+	assert.Nil(t, models.DB.Create(&publicShare).Error)
+	assert.Nil(t, utils.SetDefaultBucketObject(models.GetFileDataPublicKey(fileID), "public data", ""))
+
+	v, b := returnValidVerificationAndRequestBody(t, deleteFilesObj{FileIDs: fileIDs}, privateKey)
+	w := httpPostRequestHelperForTest(t, DeleteV2Path, "v2", deleteFilesReq{verification: v, requestBody: b})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	for _, key := range []string{models.GetFileDataKey(fileID), models.GetFileMetadataKey(fileID)} {
+		acl, err := utils.GetBlobStore().(*utils.S3Fake).GetObjectCannedAcl(key)
+		assert.Nil(t, err)
+		assert.Equal(t, utils.CannedAcl_Private, acl)
+	}
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileDataPublicKey(fileID)))
+}
@@ -0,0 +1,698 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination"
+
+	tusResumableHeader = "Tus-Resumable"
+	tusVersionHeader   = "Tus-Version"
+	tusExtensionHeader = "Tus-Extension"
+	tusMaxSizeHeader   = "Tus-Max-Size"
+	uploadLengthHeader = "Upload-Length"
+	uploadOffsetHeader = "Upload-Offset"
+	uploadMetaHeader   = "Upload-Metadata"
+
+	// tus requests can't carry a signed body, the signed request travels in these headers
+	tusPublicKeyHeader   = "Opacity-Public-Key"
+	tusSignatureHeader   = "Opacity-Signature"
+	tusRequestBodyHeader = "Opacity-Request-Body"
+
+	// The follow-up requests of an upload are authenticated by the token in the query of its Location,
+	// so tus clients can send them without signing each one
+	tusUploadTokenQuery       = "uploadToken"
+	tusUploadTokenSizeInBytes = 32
+	// The bucket aborts incomplete multipart uploads after a day
+	tusUploadTokenTTL = 24 * time.Hour
+
+	// How long a PATCH request can hold the lock of its upload, in case the node holding it goes away
+	tusUploadLockTTL = time.Hour
+
+	tusOffsetContentType = "application/offset+octet-stream"
+
+	// S3 doesn't allow more parts in a multipart upload
+	tusMaxParts = 10000
+	tusMaxSize  = tusMaxParts * utils.MaxMultiPartSize
+)
+
+// The headers tus clients send and read, browsers need CORS to allow them
+var (
+	tusRequestHeaders = []string{
+		tusResumableHeader, uploadLengthHeader, uploadOffsetHeader, uploadMetaHeader,
+		tusPublicKeyHeader, tusSignatureHeader, tusRequestBodyHeader,
+	}
+	// Part buffers of PATCH requests by part size, most uploads share the smallest one
+	tusPartBuffers sync.Map
+
+	tusResponseHeaders = []string{
+		tusResumableHeader, tusVersionHeader, tusExtensionHeader, tusMaxSizeHeader,
+		uploadLengthHeader, uploadOffsetHeader, "Location",
+	}
+)
+
+type tusUploadObj struct {
+	FileHandle string `json:"fileHandle" validate:"required,len=64" minLength:"64" maxLength:"64" example:"a deterministically created file handle"`
+	Timestamp  int64  `json:"timestamp" validate:"required"`
+}
+
+type tusUploadReq struct {
+	verification
+	requestBody
+	tusUploadObj tusUploadObj
+}
+
+func (v *tusUploadReq) getObjectRef() interface{} {
+	return &v.tusUploadObj
+}
+
+/*tusUploadToken is what the upload token of a tus upload gives access to*/
+type tusUploadToken struct {
+	FileHandle string `json:"fileHandle"`
+	PublicKey  string `json:"publicKey"`
+}
+
+// TusOptionsHandler godoc
+// @Summary tus server configuration
+// @Description the tus 1.0.0 version, extensions and maximum upload size supported by the node
+// @Success 204 {string} string ""
+// @Header 204 {string} Tus-Version "1.0.0"
+// @Header 204 {string} Tus-Extension "creation,termination"
+// @Header 204 {integer} Tus-Max-Size "the biggest Upload-Length accepted"
+// @Router /api/v2/tus [options]
+/*TusOptionsHandler is a handler for tus clients discovering what the node supports*/
+func TusOptionsHandler() gin.HandlerFunc {
+	return ginHandlerFunc(tusOptions)
+}
+
+// TusCreateHandler godoc
+// @Summary create a tus upload
+// @Description create a resumable upload with the tus 1.0.0 creation extension. The request is authenticated with
+// @Description the Opacity-Public-Key, Opacity-Signature and Opacity-Request-Body headers, which hold what the
+// @Description publicKey, signature and requestBody fields hold in the other requests, or with a session token.
+// @Description Upload-Metadata must have a "metadata" key with the metadata of the file, and may have a "sha256" key
+// @Description with the hex SHA-256 of the whole file. The file is deleted if the data doesn't match it.
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param Upload-Length header integer true "size of the file in bytes"
+// @Param Upload-Metadata header string true "metadata <the metadata of the file, base64 encoded>"
+// @Param Opacity-Public-Key header string true "a 66-character public key"
+// @Param Opacity-Signature header string false "a 128 character string created when you signed the request body"
+// @Param Opacity-Request-Body header string true "the stringified request body, with the fileHandle and a timestamp"
+// @Success 201 {string} string ""
+// @Header 201 {string} Location "the URL of the upload, its uploadToken query authenticates the next requests of the upload"
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 412 {string} string "unsupported tus version"
+// @Failure 413 {string} string "upload is too big"
+// @Router /api/v2/tus [post]
+/*TusCreateHandler is a handler for creating a tus upload*/
+func TusCreateHandler() gin.HandlerFunc {
+	return ginHandlerFunc(createTusUpload)
+}
+
+// TusHeadHandler godoc
+// @Summary get the offset of a tus upload
+// @Description get how many bytes of a tus upload were received. Authenticated by the uploadToken of the Location
+// @Description returned at creation, or like the creation.
+// @Param fileHandle path string true "the file handle of the upload"
+// @Param uploadToken query string false "the upload token from the Location returned at creation"
+// @Param Tus-Resumable header string true "1.0.0"
+// @Success 200 {string} string ""
+// @Header 200 {integer} Upload-Offset "bytes received"
+// @Header 200 {integer} Upload-Length "size of the file in bytes"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no file with that id: (with the file handle)"
+// @Router /api/v2/tus/{fileHandle} [head]
+/*TusHeadHandler is a handler for getting the offset a tus upload should resume from*/
+func TusHeadHandler() gin.HandlerFunc {
+	return ginHandlerFunc(headTusUpload)
+}
+
+// TusPatchHandler godoc
+// @Summary upload bytes of a tus upload
+// @Description append the body to a tus upload at Upload-Offset. The upload is completed with the last byte.
+// @Description Authenticated by the uploadToken of the Location returned at creation, or like the creation.
+// @Accept application/offset+octet-stream
+// @Param fileHandle path string true "the file handle of the upload"
+// @Param uploadToken query string false "the upload token from the Location returned at creation"
+// @Param Tus-Resumable header string true "1.0.0"
+// @Param Upload-Offset header integer true "offset the body starts at"
+// @Success 204 {string} string ""
+// @Header 204 {integer} Upload-Offset "bytes received"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no file with that id: (with the file handle)"
+// @Failure 409 {string} string "Upload-Offset does not match the offset of the upload"
+// @Failure 415 {string} string "Content-Type must be application/offset+octet-stream"
+// @Failure 423 {string} string "another request is uploading to this upload"
+// @Router /api/v2/tus/{fileHandle} [patch]
+/*TusPatchHandler is a handler for uploading the bytes of a tus upload*/
+func TusPatchHandler() gin.HandlerFunc {
+	return ginHandlerFunc(patchTusUpload)
+}
+
+// TusDeleteHandler godoc
+// @Summary terminate a tus upload
+// @Description stop a tus upload and delete what was uploaded, with the tus 1.0.0 termination extension.
+// @Description Authenticated by the uploadToken of the Location returned at creation, or like the creation.
+// @Param fileHandle path string true "the file handle of the upload"
+// @Param uploadToken query string false "the upload token from the Location returned at creation"
+// @Param Tus-Resumable header string true "1.0.0"
+// @Success 204 {string} string ""
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "no file with that id: (with the file handle)"
+// @Router /api/v2/tus/{fileHandle} [delete]
+/*TusDeleteHandler is a handler for terminating a tus upload*/
+func TusDeleteHandler() gin.HandlerFunc {
+	return ginHandlerFunc(deleteTusUpload)
+}
+
+/*tusMiddleware adds the Tus-Resumable header to the responses and rejects requests for another version of tus*/
+func tusMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(tusResumableHeader, tusVersion)
+		if c.Request.Method != http.MethodOptions && c.GetHeader(tusResumableHeader) != tusVersion {
+			c.Header(tusVersionHeader, tusVersion)
+			tusErrorResponse(c, http.StatusPreconditionFailed, fmt.Errorf("unsupported tus version, only %s is supported", tusVersion))
+			return
+		}
+		c.Next()
+	}
+}
+
+func tusErrorResponse(c *gin.Context, status int, err error) error {
+	c.AbortWithStatusJSON(status, err.Error())
+	return err
+}
+
+func tusOptions(c *gin.Context) error {
+	c.Header(tusVersionHeader, tusVersion)
+	c.Header(tusExtensionHeader, tusExtensions)
+	c.Header(tusMaxSizeHeader, strconv.FormatInt(tusMaxSize, 10))
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func createTusUpload(c *gin.Context) error {
+	if !utils.WritesEnabled() {
+		return ServiceUnavailableResponse(c, errMaintenance)
+	}
+
+	request, err := parseTusRequest(c)
+	if err != nil {
+		return err
+	}
+	fileHandle := request.tusUploadObj.FileHandle
+
+	uploadLength, err := strconv.ParseInt(c.GetHeader(uploadLengthHeader), 10, 64)
+	if err != nil || uploadLength <= 0 {
+		return BadRequestResponse(c, errors.New("Upload-Length must be a positive number, deferring it is not supported"))
+	}
+	if uploadLength > tusMaxSize {
+		return tusErrorResponse(c, http.StatusRequestEntityTooLarge, fmt.Errorf("upload is too big, Tus-Max-Size is %d", tusMaxSize))
+	}
+
+	uploadMetadata := parseTusMetadata(c.GetHeader(uploadMetaHeader))
+	metadata, ok := uploadMetadata["metadata"]
+	if !ok {
+		return BadRequestResponse(c, errors.New("Upload-Metadata must have the metadata of the file"))
+	}
+	fileSha256 := strings.ToLower(uploadMetadata["sha256"])
+	if err := utils.Validator.Var(fileSha256, "omitempty,len=64,hexadecimal"); err != nil {
+		return BadRequestResponse(c, errors.New("the sha256 of Upload-Metadata must be a hex SHA-256"))
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyIfPaidWithContext(account, c); err != nil {
+		return err
+	}
+
+	if err := checkHaveEnoughStorageSpace(account, uploadLength, c); err != nil {
+		return err
+	}
+
+	if existingFile, err := models.GetFileById(fileHandle); err == nil && existingFile.FileID != "" {
+		return BadRequestResponse(c, errors.New("an upload with that file handle already exists"))
+	}
+
+	objKey, uploadID, err := utils.CreateMultiPartUpload(models.GetFileDataKey(fileHandle), "")
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	if err := utils.SetDefaultBucketObject(models.GetFileMetadataKey(fileHandle), metadata, ""); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	modifierHash, err := getPermissionHash(getOwnerPublicKey(request.PublicKey, c), fileHandle, c)
+	if err != nil {
+		return err
+	}
+
+	partSize := tusPartSize(uploadLength)
+	file := models.File{
+		FileID:       fileHandle,
+		EndIndex:     int((uploadLength + partSize - 1) / partSize),
+		AwsUploadID:  uploadID,
+		AwsObjectKey: objKey,
+		ExpiredAt:    account.ExpirationDate(),
+		ModifierHash: modifierHash,
+		UploadLength: uploadLength,
+		Sha256:       fileSha256,
+	}
+
+	if err := models.DB.Create(&file).Error; err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	token, err := createTusUploadToken(fileHandle, request.PublicKey)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.Header("Location", V2Path+"/"+TusPathPrefix+"/"+fileHandle+"?"+url.Values{tusUploadTokenQuery: {token}}.Encode())
+	c.Status(http.StatusCreated)
+	return nil
+}
+
+func headTusUpload(c *gin.Context) error {
+	request, err := parseTusRequest(c)
+	if err != nil {
+		return err
+	}
+	fileHandle := request.tusUploadObj.FileHandle
+	c.Header("Cache-Control", "no-store")
+
+	file, err := models.GetFileById(fileHandle)
+	if err != nil || len(file.FileID) == 0 {
+		// The upload may be over already
+		completedFile, completedErr := models.GetCompletedFileByFileID(fileHandle)
+		if completedErr != nil || len(completedFile.FileID) == 0 {
+			return FileNotFoundResponse(c, fileHandle)
+		}
+		if err := verifyPermissions(request.PublicKey, fileHandle, completedFile.ModifierHash, c); err != nil {
+			return err
+		}
+		c.Header(uploadOffsetHeader, strconv.FormatInt(completedFile.FileSizeInByte, 10))
+		c.Header(uploadLengthHeader, strconv.FormatInt(completedFile.FileSizeInByte, 10))
+		c.Status(http.StatusOK)
+		return nil
+	}
+
+	if err := verifyPermissions(request.PublicKey, fileHandle, file.ModifierHash, c); err != nil {
+		return err
+	}
+	if file.UploadLength == 0 {
+		return BadRequestResponse(c, errors.New("the upload was not created with tus"))
+	}
+
+	offset, _, err := getTusOffset(file)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	c.Header(uploadLengthHeader, strconv.FormatInt(file.UploadLength, 10))
+	c.Status(http.StatusOK)
+	return nil
+}
+
+func patchTusUpload(c *gin.Context) error {
+	defer c.Request.Body.Close()
+
+	request, err := parseTusRequest(c)
+	if err != nil {
+		return err
+	}
+	fileHandle := request.tusUploadObj.FileHandle
+
+	if c.ContentType() != tusOffsetContentType {
+		return tusErrorResponse(c, http.StatusUnsupportedMediaType, fmt.Errorf("Content-Type must be %s", tusOffsetContentType))
+	}
+
+	file, err := models.GetFileById(fileHandle)
+	if err != nil || len(file.FileID) == 0 {
+		return FileNotFoundResponse(c, fileHandle)
+	}
+	if err := verifyPermissions(request.PublicKey, fileHandle, file.ModifierHash, c); err != nil {
+		return err
+	}
+	if file.UploadLength == 0 {
+		return BadRequestResponse(c, errors.New("the upload was not created with tus"))
+	}
+
+	// Two requests appending at the same offset would both upload the same parts and tail
+	unlock, locked, err := lockTusUpload(fileHandle)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if !locked {
+		return tusErrorResponse(c, http.StatusLocked, errors.New("another request is uploading to this upload"))
+	}
+	defer unlock()
+
+	requestOffset, err := strconv.ParseInt(c.GetHeader(uploadOffsetHeader), 10, 64)
+	if err != nil {
+		return BadRequestResponse(c, errors.New("Upload-Offset must be a number"))
+	}
+	offset, tail, err := getTusOffset(file)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if requestOffset != offset {
+		return tusErrorResponse(c, http.StatusConflict, fmt.Errorf("Upload-Offset is %d, the upload is at %d", requestOffset, offset))
+	}
+	remaining := file.UploadLength - offset
+	if c.Request.ContentLength > remaining {
+		return BadRequestResponse(c, fmt.Errorf("the body goes past Upload-Length, %d bytes are left to upload", remaining))
+	}
+
+	partSize := tusPartSize(file.UploadLength)
+	partNumber := int((offset-int64(len(tail)))/partSize) + 1
+	buffers := getTusPartBuffers(partSize)
+	pooled := buffers.Get().(*[]byte)
+	defer buffers.Put(pooled)
+	buffer := *pooled
+	buffered := copy(buffer, tail)
+	hasTail := len(tail) > 0
+
+	// Whole parts are uploaded as they come in, what is left when the body ends is kept as the tail
+	body := io.LimitReader(c.Request.Body, remaining)
+	for {
+		n, readErr := io.ReadFull(body, buffer[buffered:])
+		buffered += n
+		if readErr != nil {
+			break
+		}
+
+		if err := uploadTusPart(file, partNumber, buffer); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		partNumber++
+		buffered = 0
+		if hasTail {
+			if err := utils.DeleteDefaultBucketObject(models.GetFileUploadTailKey(fileHandle)); err != nil {
+				return InternalErrorResponse(c, err)
+			}
+			hasTail = false
+		}
+	}
+
+	offset = int64(partNumber-1)*partSize + int64(buffered)
+	if offset == file.UploadLength {
+		return finishTusUpload(request, file, partNumber, buffer[:buffered], hasTail, c)
+	}
+
+	if buffered > 0 {
+		err = utils.SetDefaultBucketObject(models.GetFileUploadTailKey(fileHandle), string(buffer[:buffered]), "")
+	} else if hasTail {
+		err = utils.DeleteDefaultBucketObject(models.GetFileUploadTailKey(fileHandle))
+	}
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(offset, 10))
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func finishTusUpload(request tusUploadReq, file models.File, lastPartNumber int, lastPart []byte, hasTail bool, c *gin.Context) error {
+	if len(lastPart) > 0 {
+		if err := uploadTusPart(file, lastPartNumber, lastPart); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+	if hasTail {
+		if err := utils.DeleteDefaultBucketObject(models.GetFileUploadTailKey(file.FileID)); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	completedFile, err := file.FinishUpload()
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	if err := activateCompletedFile(account, completedFile.FileID, c); err != nil {
+		return err
+	}
+
+	c.Header(uploadOffsetHeader, strconv.FormatInt(file.UploadLength, 10))
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+func deleteTusUpload(c *gin.Context) error {
+	request, err := parseTusRequest(c)
+	if err != nil {
+		return err
+	}
+	fileHandle := request.tusUploadObj.FileHandle
+
+	file, err := models.GetFileById(fileHandle)
+	if err != nil || len(file.FileID) == 0 {
+		return FileNotFoundResponse(c, fileHandle)
+	}
+	if err := verifyPermissions(request.PublicKey, fileHandle, file.ModifierHash, c); err != nil {
+		return err
+	}
+
+	if err := utils.AbortMultiPartUpload(aws.StringValue(file.AwsObjectKey), aws.StringValue(file.AwsUploadID)); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	// The metadata and the tail
+	if err := utils.DeleteDefaultBucketObjectKeys(fileHandle); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if err := models.DeleteCompletedUploadIndexes(fileHandle); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if err := models.DB.Delete(&file).Error; err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if token := c.Query(tusUploadTokenQuery); token != "" {
+		if err := utils.BatchDelete(&utils.KVKeys{getTusUploadTokenKeyForBadger(token)}); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+/*
+parseTusRequest verifies the upload token in the query of a tus request, or the signed request sent
+in its headers. The file handle it was given or signed for must be the one of the upload in the URL.
+*/
+func parseTusRequest(c *gin.Context) (tusUploadReq, error) {
+	if token := c.Query(tusUploadTokenQuery); token != "" && c.Param("fileHandle") != "" {
+		return parseTusUploadToken(token, c)
+	}
+
+	request := tusUploadReq{
+		verification: verification{
+			PublicKey: c.GetHeader(tusPublicKeyHeader),
+			Signature: c.GetHeader(tusSignatureHeader),
+		},
+		requestBody: requestBody{
+			RequestBody: c.GetHeader(tusRequestBodyHeader),
+		},
+	}
+	if err := utils.Validator.Struct(request); err != nil {
+		return request, BadRequestResponse(c, fmt.Errorf("bad request, unable to parse request headers: %v", err))
+	}
+
+	if err := verifyAndParseStringRequest(request.RequestBody, &request.tusUploadObj, request.verification, c); err != nil {
+		return request, err
+	}
+
+	if fileHandle := c.Param("fileHandle"); fileHandle != "" && fileHandle != request.tusUploadObj.FileHandle {
+		return request, ForbiddenResponse(c, errors.New("the request was signed for another upload"))
+	}
+	return request, nil
+}
+
+func parseTusUploadToken(token string, c *gin.Context) (tusUploadReq, error) {
+	invalidToken := errors.New("the upload token is invalid or expired")
+	if len(token) != 2*tusUploadTokenSizeInBytes {
+		return tusUploadReq{}, ForbiddenResponse(c, invalidToken)
+	}
+
+	value, _, err := utils.GetValueFromKV(getTusUploadTokenKeyForBadger(token))
+	if err == utils.ErrKeyNotFound {
+		return tusUploadReq{}, ForbiddenResponse(c, invalidToken)
+	}
+	if err != nil {
+		return tusUploadReq{}, InternalErrorResponse(c, err)
+	}
+	uploadToken := tusUploadToken{}
+	if err := json.Unmarshal([]byte(value), &uploadToken); err != nil {
+		return tusUploadReq{}, InternalErrorResponse(c, err)
+	}
+	if uploadToken.FileHandle != c.Param("fileHandle") {
+		return tusUploadReq{}, ForbiddenResponse(c, errors.New("the upload token was given for another upload"))
+	}
+
+	if err := chargeAccountRateLimit(uploadToken.PublicKey, c); err != nil {
+		return tusUploadReq{}, err
+	}
+	return tusUploadReq{
+		verification: verification{PublicKey: uploadToken.PublicKey},
+		tusUploadObj: tusUploadObj{FileHandle: uploadToken.FileHandle},
+	}, nil
+}
+
+/*createTusUploadToken returns a token giving access to the upload of fileHandle for as long as it can be resumed*/
+func createTusUploadToken(fileHandle, publicKey string) (string, error) {
+	tokenBytes := make([]byte, tusUploadTokenSizeInBytes)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	value, err := json.Marshal(tusUploadToken{FileHandle: fileHandle, PublicKey: publicKey})
+	if err != nil {
+		return "", err
+	}
+	if err := utils.BatchSet(&utils.KVPairs{getTusUploadTokenKeyForBadger(token): string(value)}, tusUploadTokenTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+/*
+lockTusUpload takes the lock of the upload, held by one request at a time across every node, and returns what
+releases it. It returns false when another request holds it.
+*/
+func lockTusUpload(fileHandle string) (func(), bool, error) {
+	key := getTusUploadLockKeyForBadger(fileHandle)
+	owner := utils.RandHexString(tusUploadTokenSizeInBytes)
+	locked, err := utils.SetIfAbsent(key, owner, tusUploadLockTTL)
+	if err != nil || !locked {
+		return nil, locked, err
+	}
+	return func() {
+		// The lock expired and was taken by another request if it isn't ours anymore
+		if value, _, err := utils.GetValueFromKV(key); err == nil && value == owner {
+			utils.LogIfError(utils.BatchDelete(&utils.KVKeys{key}), map[string]interface{}{"fileHandle": fileHandle})
+		}
+	}, true, nil
+}
+
+/*getTusPartBuffers returns the pool of buffers of partSize bytes*/
+func getTusPartBuffers(partSize int64) *sync.Pool {
+	buffers, _ := tusPartBuffers.LoadOrStore(partSize, &sync.Pool{
+		New: func() interface{} {
+			buffer := make([]byte, partSize)
+			return &buffer
+		},
+	})
+	return buffers.(*sync.Pool)
+}
+
+func getTusUploadLockKeyForBadger(fileHandle string) string {
+	return fileHandle + "_tusUploadLock"
+}
+
+func getTusUploadTokenKeyForBadger(token string) string {
+	// Like session tokens, only a hash of the token is stored
+	return utils.HashStringV2([]byte(token)) + "_tusUpload"
+}
+
+/*
+parseTusMetadata parses an Upload-Metadata header, a comma separated list of keys each followed
+by a space and its base64 encoded value
+*/
+func parseTusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 {
+			continue
+		}
+		value := ""
+		if len(fields) > 1 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				continue
+			}
+			value = string(decoded)
+		}
+		metadata[fields[0]] = value
+	}
+	return metadata
+}
+
+/*
+tusPartSize is the size of the multipart upload parts of a tus upload. It only depends on the size
+of the file, so every request splits the upload the same way.
+*/
+func tusPartSize(uploadLength int64) int64 {
+	partSize := utils.MinMultiPartSize
+	if minPartSize := (uploadLength + tusMaxParts - 1) / tusMaxParts; minPartSize > partSize {
+		partSize = minPartSize
+	}
+	return partSize
+}
+
+/*
+getTusOffset returns how many bytes of the upload were received, and the ones kept after the last complete part.
+Parts are uploaded in order, so every part before the tail is complete.
+*/
+func getTusOffset(file models.File) (int64, []byte, error) {
+	completedParts, err := models.GetCompletedUploadProgress(file.FileID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var tail []byte
+	tailKey := models.GetFileUploadTailKey(file.FileID)
+	if utils.DoesDefaultBucketObjectExist(tailKey) {
+		tailString, err := utils.GetDefaultBucketObject(tailKey, false)
+		if err != nil {
+			return 0, nil, err
+		}
+		tail = []byte(tailString)
+	}
+
+	return int64(completedParts)*tusPartSize(file.UploadLength) + int64(len(tail)), tail, nil
+}
+
+func uploadTusPart(file models.File, partNumber int, data []byte) error {
+	completedPart, err := handleChunkData(file, partNumber, data)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	return models.CreateCompletedUploadIndexWithDigest(file.FileID, partNumber, aws.StringValue(completedPart.ETag),
+		hex.EncodeToString(sum[:]), int64(len(data)))
+}
@@ -0,0 +1,268 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Tus(t *testing.T) {
+	setupTests(t)
+}
+
+func tusRequestForTest(t *testing.T, method, fileHandle string, privateKey *ecdsa.PrivateKey, timestamp int64, headers map[string]string, body []byte) *httptest.ResponseRecorder {
+	abortIfNotTesting(t)
+
+	router := returnEngine()
+	setupV2Paths(returnV2Group(router))
+
+	path := V2Path + "/" + TusPathPrefix
+	if method != http.MethodPost && method != http.MethodOptions {
+		path += "/" + fileHandle
+	}
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(body))
+	assert.Nil(t, err)
+
+	v, b := returnValidVerificationAndRequestBody(t, tusUploadObj{FileHandle: fileHandle, Timestamp: timestamp}, privateKey)
+	req.Header.Set(tusResumableHeader, tusVersion)
+	req.Header.Set(tusPublicKeyHeader, v.PublicKey)
+	req.Header.Set(tusSignatureHeader, v.Signature)
+	req.Header.Set(tusRequestBodyHeader, b.RequestBody)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_Tus_Options(t *testing.T) {
+	_, privateKey := generateValidateAccountId(t)
+	w := tusRequestForTest(t, http.MethodOptions, "", privateKey, time.Now().Unix(), nil, nil)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, tusVersion, w.Header().Get(tusVersionHeader))
+	assert.Equal(t, tusExtensions, w.Header().Get(tusExtensionHeader))
+	assert.Equal(t, strconv.FormatInt(tusMaxSize, 10), w.Header().Get(tusMaxSizeHeader))
+}
+
+func Test_Tus_Rejects_Other_Versions(t *testing.T) {
+	_, privateKey := generateValidateAccountId(t)
+	w := tusRequestForTest(t, http.MethodPost, utils.GenerateFileHandle(), privateKey, time.Now().Unix(),
+		map[string]string{tusResumableHeader: "0.2.2"}, nil)
+
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, tusVersion, w.Header().Get(tusVersionHeader))
+}
+
+func Test_Tus_Upload(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	fileHandle := utils.GenerateFileHandle()
+	timestamp := time.Now().Unix()
+
+	data := bytes.Repeat([]byte("a"), int(utils.MinMultiPartSize)+1000)
+	w := tusRequestForTest(t, http.MethodPost, fileHandle, privateKey, timestamp, map[string]string{
+		uploadLengthHeader: strconv.Itoa(len(data)),
+		uploadMetaHeader:   "metadata " + base64.StdEncoding.EncodeToString([]byte("file metadata")),
+	}, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Location"), V2Path+"/"+TusPathPrefix+"/"+fileHandle+"?"+tusUploadTokenQuery+"="))
+
+	file, err := models.GetFileById(fileHandle)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, file.EndIndex)
+
+	patch := func(offset, end int) *httptest.ResponseRecorder {
+		timestamp++
+		return tusRequestForTest(t, http.MethodPatch, fileHandle, privateKey, timestamp, map[string]string{
+			"Content-Type":     tusOffsetContentType,
+			uploadOffsetHeader: strconv.Itoa(offset),
+		}, data[offset:end])
+	}
+
+	// less than a part is kept aside until the next request
+	w = patch(0, 100)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "100", w.Header().Get(uploadOffsetHeader))
+	assert.True(t, utils.DoesDefaultBucketObjectExist(models.GetFileUploadTailKey(fileHandle)))
+
+	timestamp++
+	w = tusRequestForTest(t, http.MethodHead, fileHandle, privateKey, timestamp, nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get(uploadOffsetHeader))
+	assert.Equal(t, strconv.Itoa(len(data)), w.Header().Get(uploadLengthHeader))
+
+	// resuming from the wrong offset
+	w = patch(50, 200)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = patch(100, len(data))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, strconv.Itoa(len(data)), w.Header().Get(uploadOffsetHeader))
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileUploadTailKey(fileHandle)))
+
+	completedFile, err := models.GetCompletedFileByFileID(fileHandle)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), completedFile.FileSizeInByte)
+
+	timestamp++
+	w = tusRequestForTest(t, http.MethodHead, fileHandle, privateKey, timestamp, nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strconv.Itoa(len(data)), w.Header().Get(uploadOffsetHeader))
+}
+
+func tusTokenRequestForTest(t *testing.T, method, location string, headers map[string]string, body []byte) *httptest.ResponseRecorder {
+	abortIfNotTesting(t)
+
+	router := returnEngine()
+	setupV2Paths(returnV2Group(router))
+
+	req, err := http.NewRequest(method, location, bytes.NewBuffer(body))
+	assert.Nil(t, err)
+	req.Header.Set(tusResumableHeader, tusVersion)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func Test_Tus_Upload_With_Upload_Token(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	fileHandle := utils.GenerateFileHandle()
+
+	data := bytes.Repeat([]byte("a"), 1000)
+	w := tusRequestForTest(t, http.MethodPost, fileHandle, privateKey, time.Now().Unix(), map[string]string{
+		uploadLengthHeader: strconv.Itoa(len(data)),
+		uploadMetaHeader:   "metadata " + base64.StdEncoding.EncodeToString([]byte("file metadata")),
+	}, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	location := w.Header().Get("Location")
+
+	// The follow-up requests only carry the static tus headers
+	patchHeaders := map[string]string{"Content-Type": tusOffsetContentType, uploadOffsetHeader: "0"}
+	w = tusTokenRequestForTest(t, http.MethodPatch, location, patchHeaders, data[:100])
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = tusTokenRequestForTest(t, http.MethodHead, location, nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get(uploadOffsetHeader))
+
+	patchHeaders[uploadOffsetHeader] = "100"
+	w = tusTokenRequestForTest(t, http.MethodPatch, location, patchHeaders, data[100:])
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	completedFile, err := models.GetCompletedFileByFileID(fileHandle)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(data)), completedFile.FileSizeInByte)
+
+	// The token only gives access to its upload
+	otherLocation := strings.Replace(location, fileHandle, utils.GenerateFileHandle(), 1)
+	w = tusTokenRequestForTest(t, http.MethodHead, otherLocation, nil, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = tusTokenRequestForTest(t, http.MethodHead, V2Path+"/"+TusPathPrefix+"/"+fileHandle+"?"+tusUploadTokenQuery+"="+utils.RandHexString(64), nil, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_Tus_Terminate(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	fileHandle := utils.GenerateFileHandle()
+	timestamp := time.Now().Unix()
+
+	w := tusRequestForTest(t, http.MethodPost, fileHandle, privateKey, timestamp, map[string]string{
+		uploadLengthHeader: "1000",
+		uploadMetaHeader:   "metadata " + base64.StdEncoding.EncodeToString([]byte("file metadata")),
+	}, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = tusRequestForTest(t, http.MethodDelete, fileHandle, privateKey, timestamp+1, nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	_, err := models.GetFileById(fileHandle)
+	assert.NotNil(t, err)
+	assert.False(t, utils.DoesDefaultBucketObjectExist(models.GetFileMetadataKey(fileHandle)))
+}
+
+func Test_Tus_Patch_While_Another_Request_Uploads(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+	fileHandle := utils.GenerateFileHandle()
+	timestamp := time.Now().Unix()
+
+	w := tusRequestForTest(t, http.MethodPost, fileHandle, privateKey, timestamp, map[string]string{
+		uploadLengthHeader: "1000",
+		uploadMetaHeader:   "metadata " + base64.StdEncoding.EncodeToString([]byte("file metadata")),
+	}, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	patchHeaders := map[string]string{"Content-Type": tusOffsetContentType, uploadOffsetHeader: "0"}
+	unlock, locked, err := lockTusUpload(fileHandle)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	w = tusRequestForTest(t, http.MethodPatch, fileHandle, privateKey, timestamp+1, patchHeaders, make([]byte, 100))
+	assert.Equal(t, http.StatusLocked, w.Code)
+
+	unlock()
+	w = tusRequestForTest(t, http.MethodPatch, fileHandle, privateKey, timestamp+2, patchHeaders, make([]byte, 100))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "100", w.Header().Get(uploadOffsetHeader))
+
+	// The lock was released once the request was done
+	unlock, locked, err = lockTusUpload(fileHandle)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	unlock()
+}
+
+func Test_Tus_Request_Signed_For_Another_Upload(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	router := returnEngine()
+	setupV2Paths(returnV2Group(router))
+
+	v, b := returnValidVerificationAndRequestBody(t, tusUploadObj{FileHandle: utils.GenerateFileHandle(), Timestamp: time.Now().Unix()}, privateKey)
+	req, _ := http.NewRequest(http.MethodHead, V2Path+"/"+TusPathPrefix+"/"+utils.GenerateFileHandle(), nil)
+	req.Header.Set(tusResumableHeader, tusVersion)
+	req.Header.Set(tusPublicKeyHeader, v.PublicKey)
+	req.Header.Set(tusSignatureHeader, v.Signature)
+	req.Header.Set(tusRequestBodyHeader, b.RequestBody)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_ParseTusMetadata(t *testing.T) {
+	metadata := parseTusMetadata("metadata " + base64.StdEncoding.EncodeToString([]byte("some metadata")) + ", is_confidential,bad !!!")
+
+	assert.Equal(t, "some metadata", metadata["metadata"])
+	value, ok := metadata["is_confidential"]
+	assert.True(t, ok)
+	assert.Equal(t, "", value)
+	_, ok = metadata["bad"]
+	assert.False(t, ok)
+}
+
+func Test_TusPartSize(t *testing.T) {
+	assert.Equal(t, utils.MinMultiPartSize, tusPartSize(1))
+	assert.Equal(t, utils.MinMultiPartSize, tusPartSize(tusMaxParts*utils.MinMultiPartSize))
+	assert.Equal(t, utils.MaxMultiPartSize, tusPartSize(tusMaxSize))
+}
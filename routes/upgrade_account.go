@@ -84,6 +84,9 @@ func GetAccountUpgradeInvoiceHandler() gin.HandlerFunc {
 // @description 	"metadataKeys": "["someKey", "someOtherKey]",
 // @description 	"fileHandles": "["someHandle", "someOtherHandle]",
 // @description }
+// @description the node extends the expiration of what the account owns in the background and the response tells how far
+// @description it went. metadataKeys and fileHandles have to list everything the account has, even empty, to index what
+// @description it owned before the node kept track of it
 // @Success 200 {object} routes.StatusRes
 // @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
 // @Failure 404 {string} string "no account with that id: (with your accountID)"
@@ -165,6 +168,13 @@ func checkUpgradeStatus(c *gin.Context) error {
 		return err
 	}
 
+	// The upgrade made the account ineligible, the client can still poll how far the propagation went
+	if inProgress, err := isPaidUpgradePropagating(account, request.checkUpgradeStatusObject.StorageLimit); err != nil {
+		return InternalErrorResponse(c, err)
+	} else if inProgress {
+		return successWithExpiryPropagationResponse(c, account.AccountID)
+	}
+
 	if err := verifyUpgradeEligible(account, request.checkUpgradeStatusObject.StorageLimit, c); err != nil {
 		return err
 	}
@@ -220,9 +230,7 @@ func checkUpgradeStatus(c *gin.Context) error {
 	if err := upgradeAccountAndUpdateExpireDates(account, request, c); err != nil {
 		return InternalErrorResponse(c, err)
 	}
-	return OkResponse(c, StatusRes{
-		Status: "Success with OPCT",
-	})
+	return successWithExpiryPropagationResponse(c, account.AccountID)
 }
 
 func upgradeAccountAndUpdateExpireDates(account models.Account, request checkUpgradeStatusReq, c *gin.Context) error {
@@ -231,15 +239,8 @@ func upgradeAccountAndUpdateExpireDates(account models.Account, request checkUpg
 		account.MonthsInSubscription); err != nil {
 		return err
 	}
-	filesErr := models.UpdateExpiredAt(request.checkUpgradeStatusObject.FileHandles,
-		request.verification.PublicKey, account.ExpirationDate())
-
-	// Setting ttls on metadata to 2 months post account expiration date so the metadatas won't
-	// be deleted too soon
-	metadatasErr := updateMetadataExpiration(request.checkUpgradeStatusObject.MetadataKeys,
-		request.verification.PublicKey, account.ExpirationDate().Add(MetadataExpirationOffset), c)
-
-	return utils.CollectErrors([]error{filesErr, metadatasErr})
+	return propagateAccountExpiration(account, request.checkUpgradeStatusObject.FileHandles,
+		request.checkUpgradeStatusObject.MetadataKeys, request.verification.PublicKey, 1, c)
 }
 
 func updateMetadataExpiration(metadataKeys []string, key string, newExpiredAtTime time.Time, c *gin.Context) error {
@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+)
+
+// The most parts a multipart upload can have
+const directUploadMaxParts = 10000
+
+type directUploadPartURL struct {
+	PartNumber int    `json:"partNumber" example:"1"`
+	URL        string `json:"url" example:"a presigned URL to PUT the part to"`
+}
+
+type initUploadDirectRes struct {
+	Status    string                `json:"status" example:"File is init. Please upload the parts to their URLs"`
+	PartURLs  []directUploadPartURL `json:"partURLs"`
+	ExpiresAt int64                 `json:"expiresAt" example:"1659325302"`
+}
+
+// InitFileUploadDirectHandler godoc
+// @Summary start an upload straight to the bucket
+// @Description start an upload whose parts are PUT by the client to presigned URLs instead of being sent to the node.
+// @Description Every part but the last must be at least 5MB. Call upload-complete-direct once all the parts are uploaded.
+// @Accept mpfd
+// @Produce json
+// @Param InitFileUploadReq body routes.InitFileUploadReq true "an object to start a file upload"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"fileHandle": "a deterministically created file handle",
+// @description 	"fileSizeInByte": "200000000000006",
+// @description 	"endIndex": 2,
+// @description 	"fileSha256": "the hex SHA-256 of the whole file, optional. The file is deleted if the data doesn't match"
+// @description }
+// @Success 200 {object} routes.initUploadDirectRes
+// @Failure 400 {string} string "bad request, unable to parse request body: (with the error)"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/init-upload-direct [post]
+/*InitFileUploadDirectHandler is a handler for the user to start uploads that bypass the node*/
+func InitFileUploadDirectHandler() gin.HandlerFunc {
+	return ginHandlerFunc(initFileUploadDirect)
+}
+
+func initFileUploadDirect(c *gin.Context) error {
+	if !utils.WritesEnabled() {
+		return ServiceUnavailableResponse(c, errMaintenance)
+	}
+
+	request := InitFileUploadReq{}
+
+	if err := verifyAndParseFormRequest(&request, c); err != nil {
+		return err
+	}
+
+	endIndex := request.initFileUploadObj.EndIndex
+	if endIndex > directUploadMaxParts {
+		return BadRequestResponse(c, fmt.Errorf("endIndex can't be more than %v", directUploadMaxParts))
+	}
+	if request.initFileUploadObj.FileSizeInByte <= int64(endIndex-1)*utils.MinMultiPartSize {
+		return BadRequestResponse(c, fmt.Errorf("the file is too small to be split in %v parts of at least %v bytes", endIndex, utils.MinMultiPartSize))
+	}
+
+	file, err := startFileUpload(request, c)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(utils.Env.DirectUploadURLTTLMinutes) * time.Minute
+	res := initUploadDirectRes{
+		Status:    "File is init. Please upload the parts to their URLs",
+		ExpiresAt: time.Now().Add(ttl).Unix(),
+	}
+	for partNumber := models.FirstChunkIndex; partNumber <= endIndex; partNumber++ {
+		url, err := utils.PresignMultiPartPartURL(aws.StringValue(file.AwsObjectKey), aws.StringValue(file.AwsUploadID), partNumber, ttl)
+		if err != nil {
+			return InternalErrorResponse(c, err)
+		}
+		res.PartURLs = append(res.PartURLs, directUploadPartURL{PartNumber: partNumber, URL: url})
+	}
+
+	return OkResponse(c, res)
+}
+
+// CompleteFileUploadDirectHandler godoc
+// @Summary complete an upload straight to the bucket
+// @Description check the parts uploaded to the presigned URLs of init-upload-direct and finish the file.
+// @Description Returns the missing parts while some are not uploaded yet.
+// @Accept json
+// @Produce json
+// @Param UploadStatusReq body routes.UploadStatusReq true "an object to complete an upload"
+// @description requestBody should be a stringified version of (values are just examples):
+// @description {
+// @description 	"fileHandle": "a deterministically created file handle",
+// @description }
+// @Success 200 {object} routes.StatusRes
+// @Failure 400 {string} string "the uploaded parts don't match the size of the file"
+// @Failure 403 {string} string "signature did not match"
+// @Failure 404 {string} string "file or account not found"
+// @Failure 500 {string} string "some information about the internal error"
+// @Router /api/v2/upload-complete-direct [post]
+/*CompleteFileUploadDirectHandler is a handler for finishing uploads that bypassed the node*/
+func CompleteFileUploadDirectHandler() gin.HandlerFunc {
+	return ginHandlerFunc(completeFileUploadDirect)
+}
+
+func completeFileUploadDirect(c *gin.Context) error {
+	request := UploadStatusReq{}
+
+	if err := verifyAndParseBodyRequest(&request, c); err != nil {
+		return err
+	}
+
+	account, err := request.getAccount(c)
+	if err != nil {
+		return err
+	}
+
+	fileID := request.uploadStatusObj.FileHandle
+	completedFile, completedErr := models.GetCompletedFileByFileID(fileID)
+	if completedErr == nil && len(completedFile.FileID) != 0 &&
+		utils.DoesDefaultBucketObjectExist(models.GetFileDataKey(fileID)) {
+		return OkResponse(c, fileUploadCompletedRes)
+	}
+
+	file, err := models.GetFileById(fileID)
+	if err != nil || len(file.FileID) == 0 {
+		return FileNotFoundResponse(c, fileID)
+	}
+
+	if err := verifyPermissions(request.PublicKey, fileID, file.ModifierHash, c); err != nil {
+		return err
+	}
+
+	parts, err := utils.ListMultiPartUploadParts(aws.StringValue(file.AwsObjectKey), aws.StringValue(file.AwsUploadID))
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	missingIndexes, err := checkDirectUploadParts(file, parts)
+	if err != nil {
+		return BadRequestResponse(c, err)
+	}
+	if len(missingIndexes) > 0 {
+		return OkResponse(c, missingChunksRes{
+			Status:         "chunks missing",
+			MissingIndexes: missingIndexes,
+			EndIndex:       file.EndIndex,
+		})
+	}
+
+	// The bucket is the source of truth for parts that never went through the node
+	if err := models.DeleteCompletedUploadIndexes(file.FileID); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	for _, part := range parts {
+		if err := models.CreateCompletedUploadIndex(file.FileID, int(aws.Int64Value(part.PartNumber)), aws.StringValue(part.ETag)); err != nil {
+			return InternalErrorResponse(c, err)
+		}
+	}
+
+	if _, err := file.FinishUpload(); err != nil {
+		return InternalErrorResponse(c, err)
+	}
+
+	if err := activateCompletedFile(account, file.FileID, c); err != nil {
+		return err
+	}
+
+	return OkResponse(c, fileUploadCompletedRes)
+}
+
+/*checkDirectUploadParts returns the parts not uploaded yet, or an error when the uploaded parts can't
+make up the file announced by init-upload-direct*/
+func checkDirectUploadParts(file models.File, parts []*s3.Part) ([]int64, error) {
+	uploaded := make(map[int64]int64)
+	var totalSize int64
+	for _, part := range parts {
+		partNumber := aws.Int64Value(part.PartNumber)
+		if partNumber < models.FirstChunkIndex || partNumber > int64(file.EndIndex) {
+			return nil, fmt.Errorf("part %v is out of the %v parts of the file", partNumber, file.EndIndex)
+		}
+		uploaded[partNumber] = aws.Int64Value(part.Size)
+		totalSize += aws.Int64Value(part.Size)
+	}
+
+	var missingIndexes []int64
+	for index := int64(models.FirstChunkIndex); index <= int64(file.EndIndex); index++ {
+		size, ok := uploaded[index]
+		if !ok {
+			missingIndexes = append(missingIndexes, index)
+			continue
+		}
+		if index != int64(file.EndIndex) && size < utils.MinMultiPartSize {
+			return nil, fmt.Errorf("part %v is %v bytes and does not meet min fileSize %v", index, size, utils.MinMultiPartSize)
+		}
+	}
+	if len(missingIndexes) > 0 {
+		return missingIndexes, nil
+	}
+
+	if file.FileSizeInByte != 0 && totalSize != file.FileSizeInByte {
+		return nil, errors.New("the uploaded parts don't add up to fileSizeInByte")
+	}
+	return nil, nil
+}
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/opacity/storage-node/models"
+	"github.com/opacity/storage-node/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Init_Upload_Direct(t *testing.T) {
+	setupTests(t)
+}
+
+func initFileUploadDirectForTest(t *testing.T, fileSizeInByte int64, endIndex int, privateKey *ecdsa.PrivateKey) (string, initUploadDirectRes) {
+	req, uploadObj := createValidInitFileUploadRequest(t, fileSizeInByte, endIndex, privateKey)
+	form := map[string]string{
+		"metadata": "abc",
+	}
+	formFile := map[string]string{
+		"metadata": "abc_file",
+	}
+
+	w := httpPostFormRequestHelperForTest(t, InitUploadDirectPath, &req, form, formFile, "v2")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	res := initUploadDirectRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	return uploadObj.FileHandle, res
+}
+
+func Test_Upload_Direct(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	firstPart := bytes.Repeat([]byte("a"), int(utils.MinMultiPartSize))
+	lastPart := []byte("the last part")
+	fileID, res := initFileUploadDirectForTest(t, int64(len(firstPart)+len(lastPart)), 2, privateKey)
+	assert.Len(t, res.PartURLs, 2)
+	assert.Equal(t, 1, res.PartURLs[0].PartNumber)
+	assert.NotEmpty(t, res.PartURLs[0].URL)
+
+	file, err := models.GetFileById(fileID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(firstPart)+len(lastPart)), file.FileSizeInByte)
+
+	// The client PUTs the parts to the bucket on its own
+	_, err = utils.UploadMultiPartPart(aws.StringValue(file.AwsObjectKey), aws.StringValue(file.AwsUploadID), firstPart, 1)
+	assert.Nil(t, err)
+
+	completeReq, _ := createUploadStatusRequest(t, fileID, privateKey)
+	w := httpPostRequestHelperForTest(t, UploadCompleteDirectPath, "v2", completeReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+	missing := missingChunksRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &missing))
+	assert.Equal(t, []int64{2}, missing.MissingIndexes)
+
+	_, err = utils.UploadMultiPartPart(aws.StringValue(file.AwsObjectKey), aws.StringValue(file.AwsUploadID), lastPart, 2)
+	assert.Nil(t, err)
+
+	w = httpPostRequestHelperForTest(t, UploadCompleteDirectPath, "v2", completeReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), fileUploadCompletedRes.Status)
+
+	completedFile, err := models.GetCompletedFileByFileID(fileID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(firstPart)+len(lastPart)), completedFile.FileSizeInByte)
+
+	data, _ := utils.GetDefaultBucketObject(models.GetFileDataKey(fileID), false)
+	assert.Equal(t, string(firstPart)+string(lastPart), data)
+
+	utils.DeleteDefaultBucketObject(models.GetFileDataKey(fileID))
+}
+
+func Test_Init_Upload_Direct_Rejects_Too_Many_Parts(t *testing.T) {
+	accountID, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountID)
+
+	req, _ := createValidInitFileUploadRequest(t, utils.MinMultiPartSize, 2, privateKey)
+	w := httpPostFormRequestHelperForTest(t, InitUploadDirectPath, &req, map[string]string{"metadata": "abc"},
+		map[string]string{"metadata": "abc_file"}, "v2")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func Test_CheckDirectUploadParts(t *testing.T) {
+	file := models.File{EndIndex: 3, FileSizeInByte: 2*utils.MinMultiPartSize + 10}
+	part := func(partNumber, size int64) *s3.Part {
+		return &s3.Part{PartNumber: aws.Int64(partNumber), Size: aws.Int64(size)}
+	}
+
+	missing, err := checkDirectUploadParts(file, []*s3.Part{part(1, utils.MinMultiPartSize), part(3, 10)})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2}, missing)
+
+	missing, err = checkDirectUploadParts(file, []*s3.Part{part(1, utils.MinMultiPartSize), part(2, utils.MinMultiPartSize), part(3, 10)})
+	assert.Nil(t, err)
+	assert.Empty(t, missing)
+
+	_, err = checkDirectUploadParts(file, []*s3.Part{part(1, utils.MinMultiPartSize), part(2, 10), part(3, utils.MinMultiPartSize)})
+	assert.NotNil(t, err)
+
+	_, err = checkDirectUploadParts(file, []*s3.Part{part(1, utils.MinMultiPartSize), part(2, utils.MinMultiPartSize), part(3, 11)})
+	assert.NotNil(t, err)
+
+	_, err = checkDirectUploadParts(file, []*s3.Part{part(4, 10)})
+	assert.NotNil(t, err)
+}
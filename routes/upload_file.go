@@ -2,6 +2,7 @@ package routes
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -13,11 +14,16 @@ import (
 type UploadFileObj struct {
 	FileHandle string `form:"fileHandle" validate:"required,len=64" minLength:"64" maxLength:"64" example:"a deterministically created file handle"`
 	PartIndex  int    `form:"partIndex" validate:"required,gte=1" example:"1"`
+	/*ChunkSha256 is checked against the chunk the node receives when given*/
+	ChunkSha256 string `form:"chunkSha256" validate:"omitempty,len=64,hexadecimal" example:"the hex SHA-256 of the chunk"`
 }
 
+const chunkDataFormName = "chunkData"
+
 type UploadFileReq struct {
 	verification
 	requestBody
+	// Documents the form, the handlers stream the chunk from the request instead of filling it
 	ChunkData     string `formFile:"chunkData" validate:"required" example:"a binary string of the chunk data"`
 	uploadFileObj UploadFileObj
 }
@@ -44,6 +50,7 @@ func (v *UploadFileReq) getObjectRef() interface{} {
 // @description {
 // @description 	"fileHandle": "a deterministically created file handle",
 // @description 	"partIndex": 1,
+// @description 	"chunkSha256": "the hex SHA-256 of the chunk, optional",
 // @description }
 // @Success 200 {object} routes.StatusRes
 // @Failure 403 {object} routes.accountCreateRes
@@ -60,14 +67,16 @@ func uploadFile(c *gin.Context) error {
 
 	request := UploadFileReq{}
 
-	if err := verifyAndParseFormRequest(&request, c); err != nil {
+	chunk, err := verifyAndParseStreamedFormRequest(&request, chunkDataFormName, c)
+	if err != nil {
 		return err
 	}
+	defer chunk.Close()
 
-	return uploadChunk(request, c)
+	return uploadChunk(request, chunk, c)
 }
 
-func uploadChunk(request UploadFileReq, c *gin.Context) error {
+func uploadChunk(request UploadFileReq, chunk *spooledFormFile, c *gin.Context) error {
 	fileID := request.uploadFileObj.FileHandle
 	file, err := models.GetFileById(fileID)
 	if err != nil || len(file.FileID) == 0 {
@@ -79,18 +88,22 @@ func uploadChunk(request UploadFileReq, c *gin.Context) error {
 		return err
 	}
 
-	fileSize := len(request.ChunkData)
 	isLastChunk := request.uploadFileObj.PartIndex == file.EndIndex
-	if !isLastChunk && fileSize < int(utils.MinMultiPartSize) {
-		return BadRequestResponse(c, fmt.Errorf("upload chunk is %v and does not meet min fileSize %v", fileSize, utils.MinMultiPartSize))
+	if !isLastChunk && chunk.size < utils.MinMultiPartSize {
+		return BadRequestResponse(c, fmt.Errorf("upload chunk is %v and does not meet min fileSize %v", chunk.size, utils.MinMultiPartSize))
+	}
+	if sha := request.uploadFileObj.ChunkSha256; sha != "" && !strings.EqualFold(sha, chunk.sha256) {
+		return BadRequestResponse(c, fmt.Errorf("upload chunk has sha256 %v instead of %v", chunk.sha256, sha))
 	}
 
-	completedPart, multipartErr := handleChunkData(file, request.uploadFileObj.PartIndex, []byte(request.ChunkData))
+	completedPart, multipartErr := utils.UploadMultiPartPartFromReader(aws.StringValue(file.AwsObjectKey), aws.StringValue(file.AwsUploadID),
+		chunk.File, chunk.size, request.uploadFileObj.PartIndex, chunk.contentMD5)
 	if multipartErr != nil {
 		return InternalErrorResponse(c, multipartErr)
 	}
 
-	err = models.CreateCompletedUploadIndex(file.FileID, int(*completedPart.PartNumber), *completedPart.ETag)
+	err = models.CreateCompletedUploadIndexWithDigest(file.FileID, int(*completedPart.PartNumber), *completedPart.ETag,
+		chunk.sha256, chunk.size)
 	if err != nil {
 		return InternalErrorResponse(c, err)
 	}
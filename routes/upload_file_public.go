@@ -14,6 +14,7 @@ import (
 // @description {
 // @description 	"fileHandle": "a deterministically created file handle",
 // @description 	"partIndex": 1,
+// @description 	"chunkSha256": "the hex SHA-256 of the chunk, optional",
 // @description }
 // @Success 200 {object} routes.StatusRes
 // @Failure 400 {string} string "bad request, unable to parse request body"
@@ -31,9 +32,11 @@ func uploadFilePublic(c *gin.Context) error {
 
 	request := UploadFileReq{}
 
-	if err := verifyAndParseFormRequest(&request, c); err != nil {
+	chunk, err := verifyAndParseStreamedFormRequest(&request, chunkDataFormName, c)
+	if err != nil {
 		return err
 	}
+	defer chunk.Close()
 
-	return uploadChunk(request, c)
+	return uploadChunk(request, chunk, c)
 }
@@ -2,9 +2,13 @@ package routes
 
 import (
 	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/opacity/storage-node/models"
 	"github.com/opacity/storage-node/utils"
@@ -172,6 +176,62 @@ func Test_Upload_Completed_No_In_Order(t *testing.T) {
 	utils.DeleteDefaultBucketObject(models.GetFileDataKey(fileId))
 }
 
+func Test_Upload_Checks_Chunk_Sha256(t *testing.T) {
+	accountId, privateKey := generateValidateAccountId(t)
+	CreatePaidAccountForTest(t, accountId)
+	fileId := initFileUpload(t, 1, privateKey)
+
+	chunkData := utils.RandHexString(100)
+	uploadObj := ReturnValidUploadFileBodyForTest(t)
+	uploadObj.FileHandle = fileId
+	uploadObj.ChunkSha256 = utils.GenerateFileHandle()
+	request := ReturnValidUploadFileReqForTest(t, uploadObj, privateKey)
+	request.ChunkData = chunkData
+
+	w := UploadFileHelperForTest(t, request, UploadPath, "v1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "sha256")
+
+	sum := sha256.Sum256([]byte(chunkData))
+	uploadObj.ChunkSha256 = hex.EncodeToString(sum[:])
+	request = ReturnValidUploadFileReqForTest(t, uploadObj, privateKey)
+	request.ChunkData = chunkData
+
+	w = UploadFileHelperForTest(t, request, UploadPath, "v1")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	checkStatusReq, _ := createUploadStatusRequest(t, fileId, privateKey)
+	w = httpPostRequestHelperForTest(t, UploadStatusPath, "v1", checkStatusReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	partDigests := []models.PartDigest{{Index: 1, Size: int64(len(chunkData)), Sha256: uploadObj.ChunkSha256}}
+
+	w = httpPostRequestHelperForTest(t, DownloadV3Path, "v3", DownloadFileObj{FileID: fileId})
+	assert.Equal(t, http.StatusOK, w.Code)
+	resV3 := downloadFileV3Res{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resV3))
+	assert.Equal(t, partDigests, resV3.PartDigests)
+	assert.NotEmpty(t, resV3.FileDataUrl)
+	assert.NotEmpty(t, resV3.FileMetadataUrl)
+	assert.True(t, resV3.UrlsExpireAt > time.Now().Unix())
+	// The objects are read through the presigned URLs, they stay private
+	if fake, ok := utils.GetBlobStore().(*utils.S3Fake); ok {
+		cannedAcl, err := fake.GetObjectCannedAcl(models.GetFileDataKey(fileId))
+		assert.Nil(t, err)
+		assert.Equal(t, utils.CannedAcl_Private, cannedAcl)
+	}
+
+	// The older clients add /file and /metadata to the URL
+	w = httpPostRequestHelperForTest(t, DownloadPath, "v1", DownloadFileObj{FileID: fileId})
+	assert.Equal(t, http.StatusOK, w.Code)
+	res := downloadFileRes{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, partDigests, res.PartDigests)
+	assert.Equal(t, models.GetBucketUrl()+fileId, res.FileDownloadUrl)
+
+	utils.DeleteDefaultBucketObject(models.GetFileDataKey(fileId))
+}
+
 func initFileUpload(t *testing.T, endIndex int, privateKey *ecdsa.PrivateKey) string {
 	req, uploadObj := createValidInitFileUploadRequest(t, 123, endIndex, privateKey)
 	form := map[string]string{
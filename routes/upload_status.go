@@ -92,23 +92,37 @@ func checkUploadStatus(c *gin.Context) error {
 		return InternalErrorResponse(c, err)
 	}
 
-	if completedFile, err = models.GetCompletedFileByFileID(completedFile.FileID); err != nil {
-		return InternalErrorResponse(c, err)
+	if err := activateCompletedFile(account, completedFile.FileID, c); err != nil {
+		return err
 	}
 
-	if err := account.UseStorageSpaceInByte(completedFile.FileSizeInByte); err != nil {
-		errS3 := utils.DeleteDefaultBucketObjectKeys(completedFile.FileID)
-		errSql := models.DB.Delete(&completedFile).Error
-		return InternalErrorResponse(c, utils.CollectErrors([]error{err, errS3, errSql}))
-	}
+	return OkResponse(c, fileUploadCompletedRes)
+}
 
-	if err := utils.SetDefaultObjectCannedAcl(models.GetFileDataKey(completedFile.FileID), utils.CannedAcl_PublicRead); err != nil {
+/*activateCompletedFile charges a file that finished uploading to the account's storage. Its objects stay private,
+the v3 download route hands out presigned URLs to them. The SHA-256 announced when the upload started is checked
+later by the sha256 verifier job, which purges the file if its data doesn't match.*/
+func activateCompletedFile(account models.Account, fileID string, c *gin.Context) error {
+	completedFile, err := models.GetCompletedFileByFileID(fileID)
+	if err != nil {
 		return InternalErrorResponse(c, err)
 	}
 
-	if err := utils.SetDefaultObjectCannedAcl(models.GetFileMetadataKey(completedFile.FileID), utils.CannedAcl_PublicRead); err != nil {
-		return InternalErrorResponse(c, err)
+	// A file charged to an account has to be in its owner index, or recomputing the usage of the account
+	// would drop it. The file is discarded when either can't be done, as a retry would find it completed.
+	if err := models.SetFileOwner(completedFile.FileID, account.AccountID); err != nil {
+		return InternalErrorResponse(c, discardCompletedFile(completedFile, err))
+	}
+	if err := account.UseStorageSpaceInByte(completedFile.FileSizeInByte); err != nil {
+		return InternalErrorResponse(c, discardCompletedFile(completedFile, err))
 	}
+	return nil
+}
 
-	return OkResponse(c, fileUploadCompletedRes)
+/*discardCompletedFile deletes the objects, the row and the owner of a file that couldn't be activated*/
+func discardCompletedFile(completedFile models.CompletedFile, err error) error {
+	errS3 := utils.DeleteDefaultBucketObjectKeys(completedFile.FileID)
+	errSql := models.DB.Delete(&completedFile).Error
+	errOwner := models.DeleteFileOwners([]string{completedFile.FileID})
+	return utils.CollectErrors([]error{err, errS3, errSql, errOwner})
 }
@@ -2,15 +2,20 @@ package routes
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/opacity/storage-node/models"
@@ -22,9 +27,17 @@ const (
 	errVerifying                 = "error verifying signature"
 	marshalError                 = "bad request, unable to marshal request body: "
 	notAuthorizedResponse        = "you are not authorized to access or modify this resource"
+	requestTimestampResponse     = "request timestamp is outside of the allowed window, check the clock and sign the request again"
+	requestReplayedResponse      = "request was already used, sign it again with a new timestamp"
 	postFormTag                  = "form"
 	postFormFileTag              = "formFile"
 	bindingTag                   = "binding"
+
+	// Biggest value of a field in a streamed form, files are not limited by it
+	maxFormValueSize = 1 << 20
+
+	// Set once the signature of the request was checked against the replayed ones
+	freshSignatureContextKey = "freshSignature"
 )
 
 type verificationInterface interface {
@@ -42,7 +55,8 @@ type verification struct {
 	// signature without 0x prefix is broken into
 	// R: sig[0:63]
 	// S: sig[64:127]
-	Signature string `json:"signature" form:"signature" validate:"required,len=128" minLength:"128" maxLength:"128" example:"a 128 character string created when you signed the request with your private key or account handle"`
+	// It can be left out when the request carries a session token instead
+	Signature string `json:"signature" form:"signature" validate:"omitempty,len=128" minLength:"128" maxLength:"128" example:"a 128 character string created when you signed the request with your private key or account handle"`
 	PublicKey string `json:"publicKey" form:"publicKey" validate:"required,len=66" minLength:"66" maxLength:"66" example:"a 66-character public key"`
 }
 
@@ -59,6 +73,18 @@ func (v verification) getAccountId(c *gin.Context) (string, error) {
 }
 
 func (v verification) getAccount(c *gin.Context) (models.Account, error) {
+	if account, ok := getSessionAccount(c); ok {
+		return account, nil
+	}
+
+	account, err := v.loadAccount(c)
+	if err == nil {
+		setSessionAccount(c, account)
+	}
+	return account, err
+}
+
+func (v verification) loadAccount(c *gin.Context) (models.Account, error) {
 	accountID, err := v.getAccountId(c)
 	if err != nil {
 		return models.Account{}, err
@@ -67,12 +93,29 @@ func (v verification) getAccount(c *gin.Context) (models.Account, error) {
 	// validate user
 	account, err := models.GetAccountById(accountID)
 	if err != nil || len(account.AccountID) == 0 {
-		return account, AccountNotFoundResponse(c, accountID)
+		return v.getDelegatingAccount(accountID, c)
 	}
 
 	return account, err
 }
 
+/*getDelegatingAccount returns the account the request's key is a delegated key of*/
+func (v verification) getDelegatingAccount(keyID string, c *gin.Context) (models.Account, error) {
+	delegatedKey, ok, err := resolveDelegatedKey(v.PublicKey, c)
+	if err != nil {
+		return models.Account{}, err
+	}
+	if !ok {
+		return models.Account{}, AccountNotFoundResponse(c, keyID)
+	}
+
+	account, err := models.GetAccountById(delegatedKey.AccountID)
+	if err != nil || len(account.AccountID) == 0 {
+		return account, AccountNotFoundResponse(c, delegatedKey.AccountID)
+	}
+	return account, nil
+}
+
 type requestBody struct {
 	RequestBody string `json:"requestBody" form:"requestBody" validate:"required" example:"look at description for example"`
 }
@@ -190,6 +233,157 @@ func readFileFromForm(fileTag string, r *http.Request) (string, error) {
 	return fileBytes.String(), nil
 }
 
+/*spooledFormFile is a file of a multipart form copied to a temporary file as it was received,
+so it never has to be held in memory. Close removes it.*/
+type spooledFormFile struct {
+	*os.File
+	size   int64
+	sha256 string
+	/*contentMD5 is the base64 MD5 of the file, for the blob store to check it got the same bytes*/
+	contentMD5 string
+}
+
+func (f *spooledFormFile) Close() error {
+	return utils.CollectErrors([]error{f.File.Close(), os.Remove(f.Name())})
+}
+
+/*verifyAndParseStreamedFormRequest is verifyAndParseFormRequest for forms carrying a big file. The form is read
+part by part: the fields are set on dest and the file named fileFormName is spooled to a temporary file
+with a small buffer instead of being parsed into memory. When the fields come before the file, the request
+is verified before the file is read.*/
+func verifyAndParseStreamedFormRequest(dest interface{}, fileFormName string, c *gin.Context) (*spooledFormFile, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxRequestSize)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, BadRequestResponse(c, err)
+	}
+
+	var file *spooledFormFile
+	verified := false
+	fail := func(err error) (*spooledFormFile, error) {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(BadRequestResponse(c, err))
+		}
+
+		if part.FormName() == fileFormName {
+			if file != nil {
+				return fail(BadRequestResponse(c, fmt.Errorf("file %v is in the POST form more than once", fileFormName)))
+			}
+			if formRequestIsComplete(dest) {
+				if err := verifyFormRequest(dest, c); err != nil {
+					return fail(err)
+				}
+				verified = true
+			}
+			if file, err = spoolFormFile(part); err != nil {
+				return fail(BadRequestResponse(c, err))
+			}
+			continue
+		}
+
+		value, err := ioutil.ReadAll(io.LimitReader(part, maxFormValueSize+1))
+		if err != nil {
+			return fail(BadRequestResponse(c, err))
+		}
+		if len(value) > maxFormValueSize {
+			return fail(BadRequestResponse(c, fmt.Errorf("form value %v is too big", part.FormName())))
+		}
+		if err := setFormValue(dest, part.FormName(), string(value)); err != nil {
+			return fail(InternalErrorResponse(c, err))
+		}
+	}
+
+	if file == nil {
+		return nil, BadRequestResponse(c, fmt.Errorf("unable to get file %v from POST form", fileFormName))
+	}
+	if !verified {
+		if err := verifyFormRequest(dest, c); err != nil {
+			return fail(err)
+		}
+	}
+	return file, nil
+}
+
+func spoolFormFile(part io.Reader) (*spooledFormFile, error) {
+	tmp, err := ioutil.TempFile("", "formFile")
+	if err != nil {
+		return nil, err
+	}
+	file := &spooledFormFile{File: tmp}
+
+	h, m := sha256.New(), md5.New()
+	if file.size, err = io.Copy(io.MultiWriter(tmp, h, m), part); err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.sha256 = hex.EncodeToString(h.Sum(nil))
+	file.contentMD5 = utils.ContentMD5(m.Sum(nil))
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+/*setFormValue sets the field of dest, or of a struct embedded in dest, whose form tag is formName*/
+func setFormValue(dest interface{}, formName, value string) error {
+	s := reflect.ValueOf(dest).Elem()
+	t := s.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			// Only go 1 level down
+			for j := 0; j < field.Type.NumField(); j++ {
+				if field.Type.Field(j).Tag.Get(postFormTag) == formName {
+					return setStringField(s.Field(i).Field(j), field.Type.Field(j), value)
+				}
+			}
+		}
+		if field.Tag.Get(postFormTag) == formName {
+			return setStringField(s.Field(i), field, value)
+		}
+	}
+	return nil
+}
+
+func setStringField(v reflect.Value, field reflect.StructField, value string) error {
+	if !v.CanSet() {
+		return fmt.Errorf("field is not settable, It should be upper case but has this: %v", field)
+	}
+	v.SetString(value)
+	return nil
+}
+
+func formRequestIsComplete(dest interface{}) bool {
+	i, ok := dest.(verificationInterface)
+	if !ok {
+		return false
+	}
+	ii, ok := dest.(parsableObjectInterface)
+	return ok && i.getVerification().PublicKey != "" && ii.getObjectAsString() != ""
+}
+
+func verifyFormRequest(dest interface{}, c *gin.Context) error {
+	if i, ok := dest.(verificationInterface); ok {
+		if ii, ok := dest.(parsableObjectInterface); ok {
+			return verifyAndParseStringRequest(ii.getObjectAsString(), ii.getObjectRef(), i.getVerification(), c)
+		}
+	}
+	return nil
+}
+
 func verifyAndParseStringRequest(reqAsString string, dest interface{}, verificationData verification, c *gin.Context) error {
 	hash := utils.Hash([]byte(reqAsString))
 
@@ -201,9 +395,89 @@ func verifyAndParseStringRequest(reqAsString string, dest interface{}, verificat
 		return BadRequestResponse(c, fmt.Errorf("bad request, unable to parse request body: %v", err))
 	}
 
+	return verifyRequestIsFresh(dest, verificationData, c)
+}
+
+/*verifyRequestIsFresh rejects signed requests whose timestamp is too far from the server clock,
+and requests whose signature was already seen within that window, so a captured request can't
+be replayed. Requests without a Timestamp field are not checked.*/
+func verifyRequestIsFresh(dest interface{}, verificationData verification, c *gin.Context) error {
+	maxSkew := time.Duration(utils.Env.RequestMaxClockSkewSeconds) * time.Second
+	timestamp, ok := getRequestTimestamp(dest)
+	if !ok || maxSkew <= 0 {
+		return nil
+	}
+
+	// Some clients send milliseconds instead of seconds
+	requestTime := time.Unix(timestamp, 0)
+	if timestamp > 1e12 {
+		requestTime = time.Unix(0, timestamp*int64(time.Millisecond))
+	}
+	if skew := time.Since(requestTime); skew > maxSkew || skew < -maxSkew {
+		return ForbiddenResponse(c, errors.New(requestTimestampResponse))
+	}
+
+	// Session tokens are not single use, only signatures are
+	if verificationData.Signature == "" {
+		return nil
+	}
+	// Handlers parse the same signed body more than once
+	if checked, ok := c.Get(freshSignatureContextKey); ok && checked == verificationData.Signature {
+		return nil
+	}
+
+	signatureHash, err := utils.HashString(verificationData.Signature)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	// Past both ends of the window the timestamp check alone rejects the request. Of concurrent
+	// requests with the same signature only the one that sets the key gets through.
+	firstSeen, err := utils.SetIfAbsent(getSeenSignatureKeyForBadger(signatureHash), strconv.FormatInt(timestamp, 10), 2*maxSkew)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if !firstSeen {
+		return ForbiddenResponse(c, errors.New(requestReplayedResponse))
+	}
+	c.Set(freshSignatureContextKey, verificationData.Signature)
 	return nil
 }
 
+/*verifyStringRequestIsFresh is verifyRequestIsFresh for a request body that is verified without being parsed*/
+func verifyStringRequestIsFresh(reqAsString string, verificationData verification, c *gin.Context) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(reqAsString), &fields); err != nil {
+		return nil
+	}
+	if _, ok := fields["timestamp"]; !ok {
+		return nil
+	}
+
+	stamped := struct {
+		Timestamp int64 `json:"timestamp"`
+	}{}
+	if err := json.Unmarshal([]byte(reqAsString), &stamped); err != nil {
+		return BadRequestResponse(c, fmt.Errorf("bad request, unable to parse request body: %v", err))
+	}
+	return verifyRequestIsFresh(&stamped, verificationData, c)
+}
+
+func getRequestTimestamp(dest interface{}) (int64, bool) {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	field := v.FieldByName("Timestamp")
+	if !field.IsValid() || field.Kind() != reflect.Int64 {
+		return 0, false
+	}
+	return field.Int(), true
+}
+
 func parseStringRequest(reqAsString string, dest interface{}, c *gin.Context) error {
 	if err := utils.ParseStringifiedRequest(reqAsString, dest); err != nil {
 		return BadRequestResponse(c, fmt.Errorf("bad request, unable to parse request body: %v", err))
@@ -222,10 +496,26 @@ func verifyParsedRequest(reqBody interface{}, verificationData verification, c *
 		return err
 	}
 
-	return nil
+	return verifyRequestIsFresh(reqBody, verificationData, c)
 }
 
+/*verifyRequest accepts either a session token from the Authorization header or a signature of the request,
+and charges the rate limit of the account once it is verified*/
 func verifyRequest(hash []byte, verificationData verification, c *gin.Context) error {
+	if err := verifySessionOrSignature(hash, verificationData, c); err != nil {
+		return err
+	}
+	return chargeAccountRateLimit(verificationData.PublicKey, c)
+}
+
+func verifySessionOrSignature(hash []byte, verificationData verification, c *gin.Context) error {
+	if token := getBearerToken(c); token != "" {
+		return verifySessionToken(token, verificationData, c)
+	}
+	if verificationData.Signature == "" {
+		return BadRequestResponse(c, errors.New("signature or session token required"))
+	}
+
 	verified, err := utils.VerifyFromStrings(verificationData.PublicKey, hex.EncodeToString(hash),
 		verificationData.Signature)
 	if err != nil {
@@ -266,20 +556,18 @@ func returnAccountIfVerifiedFromStringRequest(reqAsString string, dest interface
 }
 
 func returnAccountIdWithParsedRequest(reqBody interface{}, verificationData verification, c *gin.Context) (string, error) {
-	hash, err := hashRequestBody(reqBody, c)
-	if err != nil {
+	if err := verifyParsedRequest(reqBody, verificationData, c); err != nil {
 		return "", err
 	}
 
-	return returnAccountId(hash, verificationData, c)
+	return verificationData.getAccountId(c)
 }
 
 func returnAccountIdWithStringRequest(reqAsString string, verificationData verification, c *gin.Context) (string, error) {
-	return returnAccountId(utils.Hash([]byte(reqAsString)), verificationData, c)
-}
-
-func returnAccountId(hash []byte, verificationData verification, c *gin.Context) (string, error) {
-	if err := verifyRequest(hash, verificationData, c); err != nil {
+	if err := verifyRequest(utils.Hash([]byte(reqAsString)), verificationData, c); err != nil {
+		return "", err
+	}
+	if err := verifyStringRequestIsFresh(reqAsString, verificationData, c); err != nil {
 		return "", err
 	}
 
@@ -307,6 +595,10 @@ func getPermissionHashV2KeyForBadger(prefix string) string {
 	return prefix + "_permissionHash"
 }
 
+func getSeenSignatureKeyForBadger(signatureHash string) string {
+	return signatureHash + "_seenSignature"
+}
+
 func getIsPublicV2KeyForBadger(prefix string) string {
 	return prefix + "_isPublic"
 }
@@ -323,6 +615,21 @@ func verifyPermissions(publicKey, key, expectedPermissionHash string, c *gin.Con
 	if err != nil {
 		return err
 	}
+	if permissionHash == expectedPermissionHash {
+		return nil
+	}
+
+	// The key may be a delegated key of the owner
+	delegatedKey, ok, err := resolveDelegatedKey(publicKey, c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ForbiddenResponse(c, errors.New(notAuthorizedResponse))
+	}
+	if permissionHash, err = getPermissionHash(delegatedKey.AccountPublicKey, key, c); err != nil {
+		return err
+	}
 	if permissionHash != expectedPermissionHash {
 		return ForbiddenResponse(c, errors.New(notAuthorizedResponse))
 	}
@@ -334,8 +641,23 @@ func verifyPermissionsV2(publicKey []byte, key []byte, expectedPermissionHash st
 		return ForbiddenResponse(c, errors.New("resource is ineligible for modification"))
 	}
 	permissionHash := getPermissionHashV2(publicKey, key)
+	if permissionHash == expectedPermissionHash {
+		return nil
+	}
 
-	if permissionHash != expectedPermissionHash {
+	// The key may be a delegated key of the owner
+	delegatedKey, ok, err := resolveDelegatedKey(hex.EncodeToString(publicKey), c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ForbiddenResponse(c, errors.New(notAuthorizedResponse))
+	}
+	ownerPublicKey, err := hex.DecodeString(delegatedKey.AccountPublicKey)
+	if err != nil {
+		return InternalErrorResponse(c, err)
+	}
+	if getPermissionHashV2(ownerPublicKey, key) != expectedPermissionHash {
 		return ForbiddenResponse(c, errors.New(notAuthorizedResponse))
 	}
 	return nil
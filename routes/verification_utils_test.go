@@ -2,12 +2,19 @@ package routes
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/opacity/storage-node/utils"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,6 +30,11 @@ type testVerifiedRequest struct {
 	requestObject testRequestObject
 }
 
+type testTimestampedRequestObject struct {
+	Data      string `json:"data"`
+	Timestamp int64  `json:"timestamp"`
+}
+
 type testSetRequest struct {
 	StrValue   string `form:"str"`
 	FileObject string `formFile:"file"`
@@ -144,3 +156,187 @@ func Test_NoRequiredFileFormNoExist(t *testing.T) {
 
 	assert.Nil(t, err)
 }
+
+func Test_verifyAndParseStringRequest_Rejects_Replayed_Request(t *testing.T) {
+	utils.InitKvStore()
+
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", nil)
+	dest := testTimestampedRequestObject{}
+	assert.Nil(t, verifyAndParseStringRequest(b.RequestBody, &dest, v, c))
+	assert.Equal(t, obj, dest)
+
+	// Parsing the body again while handling the same request is fine
+	assert.Nil(t, verifyAndParseStringRequest(b.RequestBody, &dest, v, c))
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/", nil)
+	assert.NotNil(t, verifyAndParseStringRequest(b.RequestBody, &dest, v, c))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), requestReplayedResponse)
+}
+
+func Test_verifyAndParseStringRequest_Rejects_Concurrent_Replays(t *testing.T) {
+	utils.InitKvStore()
+
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	var wg sync.WaitGroup
+	var accepted int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("POST", "/", nil)
+			dest := testTimestampedRequestObject{}
+			if verifyAndParseStringRequest(b.RequestBody, &dest, v, c) == nil {
+				atomic.AddInt32(&accepted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), accepted)
+}
+
+func Test_returnAccountIdWithStringRequest_Rejects_Replayed_Request(t *testing.T) {
+	utils.InitKvStore()
+
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().Unix(),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", nil)
+	_, err := returnAccountIdWithStringRequest(b.RequestBody, v, c)
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/", nil)
+	_, err = returnAccountIdWithStringRequest(b.RequestBody, v, c)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), requestReplayedResponse)
+}
+
+func Test_verifyAndParseStringRequest_Rejects_Stale_Timestamp(t *testing.T) {
+	utils.InitKvStore()
+
+	maxSkew := time.Duration(utils.Env.RequestMaxClockSkewSeconds) * time.Second
+	for _, timestamp := range []int64{time.Now().Add(-2 * maxSkew).Unix(), time.Now().Add(2 * maxSkew).Unix()} {
+		obj := testTimestampedRequestObject{
+			Data:      "some body message",
+			Timestamp: timestamp,
+		}
+		v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/", nil)
+		dest := testTimestampedRequestObject{}
+		assert.NotNil(t, verifyAndParseStringRequest(b.RequestBody, &dest, v, c))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), requestTimestampResponse)
+	}
+}
+
+func Test_verifyAndParseStringRequest_Accepts_Millisecond_Timestamp(t *testing.T) {
+	utils.InitKvStore()
+
+	obj := testTimestampedRequestObject{
+		Data:      "some body message",
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", nil)
+	dest := testTimestampedRequestObject{}
+	assert.Nil(t, verifyAndParseStringRequest(b.RequestBody, &dest, v, c))
+}
+
+func streamedFormContextForTest(t *testing.T, v verification, b requestBody, file []byte) (*gin.Context, *httptest.ResponseRecorder) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("signature", v.Signature)
+	mw.WriteField("publicKey", v.PublicKey)
+	mw.WriteField("requestBody", b.RequestBody)
+	mw.WriteField("str", "strV")
+	if file != nil {
+		w, _ := mw.CreateFormFile("file", "test")
+		w.Write(file)
+	}
+	mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/", body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	return c, w
+}
+
+func Test_verifyAndParseStreamedFormRequest(t *testing.T) {
+	utils.InitKvStore()
+
+	obj := testRequestObject{
+		Data: "some body message",
+	}
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, obj)
+	data := bytes.Repeat([]byte("test"), 10000)
+	c, _ := streamedFormContextForTest(t, v, b, data)
+
+	request := testVerifiedRequest{}
+	file, err := verifyAndParseStreamedFormRequest(&request, "file", c)
+	assert.Nil(t, err)
+	defer file.Close()
+
+	assert.Equal(t, "strV", request.StrValue)
+	assert.Equal(t, "", request.FileObject)
+	assert.Equal(t, obj.Data, request.requestObject.Data)
+	assert.Equal(t, int64(len(data)), file.size)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), file.sha256)
+	spooled, err := ioutil.ReadAll(file)
+	assert.Nil(t, err)
+	assert.Equal(t, data, spooled)
+}
+
+func Test_verifyAndParseStreamedFormRequest_Rejects_Before_Reading_File(t *testing.T) {
+	utils.InitKvStore()
+
+	v, b, _, _ := returnInvalidVerificationAndRequestBody(t, testRequestObject{Data: "some body message"})
+	c, w := streamedFormContextForTest(t, v, b, []byte("test"))
+
+	request := testVerifiedRequest{}
+	file, err := verifyAndParseStreamedFormRequest(&request, "file", c)
+	assert.NotNil(t, err)
+	assert.Nil(t, file)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func Test_verifyAndParseStreamedFormRequest_Missing_File(t *testing.T) {
+	utils.InitKvStore()
+
+	v, b, _ := returnValidVerificationAndRequestBodyWithRandomPrivateKey(t, testRequestObject{Data: "some body message"})
+	c, w := streamedFormContextForTest(t, v, b, nil)
+
+	request := testVerifiedRequest{}
+	file, err := verifyAndParseStreamedFormRequest(&request, "file", c)
+	assert.NotNil(t, err)
+	assert.Nil(t, file)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
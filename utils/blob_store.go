@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	/*BlobStoreBackendS3 stores objects in the AWS S3 bucket named by AWS_BUCKET_NAME*/
+	BlobStoreBackendS3 = "s3"
+
+	/*BlobStoreBackendLocal stores objects as files under LOCAL_BLOB_STORE_DIR*/
+	BlobStoreBackendLocal = "local"
+)
+
+/*BlobStore is the set of object operations the node performs against its bucket.
+Every implementation is bound to a single bucket. The S3 request and response types
+are used as the common vocabulary so callers don't need to change between backends.*/
+type BlobStore interface {
+	HeadObject(key string) (*s3.HeadObjectOutput, error)
+	GetObject(key, downloadRange string) (*s3.GetObjectOutput, error)
+	PutObject(key string, body io.ReadSeeker, contentType string) error
+	DeleteObject(key string) error
+	DeleteObjects(keys []string) error
+	ListObjectPages(prefix string, it ObjectIterator) error
+
+	SetObjectCannedAcl(key, cannedAcl string) error
+
+	StartMultipartUpload(key, fileType string) (*s3.CreateMultipartUploadOutput, error)
+	/*UploadPartOfMultiPartUpload rejects the part with a BadDigest error if contentMD5, the base64 MD5 sent
+	as the Content-MD5 header, is given and doesn't match the part*/
+	UploadPartOfMultiPartUpload(key, uploadID string, body io.ReadSeeker, size int64, partNumber int, contentMD5 string) (*s3.CompletedPart, error)
+	FinishMultipartUpload(key, uploadID string, completedParts []*s3.CompletedPart) (*s3.CompleteMultipartUploadOutput, error)
+	CancelMultipartUpload(key, uploadID string) error
+	ListParts(key, uploadID string) ([]*s3.Part, error)
+	PresignUploadPart(key, uploadID string, partNumber int, expires time.Duration) (string, error)
+	PresignGetObject(key string, expires time.Duration) (string, error)
+
+	PutBucketLifecycleConfiguration(rules []*s3.LifecycleRule) error
+	GetBucketLifecycleConfiguration() ([]*s3.LifecycleRule, error)
+}
+
+/*lifecycleEmulator is implemented by backends that can't apply lifecycle rules on their own*/
+type lifecycleEmulator interface {
+	RunLifecycle(now time.Time) error
+}
+
+var blobStore BlobStore
+
+/*newBlobStore creates the BlobStore selected by Env.BlobStoreBackend*/
+func newBlobStore() {
+	switch Env.BlobStoreBackend {
+	case BlobStoreBackendS3, "":
+		newS3Session()
+		blobStore = svc
+	case BlobStoreBackendLocal:
+		dir := Env.LocalBlobStoreDir
+		if IsTestEnv() {
+			dir = getLocalBlobDirTest()
+		}
+		store, err := newLocalBlobStore(dir)
+		PanicOnError(err)
+		blobStore = store
+	default:
+		PanicOnError(fmt.Errorf("unknown blob store backend %q", Env.BlobStoreBackend))
+	}
+}
+
+/*SetBlobStore replaces the BlobStore used by the bucket helpers. Mostly useful for tests.*/
+func SetBlobStore(store BlobStore) {
+	blobStore = store
+	cachedData.Clear()
+}
+
+/*GetBlobStore returns the BlobStore currently in use*/
+func GetBlobStore() BlobStore {
+	return blobStore
+}
+
+/*IsBlobStoreEnabled returns whether a BlobStore has been set up*/
+func IsBlobStoreEnabled() bool {
+	return blobStore != nil
+}
+
+/*RunBlobStoreLifecycle applies the bucket lifecycle rules for backends that don't do it themselves*/
+func RunBlobStoreLifecycle() error {
+	if l, ok := blobStore.(lifecycleEmulator); ok {
+		return l.RunLifecycle(time.Now())
+	}
+	return nil
+}
+
+/*BlobStoreEmulatesLifecycle returns whether the node has to apply the lifecycle rules itself*/
+func BlobStoreEmulatesLifecycle() bool {
+	_, ok := blobStore.(lifecycleEmulator)
+	return ok
+}
+
+/*GetBlobStoreBaseURL returns the URL the objects of the bucket are served from, ending with a slash*/
+func GetBlobStoreBaseURL() string {
+	if Env.BlobStoreBackend == BlobStoreBackendLocal {
+		return strings.TrimSuffix(Env.LocalBlobStoreURL, "/") + "/"
+	}
+	return fmt.Sprintf("https://s3.%s.amazonaws.com/%s/", Env.AwsRegion, Env.BucketName)
+}
@@ -21,11 +21,11 @@ const defaultStripeRetentionDays = 30
 const TestNetworkID = 999
 
 const DefaultPlansJson = `{
-	"10":{"name":"Free","cost":0,"costInUSD":0.00,"storageInGB":10,"maxFolders":200,"maxMetadataSizeInMB":20},
-	"128":{"name":"Basic","cost":2,"costInUSD":39.99,"storageInGB":128,"maxFolders":2000,"maxMetadataSizeInMB":200},
-	"1024":{"name":"Professional","cost":16,"costInUSD":99.99,"storageInGB":1024,"maxFolders":16000,"maxMetadataSizeInMB":1600},
-	"2048":{"name":"Business","cost":32,"costInUSD":119.99,"storageInGB":2048,"maxFolders":32000,"maxMetadataSizeInMB":3200},
-	"10000":{"name":"Custom10TB","cost":150000,"costInUSD":550.00,"storageInGB":10000,"maxFolders":156000,"maxMetadataSizeInMB":15600}
+	"10":{"name":"Free","cost":0,"costInUSD":0.00,"storageInGB":10,"maxFolders":200,"maxMetadataSizeInMB":20,"maxEgressGBPerMonth":20},
+	"128":{"name":"Basic","cost":2,"costInUSD":39.99,"storageInGB":128,"maxFolders":2000,"maxMetadataSizeInMB":200,"maxEgressGBPerMonth":256},
+	"1024":{"name":"Professional","cost":16,"costInUSD":99.99,"storageInGB":1024,"maxFolders":16000,"maxMetadataSizeInMB":1600,"maxEgressGBPerMonth":2048},
+	"2048":{"name":"Business","cost":32,"costInUSD":119.99,"storageInGB":2048,"maxFolders":32000,"maxMetadataSizeInMB":3200,"maxEgressGBPerMonth":4096},
+	"10000":{"name":"Custom10TB","cost":150000,"costInUSD":550.00,"storageInGB":10000,"maxFolders":156000,"maxMetadataSizeInMB":15600,"maxEgressGBPerMonth":20000}
 }`
 
 type PlanInfo struct {
@@ -35,6 +35,7 @@ type PlanInfo struct {
 	StorageInGB         int     `json:"storageInGB"`
 	MaxFolders          int     `json:"maxFolders"`
 	MaxMetadataSizeInMB int64   `json:"maxMetadataSizeInMB"`
+	MaxEgressGBPerMonth int     `json:"maxEgressGBPerMonth"` // 0 doesn't limit downloads, see verifyFileOwnerEgressQuota for what it limits
 }
 
 type PlanResponseType map[int]PlanInfo
@@ -66,6 +67,84 @@ type StorageNodeEnv struct {
 	BucketName string `env:"AWS_BUCKET_NAME,notEmpty"`
 	AwsRegion  string `env:"AWS_REGION,notEmpty"`
 
+	// Which object store backend to use for files, metadata and thumbnails
+	BlobStoreBackend string `env:"BLOB_STORE_BACKEND" envDefault:"s3"`
+
+	// Where the local blob store keeps its objects and the URL they are served from
+	LocalBlobStoreDir string `env:"LOCAL_BLOB_STORE_DIR" envDefault:"/var/lib/storage-node/blobs"`
+	LocalBlobStoreURL string `env:"LOCAL_BLOB_STORE_URL" envDefault:"http://localhost:3000/blobs/"`
+
+	// Where metadata is kept (badger or redis). Use redis to share it between several nodes
+	KVStoreBackend string `env:"KV_STORE_BACKEND" envDefault:"badger"`
+	RedisAddress   string `env:"REDIS_ADDRESS" envDefault:"localhost:6379"`
+	RedisPassword  string `env:"REDIS_PASSWORD" envDefault:""`
+	RedisDB        int    `env:"REDIS_DB" envDefault:"0"`
+
+	// Backups of the badger kv store written to the bucket
+	KvBackupInterval  string `env:"KV_BACKUP_INTERVAL" envDefault:"@daily"`
+	KvBackupFullEvery int    `env:"KV_BACKUP_FULL_EVERY" envDefault:"6"`
+	KvBackupKeepFull  int    `env:"KV_BACKUP_KEEP_FULL" envDefault:"4"`
+
+	// Value log files with more stale data than this ratio are rewritten by the badger GC job,
+	// which alerts when the kv store grew more than KvGrowthAlertPercent since its last run
+	KvGCDiscardRatio     float64 `env:"KV_GC_DISCARD_RATIO" envDefault:"0.5"`
+	KvGrowthAlertPercent int     `env:"KV_GROWTH_ALERT_PERCENT" envDefault:"25"`
+
+	// How far the timestamp of a signed request may be from the server clock, 0 disables the
+	// timestamp and replayed signature checks
+	RequestMaxClockSkewSeconds int `env:"REQUEST_MAX_CLOCK_SKEW_SECONDS" envDefault:"300"`
+
+	// How long the session tokens exchanged for a signed request stay valid
+	SessionTokenTTLMinutes int `env:"SESSION_TOKEN_TTL_MINUTES" envDefault:"60"`
+
+	// How long the presigned part URLs of an upload sent straight to the bucket can be used
+	DirectUploadURLTTLMinutes int `env:"DIRECT_UPLOAD_URL_TTL_MINUTES" envDefault:"1440"`
+
+	// How long the presigned URLs the download route returns for the objects of a file can be used
+	DownloadURLTTLMinutes int `env:"DOWNLOAD_URL_TTL_MINUTES" envDefault:"60"`
+
+	// How long the access token given for the password of a protected public share can be used, and how
+	// many wrong passwords a minute each share accepts
+	PublicShareAccessTokenTTLMinutes     int `env:"PUBLIC_SHARE_ACCESS_TOKEN_TTL_MINUTES" envDefault:"30"`
+	PublicSharePasswordAttemptsPerMinute int `env:"PUBLIC_SHARE_PASSWORD_ATTEMPTS_PER_MINUTE" envDefault:"5"`
+
+	// How many completed files the integrity scrubber checks each time it runs
+	IntegrityScrubFilesPerRun int `env:"INTEGRITY_SCRUB_FILES_PER_RUN" envDefault:"10000"`
+
+	// Whether the usage recomputation job corrects the counters of accounts, not only reports them.
+	// Accounts that may own files from before the ownership index are never corrected, they are
+	// indexed when they renew or upgrade.
+	UsageRecomputeRepair bool `env:"USAGE_RECOMPUTE_REPAIR" envDefault:"false"`
+
+	// How many days deleted files stay in the trash bin, where they can be restored, before they are purged.
+	// 0 deletes files right away
+	TrashRetentionDays int `env:"TRASH_RETENTION_DAYS" envDefault:"30"`
+
+	// Local copy of the S3 server access logs of the bucket, read to count the bytes it served towards the
+	// egress usage of accounts. Empty disables the ingestion, then only the downloads streamed by the node count
+	// towards the egress quotas
+	S3AccessLogPath string `env:"S3_ACCESS_LOG_PATH" envDefault:""`
+
+	// Comma separated IAM ARNs or canonical user IDs the node signs its own requests to the bucket with, as they
+	// appear in the access logs. The node reading objects, to stream them or make them public, isn't a download
+	S3AccessLogNodeRequesters string `env:"S3_ACCESS_LOG_NODE_REQUESTERS" envDefault:""`
+
+	// Requests a minute allowed for each verified account on each group of routes. 0 disables the limit of a group
+	RateLimitDefaultPerMinute     int `env:"RATE_LIMIT_DEFAULT_PER_MINUTE" envDefault:"120"`
+	RateLimitMetadataPerMinute    int `env:"RATE_LIMIT_METADATA_PER_MINUTE" envDefault:"600"`
+	RateLimitUploadPerMinute      int `env:"RATE_LIMIT_UPLOAD_PER_MINUTE" envDefault:"1200"`
+	RateLimitDownloadPerMinute    int `env:"RATE_LIMIT_DOWNLOAD_PER_MINUTE" envDefault:"300"`
+	RateLimitPublicSharePerMinute int `env:"RATE_LIMIT_PUBLIC_SHARE_PER_MINUTE" envDefault:"300"`
+	// Every client IP is also limited to this many times the limit of the group, as clients behind a NAT
+	// share it. 0 disables the per IP limits
+	RateLimitPerIPMultiplier int `env:"RATE_LIMIT_PER_IP_MULTIPLIER" envDefault:"10"`
+	// Comma separated IPs or CIDRs of the proxies in front of the node, the client IP is only taken from the
+	// X-Forwarded-For header of requests they sent. Empty when the node is reached directly
+	TrustedProxies string `env:"TRUSTED_PROXIES" envDefault:""`
+
+	// Run the tests against the real AWS bucket instead of the in-memory fake
+	TestWithRealBlobStore bool `env:"TEST_WITH_REAL_BLOB_STORE" envDefault:"false"`
+
 	// How long the user has to pay for their account before we delete it
 	AccountRetentionDays int `env:"ACCOUNT_RETENTION_DAYS" envDefault:"7"`
 
@@ -162,11 +241,15 @@ func SetTesting(filenames ...string) {
 
 	services.InitStripe(Env.StripeKeyTest)
 	runInitializations()
+
+	if Env.BlobStoreBackend != BlobStoreBackendLocal && !Env.TestWithRealBlobStore {
+		SetBlobStore(testS3Fake)
+	}
 }
 
 func runInitializations() {
 	InitKvStore()
-	newS3Session()
+	newBlobStore()
 }
 
 /*IsTestEnv returns whether we are in the test environment*/
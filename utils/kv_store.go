@@ -2,7 +2,9 @@ package utils
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"strconv"
 	"time"
 
 	"os"
@@ -12,12 +14,24 @@ import (
 
 const badgerDirProd = "/var/lib/badger/prod"
 
+const (
+	/*KVStoreBackendBadger keeps the K:V pairs in an embedded badger DB*/
+	KVStoreBackendBadger = "badger"
+
+	/*KVStoreBackendRedis keeps the K:V pairs in a redis compatible server shared by every node*/
+	KVStoreBackendRedis = "redis"
+)
+
 /*TestValueTimeToLive is some default value we can use in unit
 tests for K:V pairs in badger*/
 const TestValueTimeToLive = 1 * time.Minute
 
+/*ErrKeyNotFound is returned by GetValueFromKV when the key does not exist, whatever the backend*/
+var ErrKeyNotFound = badger.ErrKeyNotFound
+
 // Singleton DB
 var badgerDB *badger.DB
+var kvStore KVStore
 var dbNoInitError error
 var badgerDirTest string
 
@@ -27,6 +41,28 @@ type KVPairs map[string]string
 /*KVKeys is a type.  An array of key strings*/
 type KVKeys []string
 
+/*KVStore is where metadata, permission hashes and the public flags of metadata are kept*/
+type KVStore interface {
+	/*Get returns ErrKeyNotFound if the key does not exist*/
+	Get(key string) (value string, expirationTime time.Time, err error)
+	/*BatchGet skips the keys that do not exist*/
+	BatchGet(ks *KVKeys) (*KVPairs, error)
+	/*BatchSet writes the pairs in one transaction. Badger commits a batch too big for a single
+	transaction in several, so callers needing atomicity should keep batches small.*/
+	BatchSet(kvs *KVPairs, ttl time.Duration) error
+	/*SetIfAbsent atomically sets the key only if it does not exist yet, and returns whether it did*/
+	SetIfAbsent(key, value string, ttl time.Duration) (bool, error)
+	/*Increment atomically adds by to the integer value of the key and returns the sum. A missing key is created
+	with the ttl, which an existing key keeps as it was*/
+	Increment(key string, by int64, ttl time.Duration) (int64, error)
+	BatchDelete(ks *KVKeys) error
+	/*RemoveAll deletes every K:V pair and closes the store*/
+	RemoveAll() error
+	Close() error
+}
+
+type badgerKVStore struct{}
+
 func init() {
 	dbNoInitError = errors.New("badgerDB not initialized, Call InitKvStore() first")
 
@@ -35,6 +71,30 @@ func init() {
 
 /*InitKvStore returns db so that caller can call CloseKvStore to close it when it is done.*/
 func InitKvStore() (err error) {
+	if kvStore != nil {
+		return nil
+	}
+
+	switch Env.KVStoreBackend {
+	case KVStoreBackendBadger, "":
+		err = initBadger()
+		if err == nil {
+			kvStore = badgerKVStore{}
+		}
+	case KVStoreBackendRedis:
+		var store *redisKVStore
+		store, err = newRedisKVStore(Env.RedisAddress, Env.RedisPassword, Env.RedisDB)
+		if err == nil {
+			kvStore = store
+		}
+	default:
+		err = fmt.Errorf("unknown kv store backend %q", Env.KVStoreBackend)
+	}
+	LogIfError(err, nil)
+	return err
+}
+
+func initBadger() (err error) {
 	if badgerDB != nil {
 		return nil
 	}
@@ -49,25 +109,108 @@ func InitKvStore() (err error) {
 	}
 
 	badgerDB, err = badger.Open(opts)
-	LogIfError(err, nil)
 	return err
 }
 
 /*CloseKvStore closes the db.*/
 func CloseKvStore() error {
-	if badgerDB == nil {
+	if kvStore == nil {
 		return nil
 	}
 
-	err := badgerDB.Close()
+	err := kvStore.Close()
 	LogIfError(err, nil)
-	badgerDB = nil
+	kvStore = nil
 	return err
 }
 
 /*RemoveAllKvStoreData removes all the data. Caller should call InitKvStore() again to create a new one.*/
 func RemoveAllKvStoreData() error {
-	if err := CloseKvStore(); err != nil {
+	store := kvStore
+	if store == nil {
+		if Env.KVStoreBackend == KVStoreBackendRedis {
+			return dbNoInitError
+		}
+		store = badgerKVStore{}
+	}
+
+	err := store.RemoveAll()
+	kvStore = nil
+	return err
+}
+
+/*GetKvStore returns the KVStore in use. If not call InitKvStore(), it will return nil*/
+func GetKvStore() KVStore {
+	return kvStore
+}
+
+/*GetBadgerDb returns the underlying the database. If not call InitKvStore(), it will return nil*/
+func GetBadgerDb() *badger.DB {
+	return badgerDB
+}
+
+/*GetValueFromKV gets a single value from the provided key*/
+func GetValueFromKV(key string) (value string, expirationTime time.Time, err error) {
+	if kvStore == nil {
+		return value, time.Now(), dbNoInitError
+	}
+	return kvStore.Get(key)
+}
+
+/*BatchGet returns KVPairs for a set of keys. It won't treat Key missing as error.*/
+func BatchGet(ks *KVKeys) (kvs *KVPairs, err error) {
+	if kvStore == nil {
+		return &KVPairs{}, dbNoInitError
+	}
+	return kvStore.BatchGet(ks)
+}
+
+/*BatchSet updates a set of KVPairs. Return error if any fails.*/
+func BatchSet(kvs *KVPairs, ttl time.Duration) error {
+	if kvStore == nil {
+		return dbNoInitError
+	}
+	return kvStore.BatchSet(kvs, getTTL(ttl))
+}
+
+/*SetIfAbsent sets the key only if it does not exist yet and returns whether it did. Of concurrent
+callers setting the same key, only one gets true.*/
+func SetIfAbsent(key, value string, ttl time.Duration) (bool, error) {
+	if kvStore == nil {
+		return false, dbNoInitError
+	}
+	return kvStore.SetIfAbsent(key, value, getTTL(ttl))
+}
+
+/*Increment adds by to the counter at key and returns its new value, concurrent callers each get their own
+value. The counter expires ttl after it was created.*/
+func Increment(key string, by int64, ttl time.Duration) (int64, error) {
+	if kvStore == nil {
+		return 0, dbNoInitError
+	}
+	return kvStore.Increment(key, by, getTTL(ttl))
+}
+
+/*BatchDelete deletes a set of KVKeys, Return error if any fails.*/
+func BatchDelete(ks *KVKeys) error {
+	if kvStore == nil {
+		return dbNoInitError
+	}
+	return kvStore.BatchDelete(ks)
+}
+
+func (b badgerKVStore) Close() error {
+	if badgerDB == nil {
+		return nil
+	}
+
+	err := badgerDB.Close()
+	badgerDB = nil
+	return err
+}
+
+func (b badgerKVStore) RemoveAll() error {
+	if err := b.Close(); err != nil {
 		return err
 	}
 
@@ -82,13 +225,7 @@ func RemoveAllKvStoreData() error {
 	return err
 }
 
-/*GetBadgerDb returns the underlying the database. If not call InitKvStore(), it will return nil*/
-func GetBadgerDb() *badger.DB {
-	return badgerDB
-}
-
-/*GetValueFromKV gets a single value from the provided key*/
-func GetValueFromKV(key string) (value string, expirationTime time.Time, err error) {
+func (b badgerKVStore) Get(key string) (value string, expirationTime time.Time, err error) {
 	expirationTime = time.Now()
 	if badgerDB == nil {
 		return value, expirationTime, dbNoInitError
@@ -132,8 +269,7 @@ func GetValueFromKV(key string) (value string, expirationTime time.Time, err err
 	return
 }
 
-/*BatchGet returns KVPairs for a set of keys. It won't treat Key missing as error.*/
-func BatchGet(ks *KVKeys) (kvs *KVPairs, err error) {
+func (b badgerKVStore) BatchGet(ks *KVKeys) (kvs *KVPairs, err error) {
 	kvs = &KVPairs{}
 	if badgerDB == nil {
 		return kvs, dbNoInitError
@@ -183,9 +319,7 @@ func BatchGet(ks *KVKeys) (kvs *KVPairs, err error) {
 	return
 }
 
-/*BatchSet updates a set of KVPairs. Return error if any fails.*/
-func BatchSet(kvs *KVPairs, ttl time.Duration) error {
-	ttl = getTTL(ttl)
+func (b badgerKVStore) BatchSet(kvs *KVPairs, ttl time.Duration) error {
 	if badgerDB == nil {
 		return dbNoInitError
 	}
@@ -227,8 +361,103 @@ func BatchSet(kvs *KVPairs, ttl time.Duration) error {
 	return err
 }
 
-/*BatchDelete deletes a set of KVKeys, Return error if any fails.*/
-func BatchDelete(ks *KVKeys) error {
+func (b badgerKVStore) SetIfAbsent(key, value string, ttl time.Duration) (bool, error) {
+	if badgerDB == nil {
+		return false, dbNoInitError
+	}
+	if key == "" {
+		return false, errors.New("SetIfAbsent does not accept key as empty string")
+	}
+
+	txn := badgerDB.NewTransaction(true)
+	defer txn.Discard()
+
+	_, err := txn.Get([]byte(key))
+	if err == nil {
+		return false, nil
+	}
+	if err != badger.ErrKeyNotFound {
+		LogIfError(err, nil)
+		return false, err
+	}
+	if err := txn.SetEntry(badger.NewEntry([]byte(key), []byte(value)).WithTTL(ttl)); err != nil {
+		LogIfError(err, nil)
+		return false, err
+	}
+
+	// The read above makes the commit fail if another transaction set the key in the meantime
+	err = txn.Commit()
+	if err == badger.ErrConflict {
+		return false, nil
+	}
+	LogIfError(err, nil)
+	return err == nil, err
+}
+
+// How many times Increment retries a transaction another one conflicted with
+const badgerIncrementRetries = 50
+
+func (b badgerKVStore) Increment(key string, by int64, ttl time.Duration) (int64, error) {
+	if badgerDB == nil {
+		return 0, dbNoInitError
+	}
+	if key == "" {
+		return 0, errors.New("Increment does not accept key as empty string")
+	}
+
+	for retry := 0; ; retry++ {
+		sum, err := b.increment(key, by, ttl)
+		if err == badger.ErrConflict && retry < badgerIncrementRetries {
+			continue
+		}
+		LogIfError(err, nil)
+		return sum, err
+	}
+}
+
+func (b badgerKVStore) increment(key string, by int64, ttl time.Duration) (int64, error) {
+	txn := badgerDB.NewTransaction(true)
+	defer txn.Discard()
+
+	sum := by
+	item, err := txn.Get([]byte(key))
+	switch err {
+	case nil:
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return 0, err
+		}
+		count, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		sum += count
+		// The key keeps the expiration it was created with
+		ttl = 0
+		if expiresAt := item.ExpiresAt(); expiresAt > 0 {
+			// Expirations are in seconds, a key about to expire still gets one
+			ttl = time.Until(time.Unix(int64(expiresAt), 0))
+			if ttl < time.Second {
+				ttl = time.Second
+			}
+		}
+	case badger.ErrKeyNotFound:
+	default:
+		return 0, err
+	}
+
+	entry := badger.NewEntry([]byte(key), []byte(strconv.FormatInt(sum, 10)))
+	if ttl > 0 {
+		entry = entry.WithTTL(ttl)
+	}
+	if err := txn.SetEntry(entry); err != nil {
+		return 0, err
+	}
+	// The read above makes the commit fail if another transaction changed the key in the meantime
+	return sum, txn.Commit()
+}
+
+func (b badgerKVStore) BatchDelete(ks *KVKeys) error {
 	if badgerDB == nil {
 		return dbNoInitError
 	}
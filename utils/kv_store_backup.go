@@ -0,0 +1,292 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+/*KvBackupPrefix is where the kv store backups are kept in the bucket*/
+const KvBackupPrefix = "kv_backups/"
+
+const (
+	kvBackupFull        = "full"
+	kvBackupIncremental = "incr"
+	kvBackupExtension   = ".bak"
+
+	// How many writes Load keeps in flight while restoring
+	kvRestoreMaxPendingWrites = 256
+)
+
+var errNotBadgerKvStore = errors.New("only supported for the badger kv store")
+
+/*KvBackup describes a backup stored in the bucket. A full backup holds every key,
+an incremental one only the keys written since the backup whose NextSince is Since.*/
+type KvBackup struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"createdAt"`
+	Full      bool      `json:"full"`
+	Since     uint64    `json:"since"`
+	NextSince uint64    `json:"nextSince"`
+	Size      int64     `json:"size"`
+}
+
+/*BackupKvStore streams every entry written since the given version into w while the node
+keeps running. It returns the since to pass for the next incremental backup.*/
+func BackupKvStore(w io.Writer, since uint64) (uint64, error) {
+	if badgerDB == nil {
+		return 0, errNotBadgerKvStore
+	}
+	// Backup returns the version of the last entry it wrote, 0 when it wrote none
+	version, err := badgerDB.Backup(w, since)
+	if err != nil || version == 0 {
+		return since, err
+	}
+	return version + 1, nil
+}
+
+/*RestoreKvStore loads a backup written by BackupKvStore. Entries keep their version, so a key
+written after the backup was taken is not reverted; restore into an empty store to get the
+exact state of the backup. Incremental backups must be loaded in order after their full backup.*/
+func RestoreKvStore(r io.Reader) error {
+	if badgerDB == nil {
+		return errNotBadgerKvStore
+	}
+	return badgerDB.Load(r, kvRestoreMaxPendingWrites)
+}
+
+func kvBackupKey(createdAt time.Time, full bool, since, nextSince uint64) string {
+	kind := kvBackupIncremental
+	if full {
+		kind = kvBackupFull
+	}
+	// zero padded so the keys sort by creation time
+	return fmt.Sprintf("%s%020d_%s_%d_%d%s", KvBackupPrefix, createdAt.UnixNano(), kind, since, nextSince, kvBackupExtension)
+}
+
+func parseKvBackupKey(key string) (KvBackup, error) {
+	backup := KvBackup{Key: key}
+	name := strings.TrimSuffix(strings.TrimPrefix(key, KvBackupPrefix), kvBackupExtension)
+	parts := strings.Split(name, "_")
+	if len(parts) != 4 || (parts[1] != kvBackupFull && parts[1] != kvBackupIncremental) {
+		return backup, fmt.Errorf("%s is not a kv backup", key)
+	}
+
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return backup, err
+	}
+	if backup.Since, err = strconv.ParseUint(parts[2], 10, 64); err != nil {
+		return backup, err
+	}
+	if backup.NextSince, err = strconv.ParseUint(parts[3], 10, 64); err != nil {
+		return backup, err
+	}
+	backup.CreatedAt = time.Unix(0, createdAt)
+	backup.Full = parts[1] == kvBackupFull
+	return backup, nil
+}
+
+/*ListKvBackups returns the backups stored in the bucket, oldest first*/
+func ListKvBackups() ([]KvBackup, error) {
+	var backups []KvBackup
+	err := blobStore.ListObjectPages(KvBackupPrefix, func(objs []*s3.Object) bool {
+		for _, obj := range objs {
+			backup, err := parseKvBackupKey(aws.StringValue(obj.Key))
+			if err != nil {
+				continue
+			}
+			backup.Size = aws.Int64Value(obj.Size)
+			backups = append(backups, backup)
+		}
+		return true
+	})
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Key < backups[j].Key
+	})
+	return backups, err
+}
+
+/*BackupKvStoreToBlobStore writes a backup to the bucket. It is incremental on top of the latest
+backup unless there is none yet or Env.KvBackupFullEvery incremental ones were taken since the
+last full backup. Old backups are rotated out, keeping the last Env.KvBackupKeepFull full backups
+and every incremental backup that depends on them.*/
+func BackupKvStoreToBlobStore() (KvBackup, error) {
+	backups, err := ListKvBackups()
+	if err != nil {
+		return KvBackup{}, err
+	}
+
+	full := true
+	var since uint64
+	if n := len(backups); n > 0 {
+		incrementals := 0
+		for i := n - 1; i >= 0 && !backups[i].Full; i-- {
+			incrementals++
+		}
+		if incrementals < n && incrementals < Env.KvBackupFullEvery {
+			full = false
+			since = backups[n-1].NextSince
+		}
+	}
+
+	tmp, err := ioutil.TempFile("", "kvBackup")
+	if err != nil {
+		return KvBackup{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	nextSince, err := BackupKvStore(tmp, since)
+	if err != nil {
+		return KvBackup{}, err
+	}
+
+	backup := KvBackup{
+		CreatedAt: time.Now(),
+		Full:      full,
+		Since:     since,
+		NextSince: nextSince,
+	}
+	backup.Key = kvBackupKey(backup.CreatedAt, full, since, nextSince)
+	if backup.Size, err = uploadFileToBlobStore(backup.Key, tmp); err != nil {
+		return KvBackup{}, err
+	}
+
+	return backup, rotateKvBackups(append(backups, backup))
+}
+
+func rotateKvBackups(backups []KvBackup) error {
+	keepFull := Env.KvBackupKeepFull
+	if keepFull < 1 {
+		keepFull = 1
+	}
+
+	fullSeen := 0
+	oldestKept := len(backups)
+	for i := len(backups) - 1; i >= 0; i-- {
+		oldestKept = i
+		if backups[i].Full {
+			fullSeen++
+			if fullSeen >= keepFull {
+				break
+			}
+		}
+	}
+
+	var expired []string
+	for _, backup := range backups[:oldestKept] {
+		expired = append(expired, backup.Key)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	return DeleteDefaultBucketObjects(expired)
+}
+
+/*RestoreKvStoreFromBlobStore restores the state of the kv store as of the backup stored at key.
+Every key is dropped, then the full backup it is based on and every incremental backup up to it
+are loaded.*/
+func RestoreKvStoreFromBlobStore(key string) error {
+	backups, err := ListKvBackups()
+	if err != nil {
+		return err
+	}
+
+	target := -1
+	for i, backup := range backups {
+		if backup.Key == key {
+			target = i
+			break
+		}
+	}
+	if target == -1 {
+		return fmt.Errorf("no kv backup %s", key)
+	}
+
+	base := target
+	for base >= 0 && !backups[base].Full {
+		base--
+	}
+	if base < 0 {
+		return fmt.Errorf("the full backup %s depends on is gone", key)
+	}
+
+	if badgerDB == nil {
+		return errNotBadgerKvStore
+	}
+	if err := badgerDB.DropAll(); err != nil {
+		return err
+	}
+
+	for _, backup := range backups[base : target+1] {
+		if err := restoreKvBackupFromBlobStore(backup.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreKvBackupFromBlobStore(key string) error {
+	output, err := blobStore.GetObject(key, "")
+	if err != nil {
+		return err
+	}
+	defer output.Body.Close()
+
+	return RestoreKvStore(output.Body)
+}
+
+/*uploadFileToBlobStore uploads f without reading it into memory, using a multipart
+upload if it is bigger than a single part*/
+func uploadFileToBlobStore(key string, f *os.File) (int64, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := stat.Size()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	if size <= MaxMultiPartSize {
+		return size, blobStore.PutObject(key, f, DefaultFileContentType)
+	}
+
+	_, uploadID, err := CreateMultiPartUpload(key, DefaultFileContentType)
+	if err != nil {
+		return 0, err
+	}
+
+	var completedParts []*s3.CompletedPart
+	buffer := make([]byte, MaxMultiPartSize)
+	for partNumber := 1; ; partNumber++ {
+		n, readErr := io.ReadFull(f, buffer)
+		if n > 0 {
+			part, err := UploadMultiPartPart(key, aws.StringValue(uploadID), buffer[:n], partNumber)
+			if err != nil {
+				return 0, CollectErrors([]error{err, AbortMultiPartUpload(key, aws.StringValue(uploadID))})
+			}
+			completedParts = append(completedParts, part)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return 0, CollectErrors([]error{readErr, AbortMultiPartUpload(key, aws.StringValue(uploadID))})
+		}
+	}
+
+	_, err = CompleteMultiPartUpload(key, aws.StringValue(uploadID), completedParts)
+	return size, err
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KvBackup_Init(t *testing.T) {
+	SetTesting("../.env")
+}
+
+func Test_KvBackupKey_Roundtrip(t *testing.T) {
+	createdAt := time.Now()
+	key := kvBackupKey(createdAt, false, 12, 34)
+
+	backup, err := parseKvBackupKey(key)
+	assert.Nil(t, err)
+	assert.Equal(t, key, backup.Key)
+	assert.False(t, backup.Full)
+	assert.Equal(t, uint64(12), backup.Since)
+	assert.Equal(t, uint64(34), backup.NextSince)
+	assert.Equal(t, createdAt.UnixNano(), backup.CreatedAt.UnixNano())
+
+	_, err = parseKvBackupKey(KvBackupPrefix + "notabackup.bak")
+	assert.NotNil(t, err)
+}
+
+func Test_BackupKvStore_Restore(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	assert.Nil(t, BatchSet(&KVPairs{"backupKey": "before"}, TestValueTimeToLive))
+
+	assert.Nil(t, BatchSet(&KVPairs{"otherKey": "unchanged"}, TestValueTimeToLive))
+	var full bytes.Buffer
+	nextSince, err := BackupKvStore(&full, 0)
+	assert.Nil(t, err)
+
+	assert.Nil(t, BatchSet(&KVPairs{"backupKey": "after"}, TestValueTimeToLive))
+	var incremental bytes.Buffer
+	nextSince, err = BackupKvStore(&incremental, nextSince)
+	assert.Nil(t, err)
+	assert.True(t, incremental.Len() < full.Len())
+
+	// Nothing was written since, so the next backup starts from the same version
+	var empty bytes.Buffer
+	unchangedSince, err := BackupKvStore(&empty, nextSince)
+	assert.Nil(t, err)
+	assert.Equal(t, nextSince, unchangedSince)
+
+	assert.Nil(t, GetBadgerDb().DropAll())
+	assert.Nil(t, RestoreKvStore(&full))
+	value, _, err := GetValueFromKV("backupKey")
+	assert.Nil(t, err)
+	assert.Equal(t, "before", value)
+
+	assert.Nil(t, RestoreKvStore(&incremental))
+	kvs, err := BatchGet(&KVKeys{"backupKey", "otherKey"})
+	assert.Nil(t, err)
+	assert.Equal(t, KVPairs{"backupKey": "after", "otherKey": "unchanged"}, *kvs)
+}
+
+func Test_BackupKvStoreToBlobStore_Rotation(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	fullEvery, keepFull := Env.KvBackupFullEvery, Env.KvBackupKeepFull
+	Env.KvBackupFullEvery, Env.KvBackupKeepFull = 1, 2
+	defer func() {
+		Env.KvBackupFullEvery, Env.KvBackupKeepFull = fullEvery, keepFull
+	}()
+
+	existing, err := ListKvBackups()
+	assert.Nil(t, err)
+	for _, backup := range existing {
+		assert.Nil(t, DeleteDefaultBucketObject(backup.Key))
+	}
+
+	var keys []string
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, BatchSet(&KVPairs{"rotationKey": string(rune('a' + i))}, TestValueTimeToLive))
+		backup, err := BackupKvStoreToBlobStore()
+		assert.Nil(t, err)
+		keys = append(keys, backup.Key)
+	}
+
+	// full, incr, full, incr, full: the first pair is rotated out
+	backups, err := ListKvBackups()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(backups))
+	assert.True(t, backups[0].Full)
+	assert.False(t, backups[1].Full)
+	assert.True(t, backups[2].Full)
+	assert.Equal(t, keys[2], backups[0].Key)
+
+	assert.Nil(t, BatchSet(&KVPairs{"rotationKey": "z"}, TestValueTimeToLive))
+	assert.Nil(t, RestoreKvStoreFromBlobStore(backups[1].Key))
+	value, _, err := GetValueFromKV("rotationKey")
+	assert.Nil(t, err)
+	assert.Equal(t, "d", value)
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"github.com/dgraph-io/badger"
+)
+
+/*KvStoreStats are the sizes of the badger kv store on disk*/
+type KvStoreStats struct {
+	LsmSize  int64
+	VlogSize int64
+	Keys     uint64
+}
+
+/*TotalSize is the size of the LSM tree and the value log together*/
+func (s KvStoreStats) TotalSize() int64 {
+	return s.LsmSize + s.VlogSize
+}
+
+/*RunKvStoreValueLogGC rewrites value log files until none has more than discardRatio of stale
+data, and returns how many files were rewritten. It is a no-op for the other kv store backends.*/
+func RunKvStoreValueLogGC(discardRatio float64) (int, error) {
+	if badgerDB == nil {
+		return 0, nil
+	}
+
+	rewrites := 0
+	for {
+		err := badgerDB.RunValueLogGC(discardRatio)
+		if err == badger.ErrNoRewrite {
+			return rewrites, nil
+		}
+		if err != nil {
+			return rewrites, err
+		}
+		rewrites++
+		Metrics_KvStore_Vlog_GC_Rewrites_Counter.Inc()
+	}
+}
+
+/*GetKvStoreStats returns the sizes of the badger kv store and updates their gauges.
+Keys still in the memtables are not counted until they are flushed to a table.*/
+func GetKvStoreStats() (KvStoreStats, error) {
+	if badgerDB == nil {
+		return KvStoreStats{}, errNotBadgerKvStore
+	}
+
+	stats := KvStoreStats{}
+	stats.LsmSize, stats.VlogSize = badgerDB.Size()
+	for _, table := range badgerDB.Tables(true) {
+		stats.Keys += table.KeyCount
+	}
+
+	Metrics_KvStore_Lsm_Size_Bytes.Set(float64(stats.LsmSize))
+	Metrics_KvStore_Vlog_Size_Bytes.Set(float64(stats.VlogSize))
+	Metrics_KvStore_Keys_Count.Set(float64(stats.Keys))
+
+	return stats, nil
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RunKvStoreValueLogGC_And_Stats(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	assert.Nil(t, BatchSet(getKvPairs(100), TestValueTimeToLive))
+
+	// nothing to collect in a fresh value log
+	_, err := RunKvStoreValueLogGC(0.5)
+	assert.Nil(t, err)
+
+	stats, err := GetKvStoreStats()
+	assert.Nil(t, err)
+	assert.True(t, stats.VlogSize >= 0)
+	assert.Equal(t, stats.LsmSize+stats.VlogSize, stats.TotalSize())
+	assert.Equal(t, float64(stats.VlogSize), GetMetricGauge(Metrics_KvStore_Vlog_Size_Bytes))
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisPoolSize    = 16
+	redisBatchSize   = 1000
+	redisDialTimeout = 5 * time.Second
+	redisIOTimeout   = 30 * time.Second
+)
+
+/*redisKVStore is a KVStore backed by a redis compatible server, so several nodes can share the metadata*/
+type redisKVStore struct {
+	client *redis.Client
+}
+
+func newRedisKVStore(address, password string, db int) (*redisKVStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         address,
+		Password:     password,
+		DB:           db,
+		PoolSize:     redisPoolSize,
+		DialTimeout:  redisDialTimeout,
+		ReadTimeout:  redisIOTimeout,
+		WriteTimeout: redisIOTimeout,
+	})
+
+	// Fail early if the server can't be reached
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &redisKVStore{client: client}, nil
+}
+
+func (r *redisKVStore) Get(key string) (value string, expirationTime time.Time, err error) {
+	expirationTime = time.Now()
+	if key == "" {
+		return value, expirationTime, errors.New("no key specified")
+	}
+
+	ctx := context.Background()
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err == redis.Nil {
+		return value, expirationTime, ErrKeyNotFound
+	}
+	if err != nil {
+		LogIfError(err, nil)
+		return value, expirationTime, err
+	}
+
+	value = get.Val()
+	// Same as badger, a key without a TTL expires at the unix epoch
+	expirationTime = time.Unix(0, 0)
+	if ttl := pttl.Val(); ttl > 0 {
+		expirationTime = time.Now().Add(ttl)
+	}
+	return value, expirationTime, nil
+}
+
+func (r *redisKVStore) BatchGet(ks *KVKeys) (*KVPairs, error) {
+	kvs := &KVPairs{}
+
+	var keys []string
+	for _, k := range *ks {
+		// Skip any empty keys.
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+
+	ctx := context.Background()
+	for low := 0; low < len(keys); low += redisBatchSize {
+		high := low + redisBatchSize
+		if high > len(keys) {
+			high = len(keys)
+		}
+
+		values, err := r.client.MGet(ctx, keys[low:high]...).Result()
+		if err != nil {
+			LogIfError(err, map[string]interface{}{"batchSize": len(*ks)})
+			return kvs, err
+		}
+		for i, v := range values {
+			if s, ok := v.(string); ok {
+				(*kvs)[keys[low+i]] = s
+			}
+		}
+	}
+
+	return kvs, nil
+}
+
+/*BatchSet writes every pair in a single MULTI/EXEC transaction*/
+func (r *redisKVStore) BatchSet(kvs *KVPairs, ttl time.Duration) error {
+	for k := range *kvs {
+		if k == "" {
+			return errors.New("BatchSet does not accept key as empty string")
+		}
+	}
+	if len(*kvs) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for k, v := range *kvs {
+			pipe.Set(ctx, k, v, ttl)
+		}
+		return nil
+	})
+	LogIfError(err, map[string]interface{}{"batchSize": len(*kvs)})
+	return err
+}
+
+func (r *redisKVStore) SetIfAbsent(key, value string, ttl time.Duration) (bool, error) {
+	if key == "" {
+		return false, errors.New("SetIfAbsent does not accept key as empty string")
+	}
+	set, err := r.client.SetNX(context.Background(), key, value, ttl).Result()
+	LogIfError(err, nil)
+	return set, err
+}
+
+/*redisIncrementScript adds ARGV[1] to the counter at KEYS[1] and only sets the TTL ARGV[2] (in milliseconds)
+when the increment created it*/
+var redisIncrementScript = redis.NewScript(`
+local sum = redis.call("INCRBY", KEYS[1], ARGV[1])
+if sum == tonumber(ARGV[1]) and redis.call("PTTL", KEYS[1]) == -1 and tonumber(ARGV[2]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return sum
+`)
+
+func (r *redisKVStore) Increment(key string, by int64, ttl time.Duration) (int64, error) {
+	if key == "" {
+		return 0, errors.New("Increment does not accept key as empty string")
+	}
+	sum, err := redisIncrementScript.Run(context.Background(), r.client, []string{key}, by, ttl.Milliseconds()).Int64()
+	LogIfError(err, nil)
+	return sum, err
+}
+
+func (r *redisKVStore) BatchDelete(ks *KVKeys) error {
+	keys := *ks
+	ctx := context.Background()
+	for low := 0; low < len(keys); low += redisBatchSize {
+		high := low + redisBatchSize
+		if high > len(keys) {
+			high = len(keys)
+		}
+
+		if err := r.client.Del(ctx, keys[low:high]...).Err(); err != nil {
+			LogIfError(err, map[string]interface{}{"batchSize": len(*ks)})
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *redisKVStore) RemoveAll() error {
+	err := r.client.FlushDB(context.Background()).Err()
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (r *redisKVStore) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedisKVStoreForTest(t *testing.T) (*miniredis.Miniredis, *redisKVStore) {
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+
+	store, err := newRedisKVStore(server.Addr(), "", 0)
+	assert.Nil(t, err)
+	return server, store
+}
+
+func Test_RedisKVStore_Get_Set_Delete(t *testing.T) {
+	server, store := newRedisKVStoreForTest(t)
+	defer server.Close()
+	defer store.Close()
+
+	_, _, err := store.Get("missing")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	assert.Nil(t, store.BatchSet(&KVPairs{"key": "opacity", "other": "value"}, TestValueTimeToLive))
+
+	value, expirationTime, err := store.Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "opacity", value)
+	assert.WithinDuration(t, time.Now().Add(TestValueTimeToLive), expirationTime, 2*time.Second)
+
+	kvs, err := store.BatchGet(&KVKeys{"key", "", "missing", "other"})
+	assert.Nil(t, err)
+	assert.Equal(t, KVPairs{"key": "opacity", "other": "value"}, *kvs)
+
+	assert.Nil(t, store.BatchDelete(&KVKeys{"key"}))
+	kvs, err = store.BatchGet(&KVKeys{"key", "other"})
+	assert.Nil(t, err)
+	assert.Equal(t, KVPairs{"other": "value"}, *kvs)
+
+	assert.NotNil(t, store.BatchSet(&KVPairs{"": "value"}, TestValueTimeToLive))
+}
+
+func Test_RedisKVStore_SetIfAbsent(t *testing.T) {
+	server, store := newRedisKVStoreForTest(t)
+	defer server.Close()
+	defer store.Close()
+
+	set, err := store.SetIfAbsent("key", "first", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.True(t, set)
+
+	set, err = store.SetIfAbsent("key", "second", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.False(t, set)
+
+	value, _, err := store.Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", value)
+}
+
+func Test_RedisKVStore_Increment(t *testing.T) {
+	server, store := newRedisKVStoreForTest(t)
+	defer server.Close()
+	defer store.Close()
+
+	sum, err := store.Increment("counter", 2, TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), sum)
+	assert.Equal(t, TestValueTimeToLive, server.TTL("counter"))
+
+	// Counting again does not push the expiration back
+	server.FastForward(time.Second)
+	sum, err = store.Increment("counter", -1, TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), sum)
+	assert.Equal(t, TestValueTimeToLive-time.Second, server.TTL("counter"))
+}
+
+func Test_RedisKVStore_Mass_Batch(t *testing.T) {
+	server, store := newRedisKVStoreForTest(t)
+	defer server.Close()
+	defer store.Close()
+
+	count := redisBatchSize*2 + 10
+	assert.Nil(t, store.BatchSet(getKvPairs(count), TestValueTimeToLive))
+
+	keys := KVKeys{}
+	for i := 0; i < count; i++ {
+		keys = append(keys, strconv.Itoa(i))
+	}
+	kvs, err := store.BatchGet(&keys)
+	assert.Nil(t, err)
+	assert.Equal(t, count, len(*kvs))
+
+	assert.Nil(t, store.BatchDelete(&keys))
+	kvs, err = store.BatchGet(&keys)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(*kvs))
+}
+
+func Test_RedisKVStore_RemoveAll(t *testing.T) {
+	server, store := newRedisKVStoreForTest(t)
+	defer server.Close()
+
+	assert.Nil(t, store.BatchSet(getKvPairs(2), TestValueTimeToLive))
+	assert.Nil(t, store.RemoveAll())
+
+	store, err := newRedisKVStore(server.Addr(), "", 0)
+	assert.Nil(t, err)
+	defer store.Close()
+	kvs, err := store.BatchGet(&KVKeys{"0", "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(*kvs))
+}
+
+func Test_RedisKVStore_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = newRedisKVStore(address, "", 0)
+	assert.NotNil(t, err)
+}
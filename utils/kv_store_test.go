@@ -2,6 +2,8 @@ package utils
 
 import (
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"time"
@@ -102,6 +104,60 @@ func Test_KVStoreBatchDelete(t *testing.T) {
 	assert.True(t, len(*kvs) == 0)
 }
 
+func Test_KVStoreSetIfAbsent(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	BatchDelete(&KVKeys{"setIfAbsentKey"})
+
+	var wg sync.WaitGroup
+	var setCount int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			set, err := SetIfAbsent("setIfAbsentKey", strconv.Itoa(i), TestValueTimeToLive)
+			assert.Nil(t, err)
+			if set {
+				atomic.AddInt32(&setCount, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), setCount)
+
+	set, err := SetIfAbsent("setIfAbsentKey", "again", TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.False(t, set)
+}
+
+func Test_KVStoreIncrement(t *testing.T) {
+	InitKvStore()
+	defer CloseKvStore()
+
+	BatchDelete(&KVKeys{"incrementKey"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Increment("incrementKey", 1, TestValueTimeToLive)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	sum, err := Increment("incrementKey", -3, TestValueTimeToLive)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), sum)
+
+	value, expirationTime, err := GetValueFromKV("incrementKey")
+	assert.Nil(t, err)
+	assert.Equal(t, "7", value)
+	assert.True(t, expirationTime.After(time.Now()))
+}
+
 func Test_KVStore_MassBatchDelete(t *testing.T) {
 	InitKvStore()
 	defer CloseKvStore()
@@ -0,0 +1,989 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	localObjectsDir   = "objects"
+	localInfoDir      = "info"
+	localMultipartDir = "multipart"
+	localTmpDir       = "tmp"
+	localLifecycle    = "lifecycle.json"
+	localUploadInfo   = "upload.json"
+	localPartPrefix   = "part-"
+	localUploadIDSize = 32
+	localPresignKey   = "presign.key"
+	localPresignSize  = 32
+)
+
+/*ErrLocalBlobNotPublic is returned when an object served over HTTP does not have a public canned ACL*/
+var ErrLocalBlobNotPublic = errors.New("access denied, object is not public")
+
+/*ErrLocalBlobBadSignature is returned when a presigned URL of the local blob store is forged or expired*/
+var ErrLocalBlobBadSignature = errors.New("access denied, the URL signature is invalid or expired")
+
+var (
+	localBlobDirTest     string
+	localBlobDirTestOnce sync.Once
+)
+
+/*getLocalBlobDirTest creates the directory the local blob store uses in tests the first time it is needed*/
+func getLocalBlobDirTest() string {
+	localBlobDirTestOnce.Do(func() {
+		var err error
+		localBlobDirTest, err = ioutil.TempDir("", "localBlobStoreForUnitTest")
+		PanicOnError(err)
+	})
+	return localBlobDirTest
+}
+
+/*localBlobStore is a BlobStore that keeps every object as a plain file under root.
+Object data lives in root/objects/<key>, the content type, ETag and canned ACL of
+each object in root/info/<key>.json and pending multipart uploads in root/multipart/<uploadID>.*/
+type localBlobStore struct {
+	root       string
+	presignKey []byte
+	// mu guards every change to the object and info files and to the multipart uploads. Data is copied
+	// to a temp file first, only moving it into place happens under mu.
+	mu sync.Mutex
+}
+
+type localObjectInfo struct {
+	ContentType string `json:"contentType"`
+	ETag        string `json:"etag"`
+	CannedAcl   string `json:"cannedAcl"`
+}
+
+type localUpload struct {
+	Key         string    `json:"key"`
+	ContentType string    `json:"contentType"`
+	Initiated   time.Time `json:"initiated"`
+}
+
+type localObjectBody struct {
+	io.Reader
+	io.Closer
+}
+
+func newLocalBlobStore(root string) (*localBlobStore, error) {
+	for _, dir := range []string{localObjectsDir, localInfoDir, localMultipartDir, localTmpDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0750); err != nil {
+			return nil, err
+		}
+	}
+	presignKey, err := loadPresignKey(filepath.Join(root, localPresignKey))
+	if err != nil {
+		return nil, err
+	}
+	return &localBlobStore{root: root, presignKey: presignKey}, nil
+}
+
+/*loadPresignKey reads the key presigned URLs are signed with, creating it the first time, so the URLs
+stay valid when the node restarts*/
+func loadPresignKey(p string) ([]byte, error) {
+	key, err := ioutil.ReadFile(p)
+	if err == nil && len(key) == localPresignSize {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, localPresignSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, ioutil.WriteFile(p, key, 0600)
+}
+
+func blobNotFoundError(code, key string) error {
+	return awserr.NewRequestFailure(awserr.New(code, "The specified key does not exist: "+key, nil), http.StatusNotFound, "")
+}
+
+func badDigestError() error {
+	return awserr.NewRequestFailure(awserr.New("BadDigest", "The Content-MD5 you specified did not match what we received.", nil),
+		http.StatusBadRequest, "")
+}
+
+/*cleanKey turns an object key into a relative file path, refusing keys with ".." segments that would escape the store*/
+func cleanKey(key string) (string, error) {
+	invalidKey := awserr.NewRequestFailure(awserr.New("InvalidKey", "invalid object key: "+key, nil), http.StatusBadRequest, "")
+	for _, segment := range strings.FieldsFunc(key, isKeySeparator) {
+		if segment == ".." {
+			return "", invalidKey
+		}
+	}
+	cleaned := path.Clean("/" + key)
+	if key == "" || cleaned == "/" {
+		return "", invalidKey
+	}
+	return filepath.FromSlash(cleaned[1:]), nil
+}
+
+func isKeySeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+func (l *localBlobStore) objectPath(key string) (string, error) {
+	k, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, localObjectsDir, k), nil
+}
+
+func (l *localBlobStore) infoPath(key string) (string, error) {
+	k, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, localInfoDir, k+".json"), nil
+}
+
+func (l *localBlobStore) uploadDir(uploadID string) (string, error) {
+	if len(uploadID) != localUploadIDSize || strings.ContainsAny(uploadID, `/\.`) {
+		return "", blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	dir := filepath.Join(l.root, localMultipartDir, uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		return "", blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	return dir, nil
+}
+
+func (l *localBlobStore) readInfo(key string) (localObjectInfo, error) {
+	info := localObjectInfo{ContentType: DefaultFileContentType, CannedAcl: CannedAcl_Private}
+	p, err := l.infoPath(key)
+	if err != nil {
+		return info, err
+	}
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return info, nil
+		}
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+func (l *localBlobStore) writeInfo(key string, info localObjectInfo) error {
+	p, err := l.infoPath(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return l.writeFileAtomic(p, bytes.NewReader(data))
+}
+
+func (l *localBlobStore) writeFileAtomic(dst string, r io.Reader) error {
+	_, err := l.writeFileAtomicWithMD5(dst, r, "")
+	return err
+}
+
+/*writeFileAtomicWithMD5 copies r into a temp file and renames it to dst, returning the hex MD5 of what was written.
+Nothing is written to dst if contentMD5 is given and isn't the MD5 of r.*/
+func (l *localBlobStore) writeFileAtomicWithMD5(dst string, r io.Reader, contentMD5 string) (string, error) {
+	tmp, sum, err := l.writeTempFile(r, contentMD5)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+	return sum, moveFile(tmp, dst)
+}
+
+/*writeTempFile copies r into a temp file the caller moves into place or removes, and returns its path and the
+hex MD5 of what was written. Nothing is kept if contentMD5 is given and isn't the MD5 of r.*/
+func (l *localBlobStore) writeTempFile(r io.Reader, contentMD5 string) (string, string, error) {
+	tmp, err := ioutil.TempFile(filepath.Join(l.root, localTmpDir), "blob")
+	if err != nil {
+		return "", "", err
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+	if contentMD5 != "" && contentMD5 != ContentMD5(h.Sum(nil)) {
+		os.Remove(tmp.Name())
+		return "", "", badDigestError()
+	}
+	return tmp.Name(), hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+/*removeEmptyParents removes the empty directories between p and stop*/
+func removeEmptyParents(p, stop string) {
+	for dir := filepath.Dir(p); dir != stop && strings.HasPrefix(dir, stop); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+func (l *localBlobStore) HeadObject(key string) (*s3.HeadObjectOutput, error) {
+	p, err := l.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(p)
+	if err != nil || stat.IsDir() {
+		return nil, blobNotFoundError("NotFound", key)
+	}
+	info, err := l.readInfo(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3.HeadObjectOutput{
+		AcceptRanges:  aws.String("bytes"),
+		ContentLength: aws.Int64(stat.Size()),
+		ContentType:   aws.String(info.ContentType),
+		ETag:          aws.String(info.ETag),
+		LastModified:  aws.Time(stat.ModTime()),
+	}, nil
+}
+
+func (l *localBlobStore) GetObject(key, downloadRange string) (*s3.GetObjectOutput, error) {
+	p, err := l.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchKey, key)
+	}
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		f.Close()
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchKey, key)
+	}
+	info, err := l.readInfo(key)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	output := &s3.GetObjectOutput{
+		AcceptRanges:  aws.String("bytes"),
+		Body:          f,
+		ContentLength: aws.Int64(stat.Size()),
+		ContentType:   aws.String(info.ContentType),
+		ETag:          aws.String(info.ETag),
+		LastModified:  aws.Time(stat.ModTime()),
+	}
+
+	if downloadRange != "" {
+		start, end, err := parseByteRange(downloadRange, stat.Size())
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		output.Body = localObjectBody{Reader: io.NewSectionReader(f, start, end-start+1), Closer: f}
+		output.ContentLength = aws.Int64(end - start + 1)
+		output.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, stat.Size()))
+	}
+
+	return output, nil
+}
+
+/*parseByteRange parses a single HTTP byte range ("bytes=0-99", "bytes=100-" or "bytes=-100")
+and returns the inclusive start and end offsets for an object of the given size*/
+func parseByteRange(downloadRange string, size int64) (int64, int64, error) {
+	invalidRange := awserr.NewRequestFailure(awserr.New("InvalidRange", "The requested range is not satisfiable: "+downloadRange, nil),
+		http.StatusRequestedRangeNotSatisfiable, "")
+
+	spec := strings.TrimPrefix(strings.TrimSpace(downloadRange), "bytes=")
+	if spec == downloadRange || strings.Contains(spec, ",") {
+		return 0, 0, invalidRange
+	}
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, invalidRange
+	}
+
+	var start, end int64
+	var err error
+	switch {
+	case parts[0] == "":
+		suffix, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || suffix <= 0 {
+			return 0, 0, invalidRange
+		}
+		if suffix > size {
+			suffix = size
+		}
+		start, end = size-suffix, size-1
+	default:
+		if start, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+			return 0, 0, invalidRange
+		}
+		end = size - 1
+		if parts[1] != "" {
+			if end, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+				return 0, 0, invalidRange
+			}
+			if end > size-1 {
+				end = size - 1
+			}
+		}
+	}
+
+	if start < 0 || start > end || start >= size {
+		return 0, 0, invalidRange
+	}
+	return start, end, nil
+}
+
+func (l *localBlobStore) PutObject(key string, body io.ReadSeeker, contentType string) error {
+	p, err := l.objectPath(key)
+	if err != nil {
+		return err
+	}
+	tmp, etag, err := l.writeTempFile(body, "")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := moveFile(tmp, p); err != nil {
+		return err
+	}
+	return l.writeInfo(key, localObjectInfo{
+		ContentType: contentType,
+		ETag:        strconv.Quote(etag),
+		CannedAcl:   CannedAcl_Private,
+	})
+}
+
+func (l *localBlobStore) DeleteObject(key string) error {
+	p, err := l.objectPath(key)
+	if err != nil {
+		return err
+	}
+	ip, _ := l.infoPath(key)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(ip); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	removeEmptyParents(p, filepath.Join(l.root, localObjectsDir))
+	removeEmptyParents(ip, filepath.Join(l.root, localInfoDir))
+	return nil
+}
+
+func (l *localBlobStore) DeleteObjects(keys []string) error {
+	for _, key := range keys {
+		if err := l.DeleteObject(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *localBlobStore) ListObjectPages(prefix string, it ObjectIterator) error {
+	objectsRoot := filepath.Join(l.root, localObjectsDir)
+	var objects []*s3.Object
+
+	err := filepath.Walk(objectsRoot, func(p string, stat os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if stat.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(objectsRoot, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := l.readInfo(key)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, &s3.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(stat.Size()),
+			ETag:         aws.String(info.ETag),
+			LastModified: aws.Time(stat.ModTime()),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return aws.StringValue(objects[i].Key) < aws.StringValue(objects[j].Key)
+	})
+
+	if len(objects) == 0 {
+		it(objects)
+		return nil
+	}
+	for low := 0; low < len(objects); low += int(awsPagingSize) {
+		high := low + int(awsPagingSize)
+		if high > len(objects) {
+			high = len(objects)
+		}
+		if !it(objects[low:high]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (l *localBlobStore) SetObjectCannedAcl(key, cannedAcl string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.HeadObject(key); err != nil {
+		return err
+	}
+	info, err := l.readInfo(key)
+	if err != nil {
+		return err
+	}
+	info.CannedAcl = cannedAcl
+	return l.writeInfo(key, info)
+}
+
+func (l *localBlobStore) StartMultipartUpload(key, fileType string) (*s3.CreateMultipartUploadOutput, error) {
+	if _, err := cleanKey(key); err != nil {
+		return nil, err
+	}
+
+	uploadID := RandHexString(localUploadIDSize)
+	dir := filepath.Join(l.root, localMultipartDir, uploadID)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(localUpload{Key: key, ContentType: fileType, Initiated: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	if err := l.writeFileAtomic(filepath.Join(dir, localUploadInfo), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	return &s3.CreateMultipartUploadOutput{
+		Bucket:   aws.String(Env.BucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}, nil
+}
+
+func (l *localBlobStore) readUpload(dir string) (localUpload, error) {
+	upload := localUpload{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, localUploadInfo))
+	if err != nil {
+		return upload, err
+	}
+	err = json.Unmarshal(data, &upload)
+	return upload, err
+}
+
+func partFileName(partNumber int64) string {
+	return fmt.Sprintf("%s%05d", localPartPrefix, partNumber)
+}
+
+func (l *localBlobStore) UploadPartOfMultiPartUpload(key, uploadID string, body io.ReadSeeker, size int64,
+	partNumber int, contentMD5 string) (*s3.CompletedPart, error) {
+	return l.uploadPart(uploadID, io.LimitReader(body, size), partNumber, contentMD5)
+}
+
+func (l *localBlobStore) uploadPart(uploadID string, body io.Reader, partNumber int, contentMD5 string) (*s3.CompletedPart, error) {
+	if _, err := l.uploadDir(uploadID); err != nil {
+		return nil, err
+	}
+	if partNumber < 1 || partNumber > 10000 {
+		return nil, awserr.NewRequestFailure(awserr.New("InvalidArgument", "part number must be between 1 and 10000", nil),
+			http.StatusBadRequest, "")
+	}
+
+	tmp, etag, err := l.writeTempFile(body, contentMD5)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The upload may have been finished or aborted while the part was copied
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, partFileName(int64(partNumber)))); err != nil {
+		return nil, err
+	}
+
+	return &s3.CompletedPart{
+		ETag:       aws.String(strconv.Quote(etag)),
+		PartNumber: aws.Int64(int64(partNumber)),
+	}, nil
+}
+
+func (l *localBlobStore) FinishMultipartUpload(key, uploadID string,
+	completedParts []*s3.CompletedPart) (*s3.CompleteMultipartUploadOutput, error) {
+	upload, files, etag, err := l.openCompletedParts(key, uploadID, completedParts)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	// The parts are read from the files opened above, parts uploaded again since don't change the object
+	readers := make([]io.Reader, len(files))
+	for i, f := range files {
+		readers[i] = f
+	}
+	tmp, _, err := l.writeTempFile(io.MultiReader(readers...), "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+
+	p, err := l.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Only one of the requests finishing or aborting the upload at the same time gets through
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := moveFile(tmp, p); err != nil {
+		return nil, err
+	}
+	if err := l.writeInfo(key, localObjectInfo{
+		ContentType: upload.ContentType,
+		ETag:        etag,
+		CannedAcl:   CannedAcl_Private,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+
+	return &s3.CompleteMultipartUploadOutput{
+		Bucket:   aws.String(Env.BucketName),
+		Key:      aws.String(key),
+		ETag:     aws.String(etag),
+		Location: aws.String(GetBlobStoreBaseURL() + key),
+	}, nil
+}
+
+/*openCompletedParts checks the completed parts against the parts of the upload and opens them in order. It returns
+the upload, the opened parts, which the caller closes even on errors, and the ETag of the object they make.*/
+func (l *localBlobStore) openCompletedParts(key, uploadID string,
+	completedParts []*s3.CompletedPart) (localUpload, []*os.File, string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return localUpload{}, nil, "", err
+	}
+	upload, err := l.readUpload(dir)
+	if err != nil {
+		return upload, nil, "", err
+	}
+	if upload.Key != key {
+		return upload, nil, "", blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+
+	invalidPart := func(msg string) error {
+		return awserr.NewRequestFailure(awserr.New("InvalidPart", msg, nil), http.StatusBadRequest, "")
+	}
+	if len(completedParts) == 0 {
+		return upload, nil, "", awserr.NewRequestFailure(awserr.New("MalformedXML", "no parts specified", nil), http.StatusBadRequest, "")
+	}
+
+	var files []*os.File
+	etags := md5.New()
+	var previous int64
+	for i, part := range completedParts {
+		partNumber := aws.Int64Value(part.PartNumber)
+		if partNumber <= previous {
+			return upload, files, "", awserr.NewRequestFailure(awserr.New("InvalidPartOrder", "parts must be in ascending order", nil),
+				http.StatusBadRequest, "")
+		}
+		previous = partNumber
+
+		f, err := os.Open(filepath.Join(dir, partFileName(partNumber)))
+		if err != nil {
+			return upload, files, "", invalidPart(fmt.Sprintf("part %d was not uploaded", partNumber))
+		}
+		files = append(files, f)
+
+		h := md5.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return upload, files, "", err
+		}
+		sum := h.Sum(nil)
+		if strconv.Quote(hex.EncodeToString(sum)) != aws.StringValue(part.ETag) {
+			return upload, files, "", invalidPart(fmt.Sprintf("part %d etag does not match", partNumber))
+		}
+		if stat, err := f.Stat(); err == nil && i < len(completedParts)-1 && stat.Size() < MinMultiPartSize {
+			return upload, files, "", awserr.NewRequestFailure(awserr.New("EntityTooSmall", fmt.Sprintf("part %d is smaller than the minimum allowed size", partNumber), nil),
+				http.StatusBadRequest, "")
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return upload, files, "", err
+		}
+		etags.Write(sum)
+	}
+
+	return upload, files, strconv.Quote(fmt.Sprintf("%s-%d", hex.EncodeToString(etags.Sum(nil)), len(completedParts))), nil
+}
+
+func (l *localBlobStore) CancelMultipartUpload(key, uploadID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+/*ListParts hashes every part to get its ETag, which is fine for the sizes the local store is meant for*/
+func (l *localBlobStore) ListParts(key, uploadID string) ([]*s3.Part, error) {
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := l.readUpload(dir)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Key != key {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	parts := []*s3.Part{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), localPartPrefix) {
+			continue
+		}
+		partNumber, err := strconv.ParseInt(strings.TrimPrefix(entry.Name(), localPartPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		etag, err := md5File(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, &s3.Part{
+			ETag:         aws.String(strconv.Quote(etag)),
+			LastModified: aws.Time(entry.ModTime()),
+			PartNumber:   aws.Int64(partNumber),
+			Size:         aws.Int64(entry.Size()),
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.Int64Value(parts[i].PartNumber) < aws.Int64Value(parts[j].PartNumber)
+	})
+	return parts, nil
+}
+
+func md5File(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+/*PresignUploadPart returns a URL under LocalBlobStoreURL that accepts a PUT of the part until it expires*/
+func (l *localBlobStore) PresignUploadPart(key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	if _, err := l.uploadDir(uploadID); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+	query := url.Values{}
+	query.Set("uploadId", uploadID)
+	query.Set("partNumber", strconv.Itoa(partNumber))
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	query.Set("signature", l.presignPart(key, uploadID, partNumber, expiresAt))
+	return GetBlobStoreBaseURL() + key + "?" + query.Encode(), nil
+}
+
+func (l *localBlobStore) presignPart(key, uploadID string, partNumber int, expiresAt int64) string {
+	mac := hmac.New(sha256.New, l.presignKey)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%d", key, uploadID, partNumber, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*PresignGetObject returns a URL under LocalBlobStoreURL that serves the object, even a private one, until it expires*/
+func (l *localBlobStore) PresignGetObject(key string, expires time.Duration) (string, error) {
+	if _, err := l.HeadObject(key); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	query.Set("signature", l.presignGet(key, expiresAt))
+	return GetBlobStoreBaseURL() + key + "?" + query.Encode(), nil
+}
+
+func (l *localBlobStore) presignGet(key string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, l.presignKey)
+	fmt.Fprintf(mac, "GET\n%s\n%d", key, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*uploadPresignedPart stores a part PUT to a URL returned by PresignUploadPart*/
+func (l *localBlobStore) uploadPresignedPart(key string, query url.Values, body io.Reader, contentMD5 string) (*s3.CompletedPart, error) {
+	uploadID := query.Get("uploadId")
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil {
+		return nil, ErrLocalBlobBadSignature
+	}
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrLocalBlobBadSignature
+	}
+	signature := l.presignPart(key, uploadID, partNumber, expiresAt)
+	if !hmac.Equal([]byte(signature), []byte(query.Get("signature"))) {
+		return nil, ErrLocalBlobBadSignature
+	}
+
+	dir, err := l.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload, err := l.readUpload(dir); err != nil || upload.Key != key {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	return l.uploadPart(uploadID, body, partNumber, contentMD5)
+}
+
+func (l *localBlobStore) PutBucketLifecycleConfiguration(rules []*s3.LifecycleRule) error {
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	return l.writeFileAtomic(filepath.Join(l.root, localLifecycle), bytes.NewReader(data))
+}
+
+func (l *localBlobStore) GetBucketLifecycleConfiguration() ([]*s3.LifecycleRule, error) {
+	data, err := ioutil.ReadFile(filepath.Join(l.root, localLifecycle))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rules []*s3.LifecycleRule
+	err = json.Unmarshal(data, &rules)
+	return rules, err
+}
+
+func lifecycleRulePrefix(rule *s3.LifecycleRule) string {
+	if rule.Filter != nil && rule.Filter.Prefix != nil {
+		return aws.StringValue(rule.Filter.Prefix)
+	}
+	return aws.StringValue(rule.Prefix)
+}
+
+/*RunLifecycle applies the stored lifecycle rules the way S3 would, expiring objects
+and aborting incomplete multipart uploads that are older than the rule allows*/
+func (l *localBlobStore) RunLifecycle(now time.Time) error {
+	rules, err := l.GetBucketLifecycleConfiguration()
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if aws.StringValue(rule.Status) != s3.ExpirationStatusEnabled {
+			continue
+		}
+		prefix := lifecycleRulePrefix(rule)
+
+		if rule.Expiration != nil {
+			var expiredBefore time.Time
+			if rule.Expiration.Date != nil {
+				if now.Before(aws.TimeValue(rule.Expiration.Date)) {
+					continue
+				}
+				expiredBefore = now
+			} else if rule.Expiration.Days != nil {
+				expiredBefore = now.AddDate(0, 0, -int(aws.Int64Value(rule.Expiration.Days)))
+			} else {
+				continue
+			}
+
+			var expiredKeys []string
+			if err := l.ListObjectPages(prefix, func(objs []*s3.Object) bool {
+				for _, obj := range objs {
+					if aws.TimeValue(obj.LastModified).Before(expiredBefore) {
+						expiredKeys = append(expiredKeys, aws.StringValue(obj.Key))
+					}
+				}
+				return true
+			}); err != nil {
+				return err
+			}
+			if err := l.DeleteObjects(expiredKeys); err != nil {
+				return err
+			}
+		}
+
+		if rule.AbortIncompleteMultipartUpload != nil && rule.AbortIncompleteMultipartUpload.DaysAfterInitiation != nil {
+			abortBefore := now.AddDate(0, 0, -int(aws.Int64Value(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation)))
+			uploadsRoot := filepath.Join(l.root, localMultipartDir)
+			dirs, err := ioutil.ReadDir(uploadsRoot)
+			if err != nil {
+				return err
+			}
+			for _, d := range dirs {
+				dir := filepath.Join(uploadsRoot, d.Name())
+				upload, err := l.readUpload(dir)
+				if err != nil {
+					continue
+				}
+				if strings.HasPrefix(upload.Key, prefix) && upload.Initiated.Before(abortBefore) {
+					l.mu.Lock()
+					err := os.RemoveAll(dir)
+					l.mu.Unlock()
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+/*getPublicObject returns an object only if its canned ACL allows anonymous reads*/
+func (l *localBlobStore) getPublicObject(key, downloadRange string) (*s3.GetObjectOutput, error) {
+	if _, err := l.HeadObject(key); err != nil {
+		return nil, err
+	}
+	info, err := l.readInfo(key)
+	if err != nil {
+		return nil, err
+	}
+	if info.CannedAcl != CannedAcl_PublicRead && info.CannedAcl != CannedAcl_PublicReadWrite {
+		return nil, ErrLocalBlobNotPublic
+	}
+	return l.GetObject(key, downloadRange)
+}
+
+/*getPresignedObject returns an object requested through a URL returned by PresignGetObject*/
+func (l *localBlobStore) getPresignedObject(key, downloadRange string, query url.Values) (*s3.GetObjectOutput, error) {
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrLocalBlobBadSignature
+	}
+	if !hmac.Equal([]byte(l.presignGet(key, expiresAt)), []byte(query.Get("signature"))) {
+		return nil, ErrLocalBlobBadSignature
+	}
+	return l.GetObject(key, downloadRange)
+}
+
+/*GetLocalBlobObject returns an object from the local blob store, for serving the objects GetBucketUrl
+points at over HTTP. Only publicly readable objects are returned, unless the query holds the signature
+of a presigned URL.*/
+func GetLocalBlobObject(key, downloadRange string, query url.Values) (*s3.GetObjectOutput, error) {
+	l, ok := blobStore.(*localBlobStore)
+	if !ok {
+		return nil, errors.New("the local blob store is not enabled")
+	}
+	if query.Get("signature") != "" {
+		return l.getPresignedObject(key, downloadRange, query)
+	}
+	return l.getPublicObject(key, downloadRange)
+}
+
+/*UploadLocalBlobPart stores a part of a multipart upload sent to a presigned URL of the local blob store.
+The part is rejected if contentMD5 is given and isn't its MD5, like S3 does with the Content-MD5 header.*/
+func UploadLocalBlobPart(key string, query url.Values, body io.Reader, contentMD5 string) (*s3.CompletedPart, error) {
+	l, ok := blobStore.(*localBlobStore)
+	if !ok {
+		return nil, errors.New("the local blob store is not enabled")
+	}
+	return l.uploadPresignedPart(key, query, body, contentMD5)
+}
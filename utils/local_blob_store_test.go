@@ -0,0 +1,389 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLocalBlobStoreForTest(t *testing.T) *localBlobStore {
+	dir, err := ioutil.TempDir("", "localBlobStoreTest")
+	assert.Nil(t, err)
+	store, err := newLocalBlobStore(dir)
+	assert.Nil(t, err)
+	return store
+}
+
+func Test_LocalBlobStore_Put_Get_Head_Delete(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	assert.Nil(t, store.PutObject("abc/file", strings.NewReader("0123456789"), "text/plain"))
+
+	head, err := store.HeadObject("abc/file")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), aws.Int64Value(head.ContentLength))
+	assert.Equal(t, "text/plain", aws.StringValue(head.ContentType))
+	assert.NotEmpty(t, aws.StringValue(head.ETag))
+
+	output, err := store.GetObject("abc/file", "")
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(output.Body)
+	output.Body.Close()
+	assert.Equal(t, "0123456789", string(data))
+
+	assert.Nil(t, store.DeleteObject("abc/file"))
+	_, err = store.HeadObject("abc/file")
+	assert.NotNil(t, err)
+	_, err = os.Stat(filepath.Join(store.root, localObjectsDir, "abc"))
+	assert.True(t, os.IsNotExist(err))
+
+	// deleting a missing object is not an error, same as S3
+	assert.Nil(t, store.DeleteObject("abc/file"))
+}
+
+func Test_LocalBlobStore_Range_Reads(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	assert.Nil(t, store.PutObject("range", strings.NewReader("0123456789"), DefaultFileContentType))
+
+	ranges := map[string]string{
+		"bytes=0-3":  "0123",
+		"bytes=7-":   "789",
+		"bytes=-2":   "89",
+		"bytes=8-20": "89",
+	}
+	for downloadRange, expected := range ranges {
+		output, err := store.GetObject("range", downloadRange)
+		assert.Nil(t, err)
+		data, _ := ioutil.ReadAll(output.Body)
+		output.Body.Close()
+		assert.Equal(t, expected, string(data))
+		assert.Equal(t, int64(len(expected)), aws.Int64Value(output.ContentLength))
+		assert.NotNil(t, output.ContentRange)
+	}
+
+	_, err := store.GetObject("range", "bytes=20-30")
+	assert.NotNil(t, err)
+}
+
+func Test_LocalBlobStore_Rejects_Escaping_Keys(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	assert.NotNil(t, store.PutObject("../outside", strings.NewReader("data"), DefaultFileContentType))
+	assert.NotNil(t, store.PutObject("", strings.NewReader("data"), DefaultFileContentType))
+	assert.NotNil(t, store.PutObject("abc/../../outside", strings.NewReader("data"), DefaultFileContentType))
+
+	// Dots inside a segment are fine
+	assert.Nil(t, store.PutObject("abc/a..b", strings.NewReader("data"), DefaultFileContentType))
+	_, err := store.HeadObject("abc/a..b")
+	assert.Nil(t, err)
+}
+
+func Test_LocalBlobStore_List_And_Bulk_Delete(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	keys := []string{"a/file", "a/metadata", "b/file"}
+	for _, key := range keys {
+		assert.Nil(t, store.PutObject(key, strings.NewReader(key), DefaultFileContentType))
+	}
+
+	var listed []string
+	assert.Nil(t, store.ListObjectPages("a/", func(objs []*s3.Object) bool {
+		for _, obj := range objs {
+			listed = append(listed, aws.StringValue(obj.Key))
+		}
+		return true
+	}))
+	assert.Equal(t, []string{"a/file", "a/metadata"}, listed)
+
+	assert.Nil(t, store.DeleteObjects(keys))
+	listed = nil
+	assert.Nil(t, store.ListObjectPages("", func(objs []*s3.Object) bool {
+		for _, obj := range objs {
+			listed = append(listed, aws.StringValue(obj.Key))
+		}
+		return true
+	}))
+	assert.Empty(t, listed)
+}
+
+func Test_LocalBlobStore_Multipart_Upload(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	firstPart := bytes.Repeat([]byte("a"), int(MinMultiPartSize))
+	lastPart := []byte("the end")
+
+	output, err := store.StartMultipartUpload("multi/file", DefaultFileContentType)
+	assert.Nil(t, err)
+	uploadID := aws.StringValue(output.UploadId)
+
+	part1, err := store.UploadPartOfMultiPartUpload("multi/file", uploadID, bytes.NewReader(firstPart), int64(len(firstPart)), 1, "")
+	assert.Nil(t, err)
+	part2, err := store.UploadPartOfMultiPartUpload("multi/file", uploadID, bytes.NewReader(lastPart), int64(len(lastPart)), 2, "")
+	assert.Nil(t, err)
+
+	// a wrong ETag must be rejected
+	_, err = store.FinishMultipartUpload("multi/file", uploadID, []*s3.CompletedPart{part1, {
+		ETag:       aws.String(`"bogus"`),
+		PartNumber: aws.Int64(2),
+	}})
+	assert.NotNil(t, err)
+
+	completed, err := store.FinishMultipartUpload("multi/file", uploadID, []*s3.CompletedPart{part1, part2})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(aws.StringValue(completed.ETag), `-2"`))
+
+	head, err := store.HeadObject("multi/file")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(firstPart)+len(lastPart)), aws.Int64Value(head.ContentLength))
+
+	// the upload is gone once it is completed
+	assert.NotNil(t, store.CancelMultipartUpload("multi/file", uploadID))
+}
+
+func Test_LocalBlobStore_Multipart_Content_MD5(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	output, err := store.StartMultipartUpload("multi/digest", DefaultFileContentType)
+	assert.Nil(t, err)
+	uploadID := aws.StringValue(output.UploadId)
+
+	sum := md5.Sum([]byte("data"))
+	part, err := store.UploadPartOfMultiPartUpload("multi/digest", uploadID, strings.NewReader("data"), 4, 1, ContentMD5(sum[:]))
+	assert.Nil(t, err)
+
+	// a part that doesn't match its Content-MD5 doesn't replace the one already uploaded
+	_, err = store.UploadPartOfMultiPartUpload("multi/digest", uploadID, strings.NewReader("atad"), 4, 1, ContentMD5(sum[:]))
+	aerr, ok := err.(awserr.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "BadDigest", aerr.Code())
+
+	_, err = store.FinishMultipartUpload("multi/digest", uploadID, []*s3.CompletedPart{part})
+	assert.Nil(t, err)
+	object, err := store.GetObject("multi/digest", "")
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(object.Body)
+	object.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+func Test_LocalBlobStore_Multipart_Abort(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	output, err := store.StartMultipartUpload("multi/aborted", DefaultFileContentType)
+	assert.Nil(t, err)
+	uploadID := aws.StringValue(output.UploadId)
+
+	_, err = store.UploadPartOfMultiPartUpload("multi/aborted", uploadID, strings.NewReader("data"), 4, 1, "")
+	assert.Nil(t, err)
+	assert.Nil(t, store.CancelMultipartUpload("multi/aborted", uploadID))
+
+	_, err = store.UploadPartOfMultiPartUpload("multi/aborted", uploadID, strings.NewReader("data"), 4, 2, "")
+	assert.NotNil(t, err)
+	_, err = store.HeadObject("multi/aborted")
+	assert.NotNil(t, err)
+}
+
+/*blockingReaderForTest signals started on its first read and waits for release before reading anything*/
+type blockingReaderForTest struct {
+	io.Reader
+	started, release chan struct{}
+}
+
+func (r *blockingReaderForTest) Read(p []byte) (int, error) {
+	if r.started != nil {
+		close(r.started)
+		r.started = nil
+		<-r.release
+	}
+	return r.Reader.Read(p)
+}
+
+func Test_LocalBlobStore_Part_Uploaded_While_Aborting(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	output, err := store.StartMultipartUpload("multi/aborting", DefaultFileContentType)
+	assert.Nil(t, err)
+	uploadID := aws.StringValue(output.UploadId)
+
+	body := &blockingReaderForTest{Reader: strings.NewReader("data"), started: make(chan struct{}), release: make(chan struct{})}
+	started := body.started
+	uploaded := make(chan error)
+	go func() {
+		_, err := store.uploadPart(uploadID, body, 1, "")
+		uploaded <- err
+	}()
+
+	<-started
+	assert.Nil(t, store.CancelMultipartUpload("multi/aborting", uploadID))
+	close(body.release)
+
+	// The part doesn't bring the aborted upload back
+	assert.NotNil(t, <-uploaded)
+	_, err = os.Stat(filepath.Join(store.root, localMultipartDir, uploadID))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_LocalBlobStore_Presigned_Part_Upload(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	output, err := store.StartMultipartUpload("multi/presigned", DefaultFileContentType)
+	assert.Nil(t, err)
+	uploadID := aws.StringValue(output.UploadId)
+
+	presigned, err := store.PresignUploadPart("multi/presigned", uploadID, 1, time.Hour)
+	assert.Nil(t, err)
+	u, err := url.Parse(presigned)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(u.Path, "/multi/presigned"))
+
+	// the signature covers the part number
+	forged := u.Query()
+	forged.Set("partNumber", "2")
+	_, err = store.uploadPresignedPart("multi/presigned", forged, strings.NewReader("data"), "")
+	assert.Equal(t, ErrLocalBlobBadSignature, err)
+	_, err = store.uploadPresignedPart("multi/other", u.Query(), strings.NewReader("data"), "")
+	assert.Equal(t, ErrLocalBlobBadSignature, err)
+
+	part, err := store.uploadPresignedPart("multi/presigned", u.Query(), strings.NewReader("data"), "")
+	assert.Nil(t, err)
+
+	parts, err := store.ListParts("multi/presigned", uploadID)
+	assert.Nil(t, err)
+	assert.Len(t, parts, 1)
+	assert.Equal(t, int64(1), aws.Int64Value(parts[0].PartNumber))
+	assert.Equal(t, int64(4), aws.Int64Value(parts[0].Size))
+	assert.Equal(t, aws.StringValue(part.ETag), aws.StringValue(parts[0].ETag))
+
+	// presigned URLs survive a restart of the node
+	reopened, err := newLocalBlobStore(store.root)
+	assert.Nil(t, err)
+	_, err = reopened.uploadPresignedPart("multi/presigned", u.Query(), strings.NewReader("data"), "")
+	assert.Nil(t, err)
+
+	expired, err := store.PresignUploadPart("multi/presigned", uploadID, 1, -time.Minute)
+	assert.Nil(t, err)
+	u, _ = url.Parse(expired)
+	_, err = store.uploadPresignedPart("multi/presigned", u.Query(), strings.NewReader("data"), "")
+	assert.Equal(t, ErrLocalBlobBadSignature, err)
+}
+
+func Test_LocalBlobStore_Presigned_Get(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	assert.Nil(t, store.PutObject("private/file", strings.NewReader("data"), DefaultFileContentType))
+
+	presigned, err := store.PresignGetObject("private/file", time.Hour)
+	assert.Nil(t, err)
+	u, err := url.Parse(presigned)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(u.Path, "/private/file"))
+
+	// the object stays private, only the signed URL reads it
+	output, err := store.getPresignedObject("private/file", "", u.Query())
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(output.Body)
+	output.Body.Close()
+	assert.Equal(t, "data", string(data))
+	_, err = store.getPublicObject("private/file", "")
+	assert.Equal(t, ErrLocalBlobNotPublic, err)
+
+	// the signature covers the key and the expiration
+	_, err = store.getPresignedObject("private/other", "", u.Query())
+	assert.Equal(t, ErrLocalBlobBadSignature, err)
+	forged := u.Query()
+	forged.Set("expires", "99999999999")
+	_, err = store.getPresignedObject("private/file", "", forged)
+	assert.Equal(t, ErrLocalBlobBadSignature, err)
+
+	expired, err := store.PresignGetObject("private/file", -time.Minute)
+	assert.Nil(t, err)
+	u, _ = url.Parse(expired)
+	_, err = store.getPresignedObject("private/file", "", u.Query())
+	assert.Equal(t, ErrLocalBlobBadSignature, err)
+
+	_, err = store.PresignGetObject("private/missing", time.Hour)
+	assert.NotNil(t, err)
+}
+
+func Test_LocalBlobStore_Canned_Acl(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	assert.Nil(t, store.PutObject("acl/file", strings.NewReader("data"), DefaultFileContentType))
+
+	_, err := store.getPublicObject("acl/file", "")
+	assert.Equal(t, ErrLocalBlobNotPublic, err)
+
+	assert.Nil(t, store.SetObjectCannedAcl("acl/file", CannedAcl_PublicRead))
+	output, err := store.getPublicObject("acl/file", "")
+	assert.Nil(t, err)
+	output.Body.Close()
+
+	assert.NotNil(t, store.SetObjectCannedAcl("acl/missing", CannedAcl_PublicRead))
+}
+
+func Test_LocalBlobStore_Lifecycle(t *testing.T) {
+	store := newLocalBlobStoreForTest(t)
+	defer os.RemoveAll(store.root)
+
+	rules := []*s3.LifecycleRule{
+		{
+			ID:         aws.String("expire"),
+			Status:     aws.String(s3.ExpirationStatusEnabled),
+			Expiration: &s3.LifecycleExpiration{Days: aws.Int64(1)},
+			Filter:     &s3.LifecycleRuleFilter{Prefix: aws.String("expiring/")},
+		},
+		{
+			ID:                             aws.String("abort"),
+			Status:                         aws.String(s3.ExpirationStatusEnabled),
+			AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int64(1)},
+			Filter:                         &s3.LifecycleRuleFilter{Prefix: aws.String("")},
+		},
+	}
+	assert.Nil(t, store.PutBucketLifecycleConfiguration(rules))
+
+	stored, err := store.GetBucketLifecycleConfiguration()
+	assert.Nil(t, err)
+	assert.Len(t, stored, 2)
+	assert.Equal(t, "expire", aws.StringValue(stored[0].ID))
+
+	assert.Nil(t, store.PutObject("expiring/file", strings.NewReader("data"), DefaultFileContentType))
+	assert.Nil(t, store.PutObject("kept/file", strings.NewReader("data"), DefaultFileContentType))
+	output, err := store.StartMultipartUpload("kept/multi", DefaultFileContentType)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.RunLifecycle(time.Now()))
+	_, err = store.HeadObject("expiring/file")
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.RunLifecycle(time.Now().Add(49*time.Hour)))
+	_, err = store.HeadObject("expiring/file")
+	assert.NotNil(t, err)
+	_, err = store.HeadObject("kept/file")
+	assert.Nil(t, err)
+	assert.NotNil(t, store.CancelMultipartUpload("kept/multi", aws.StringValue(output.UploadId)))
+}
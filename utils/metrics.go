@@ -16,7 +16,7 @@ var (
 		Help: "The total number of ping std out",
 	})
 
-	// Statis for Ok, BadRequest, InternalError, ServiceUnavailable, Forbidden, NotFound
+	// Statis for Ok, BadRequest, InternalError, ServiceUnavailable, Forbidden, NotFound, Gone, TooManyRequests
 	Metrics_Http_Response_Counter = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "storagenode_http_response_counter",
 		Help: "The total number of Http Response code",
@@ -25,6 +25,8 @@ var (
 	Metrics_400_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "400"})
 	Metrics_403_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "403"})
 	Metrics_404_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "404"})
+	Metrics_410_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "410"})
+	Metrics_429_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "429"})
 	Metrics_500_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "500"})
 	Metrics_503_Response_Counter = Metrics_Http_Response_Counter.With(prometheus.Labels{"response_code": "503"})
 
@@ -101,6 +103,66 @@ var (
 		Help: "Totals all the file sizes of rows in completed_files table in SQL, as MB",
 	})
 
+	Metrics_KvStore_Lsm_Size_Bytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_kvstore_lsm_size_bytes",
+		Help: "Size of the LSM tree of the badger kv store, in bytes",
+	})
+
+	Metrics_KvStore_Vlog_Size_Bytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_kvstore_vlog_size_bytes",
+		Help: "Size of the value log of the badger kv store, in bytes",
+	})
+
+	Metrics_KvStore_Keys_Count = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_kvstore_keys_count",
+		Help: "Number of keys in the LSM tables of the badger kv store, including old versions not compacted yet",
+	})
+
+	Metrics_KvStore_Vlog_GC_Rewrites_Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storagenode_kvstore_vlog_gc_rewrites_counter",
+		Help: "The total number of value log files rewritten by the garbage collection",
+	})
+
+	Metrics_Rate_Limited_Counter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "storagenode_rate_limited_requests_counter",
+		Help: "The total number of requests rejected by the rate limiter",
+	}, []string{"route_group", "key_type"})
+
+	Metrics_Integrity_Open_Issues = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "storagenode_integrity_open_issues",
+		Help: "Number of completed files with an open integrity report",
+	}, []string{"issue"})
+
+	Metrics_Integrity_Files_Scrubbed_Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storagenode_integrity_files_scrubbed_counter",
+		Help: "The total number of completed files checked by the integrity scrubber",
+	})
+
+	Metrics_Integrity_Last_Pass_Timestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_integrity_last_pass_timestamp",
+		Help: "Unix time at which the integrity scrubber last finished checking every completed file",
+	})
+
+	Metrics_Reconcile_Orphan_Objects = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_reconcile_orphan_objects",
+		Help: "Number of objects in the bucket no row referred to at the last reconciliation",
+	})
+
+	Metrics_Reconcile_Missing_Objects = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_reconcile_missing_objects",
+		Help: "Number of objects referred to by a row that were not in the bucket at the last reconciliation",
+	})
+
+	Metrics_Accounts_With_Drifted_Usage = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "storagenode_accounts_with_drifted_usage",
+		Help: "Number of accounts whose storage counters didn't match the files and metadatas they own at the last recomputation",
+	})
+
+	Metrics_Download_Proxy_Bytes_Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "storagenode_download_proxy_bytes_counter",
+		Help: "The total number of bytes of objects streamed to clients by the node",
+	})
+
 	// TODO:  use AWS cloudwatch to get these last two metrics
 	// https://docs.aws.amazon.com/sdk-for-go/api/service/cloudwatch/#CloudWatch.GetMetricStatistics
 	//Metrics_Files_Count_S3 = promauto.NewGauge(prometheus.GaugeOpts{
@@ -131,3 +193,9 @@ func GetMetricCounter(m prometheus.Counter) float64 {
 	m.Write(pb)
 	return pb.GetCounter().GetValue()
 }
+
+func GetMetricGauge(m prometheus.Gauge) float64 {
+	pb := &dto.Metric{}
+	m.Write(pb)
+	return pb.GetGauge().GetValue()
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// How often buckets that refilled completely are dropped from memory
+const rateLimiterSweepInterval = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+/*RateLimiter is an in-memory token bucket per key. Each bucket holds up to perMinute tokens and
+refills at perMinute tokens a minute, a request takes one token. Buckets are kept per node, several
+nodes behind a load balancer each enforce the limit on their own.*/
+type RateLimiter struct {
+	mutex     sync.Mutex
+	perSecond float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+/*NewRateLimiter returns a RateLimiter allowing perMinute requests a minute for each key*/
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{
+		perSecond: float64(perMinute) / 60,
+		burst:     float64(perMinute),
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+/*Allow takes a token from the bucket of key. When the bucket is empty it returns false and how long
+until a token is available again.*/
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = l.refilledTokens(bucket, now)
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		return false, l.timeUntilToken(bucket.tokens)
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+func (l *RateLimiter) refilledTokens(bucket *tokenBucket, now time.Time) float64 {
+	return math.Min(l.burst, bucket.tokens+now.Sub(bucket.lastSeen).Seconds()*l.perSecond)
+}
+
+func (l *RateLimiter) timeUntilToken(tokens float64) time.Duration {
+	return time.Duration((1 - tokens) / l.perSecond * float64(time.Second))
+}
+
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	// A bucket that had time to refill is the same as a new one
+	refillTime := time.Duration(l.burst / l.perSecond * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) >= refillTime {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRateLimiterForTest(perMinute int) (*RateLimiter, *time.Time) {
+	limiter := NewRateLimiter(perMinute)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func Test_RateLimiter_Allows_Burst_Then_Rejects(t *testing.T) {
+	limiter, _ := newRateLimiterForTest(3)
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("key")
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter := limiter.Allow("key")
+	assert.False(t, allowed)
+	assert.Equal(t, 20*time.Second, retryAfter)
+
+	// other keys have their own bucket
+	allowed, _ = limiter.Allow("otherKey")
+	assert.True(t, allowed)
+}
+
+func Test_RateLimiter_Refills(t *testing.T) {
+	limiter, now := newRateLimiterForTest(60)
+
+	for i := 0; i < 60; i++ {
+		limiter.Allow("key")
+	}
+	allowed, _ := limiter.Allow("key")
+	assert.False(t, allowed)
+
+	*now = now.Add(time.Second)
+	allowed, _ = limiter.Allow("key")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("key")
+	assert.False(t, allowed)
+}
+
+func Test_RateLimiter_Sweeps_Idle_Buckets(t *testing.T) {
+	limiter, now := newRateLimiterForTest(60)
+
+	limiter.Allow("key")
+	assert.Len(t, limiter.buckets, 1)
+
+	*now = now.Add(rateLimiterSweepInterval)
+	limiter.Allow("otherKey")
+	assert.Len(t, limiter.buckets, 1)
+	_, ok := limiter.buckets["otherKey"]
+	assert.True(t, ok)
+}
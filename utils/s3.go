@@ -2,8 +2,13 @@ package utils
 
 import (
 	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -29,6 +34,9 @@ const (
 	DefaultFileContentType    = "application/octet-stream"
 )
 
+/*ErrS3NotEnabled is returned for the requests that can't be skipped when S3 is not set up*/
+var ErrS3NotEnabled = errors.New("S3 is not enabled")
+
 var awsPagingSize int64
 var svc *s3Wrapper
 var cachedData cmap.ConcurrentMap
@@ -53,7 +61,7 @@ func SetS3DataCaching(isCaching bool) {
 }
 
 func IsS3Enabled() bool {
-	return svc.s3 != nil
+	return svc != nil && svc.s3 != nil
 }
 
 /* Create a private bucket with bucketName. */
@@ -72,87 +80,63 @@ func deleteBucket(bucketName string) error {
 	return svc.DeleteBucket(input)
 }
 
-func doesObjectExist(bucketName string, objectKey string) bool {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-	}
-
-	r, err := svc.HeadObject(input)
+func doesObjectExist(objectKey string) bool {
+	r, err := blobStore.HeadObject(objectKey)
 	return err == nil && r != nil
 }
 
-func getObjectSizeInByte(bucketName string, objectKey string) int64 {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-	}
-
-	r, err := svc.HeadObject(input)
-	if err != nil {
+func getObjectSizeInByte(objectKey string) int64 {
+	r, err := blobStore.HeadObject(objectKey)
+	if err != nil || r == nil {
 		return 0
 	}
 	return aws.Int64Value(r.ContentLength)
 }
 
-func getObject(bucketName, objectKey, downloadRange string, cached bool) (string, error) {
+func getObject(objectKey, downloadRange string, cached bool) (string, error) {
 	if cached {
-		valueS, okS := cachedData.Get(getKey(bucketName, objectKey))
+		valueS, okS := cachedData.Get(getKey(Env.BucketName, objectKey))
 		if okS {
 			return valueS.(string), nil
 		}
 	}
 
-	input := &s3.GetObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-	}
-	if downloadRange != "" {
-		input.SetRange(downloadRange)
-	}
-
-	output, err := svc.s3.GetObject(input)
+	output, err := blobStore.GetObject(objectKey, downloadRange)
 	if err != nil {
 		return "", err
 	}
+	defer output.Body.Close()
 
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(output.Body)
 	outputString := buf.String()
 
 	if err == nil && shouldCachedData {
-		cachedData.Set(getKey(bucketName, objectKey), outputString)
+		cachedData.Set(getKey(Env.BucketName, objectKey), outputString)
 	}
 
 	return outputString, err
 }
 
-func getObjectOutput(bucketName, objectKey, downloadRange string, cached bool) (*s3.GetObjectOutput, error) {
+func getObjectOutput(objectKey, downloadRange string, cached bool) (*s3.GetObjectOutput, error) {
 	if cached {
-		valueR, okR := cachedData.Get(getKey(bucketName, objectKey+"_object"))
+		valueR, okR := cachedData.Get(getKey(Env.BucketName, objectKey+"_object"))
 		if okR {
 			return valueR.(*s3.GetObjectOutput), nil
 		}
 	}
 
-	input := &s3.GetObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-	}
-	if downloadRange != "" {
-		input.SetRange(downloadRange)
-	}
-	output, err := svc.s3.GetObject(input)
+	output, err := blobStore.GetObject(objectKey, downloadRange)
 
 	if err == nil && shouldCachedData {
-		cachedData.Set(getKey(bucketName, objectKey+"_object"), output)
+		cachedData.Set(getKey(Env.BucketName, objectKey+"_object"), output)
 	}
 
 	return output, err
 }
 
-func getObjectAsString(bucketName, objectKey, downloadRange string, cached bool) (string, error) {
-	outputString, err := getObject(bucketName, objectKey, downloadRange, cached)
+func getObjectAsString(objectKey, downloadRange string, cached bool) (string, error) {
+	outputString, err := getObject(objectKey, downloadRange, cached)
 	if err != nil {
 		return "", err
 	}
@@ -160,42 +144,24 @@ func getObjectAsString(bucketName, objectKey, downloadRange string, cached bool)
 	return outputString, nil
 }
 
-func setObject(bucketName, objectKey, data, fileContentType string) error {
-	input := &s3.PutObjectInput{
-		Body:        aws.ReadSeekCloser(strings.NewReader(data)),
-		Bucket:      aws.String(bucketName),
-		Key:         aws.String(objectKey),
-		ContentType: aws.String(fileContentType),
-	}
-
-	err := svc.PutObject(input)
+func setObject(objectKey, data, fileContentType string) error {
+	err := blobStore.PutObject(objectKey, strings.NewReader(data), fileContentType)
 	if err == nil && shouldCachedData {
-		cachedData.Set(getKey(bucketName, objectKey), data)
+		cachedData.Set(getKey(Env.BucketName, objectKey), data)
 	}
 	return err
 }
 
-func deleteObject(bucketName string, objectKey string) error {
-	cachedData.Remove(getKey(bucketName, objectKey))
+func deleteObject(objectKey string) error {
+	cachedData.Remove(getKey(Env.BucketName, objectKey))
 
-	input := &s3.DeleteObjectInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectKey),
-	}
-
-	return svc.DeleteObject(input)
+	return blobStore.DeleteObject(objectKey)
 }
 
-func listObjectKeys(bucketName string, objectKeyPrefix string) ([]string, error) {
+func listObjectKeys(objectKeyPrefix string) ([]string, error) {
 	var keys []string
 
-	input := &s3.ListObjectsV2Input{
-		Bucket:  aws.String(bucketName),
-		Prefix:  aws.String(objectKeyPrefix),
-		MaxKeys: aws.Int64(awsPagingSize),
-	}
-
-	err := svc.ListObjectPages(input, func(objs []*s3.Object) bool {
+	err := blobStore.ListObjectPages(objectKeyPrefix, func(objs []*s3.Object) bool {
 		for _, c := range objs {
 			keys = append(keys, aws.StringValue(c.Key))
 		}
@@ -204,27 +170,19 @@ func listObjectKeys(bucketName string, objectKeyPrefix string) ([]string, error)
 	return keys, err
 }
 
-func deleteObjectKeys(bucketName string, objectKeyPrefix string) error {
-	input := &s3.ListObjectsV2Input{
-		Bucket:  aws.String(bucketName),
-		Prefix:  aws.String(objectKeyPrefix),
-		MaxKeys: aws.Int64(awsPagingSize),
-	}
-
+func deleteObjectKeys(objectKeyPrefix string) error {
 	var deleteErr error
 
-	err := svc.ListObjectPages(input, func(objs []*s3.Object) bool {
-		var objIdentifier []*s3.ObjectIdentifier
+	err := blobStore.ListObjectPages(objectKeyPrefix, func(objs []*s3.Object) bool {
+		var keys []string
 		for _, c := range objs {
-			objIdentifier = append(objIdentifier, &s3.ObjectIdentifier{Key: c.Key})
+			cachedData.Remove(getKey(Env.BucketName, aws.StringValue(c.Key)))
+			keys = append(keys, aws.StringValue(c.Key))
 		}
-		deleteInput := &s3.DeleteObjectsInput{
-			Bucket: aws.String(bucketName),
-			Delete: &s3.Delete{
-				Objects: objIdentifier,
-			},
+		if len(keys) == 0 {
+			return true
 		}
-		deleteErr = svc.DeleteObjects(deleteInput)
+		deleteErr = blobStore.DeleteObjects(keys)
 		return deleteErr == nil
 	})
 
@@ -235,97 +193,99 @@ func deleteObjectKeys(bucketName string, objectKeyPrefix string) error {
 }
 
 func createMultiPartUpload(key, fileType string) (*string, *string, error) {
-	output, err := svc.StartMultipartUpload(key, fileType)
+	output, err := blobStore.StartMultipartUpload(key, fileType)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	return output.Key, output.UploadId, err
 }
 
-func uploadPart(key, uploadID string, fileBytes []byte, partNumber int) (*s3.CompletedPart, error) {
-	return svc.UploadPartOfMultiPartUpload(key, uploadID, fileBytes, partNumber)
+func uploadPart(key, uploadID string, body io.ReadSeeker, size int64, partNumber int, contentMD5 string) (*s3.CompletedPart, error) {
+	return blobStore.UploadPartOfMultiPartUpload(key, uploadID, body, size, partNumber, contentMD5)
+}
+
+func listParts(key, uploadID string) ([]*s3.Part, error) {
+	return blobStore.ListParts(key, uploadID)
+}
+
+func presignUploadPart(key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	return blobStore.PresignUploadPart(key, uploadID, partNumber, expires)
+}
+
+func presignGetObject(key string, expires time.Duration) (string, error) {
+	return blobStore.PresignGetObject(key, expires)
 }
 
 func abortMultiPartUpload(key, uploadID string) error {
-	return svc.CancelMultipartUpload(key, uploadID)
+	return blobStore.CancelMultipartUpload(key, uploadID)
 }
 
 func completeMultiPartUpload(key, uploadID string,
 	completedParts []*s3.CompletedPart) (*s3.CompleteMultipartUploadOutput, error) {
-	return svc.FinishMultipartUpload(key, uploadID, completedParts)
+	return blobStore.FinishMultipartUpload(key, uploadID, completedParts)
 }
 
-func setObjectCannedAcl(bucketName string, objectName string, cannedAcl string) error {
-	input := &s3.PutObjectAclInput{
-		Bucket: aws.String(bucketName),
-		Key:    aws.String(objectName),
-		ACL:    aws.String(cannedAcl),
-	}
-
-	return svc.SetObjectCannedAcl(input)
+func setObjectCannedAcl(objectName string, cannedAcl string) error {
+	return blobStore.SetObjectCannedAcl(objectName, cannedAcl)
 }
 
-func setBucketLifecycle(bucketName string, rules []*s3.LifecycleRule) error {
-	input := &s3.PutBucketLifecycleConfigurationInput{
-		Bucket: aws.String(bucketName),
-		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{
-			Rules: rules,
-		},
-	}
-
-	return svc.PutBucketLifecycleConfiguration(input)
+func setBucketLifecycle(rules []*s3.LifecycleRule) error {
+	return blobStore.PutBucketLifecycleConfiguration(rules)
 }
 
-func getBucketLifecycle(bucketName string) ([]*s3.LifecycleRule, error) {
-	input := &s3.GetBucketLifecycleConfigurationInput{
-		Bucket: aws.String(bucketName),
-	}
-	return svc.GetBucketLifecycleConfiguration(input)
+func getBucketLifecycle() ([]*s3.LifecycleRule, error) {
+	return blobStore.GetBucketLifecycleConfiguration()
 }
 
-func iterateBucketAllObjects(bucketName string, i ObjectIterator) error {
-	input := &s3.ListObjectsV2Input{
-		Bucket:  aws.String(bucketName),
-		MaxKeys: aws.Int64(awsPagingSize),
-	}
-	return svc.ListObjectPages(input, i)
+func iterateBucketAllObjects(i ObjectIterator) error {
+	return blobStore.ListObjectPages("", i)
 }
 
-func deleteObjects(bucketName string, objectKeys []string) error {
-	input := &s3.DeleteObjectsInput{
-		Bucket: aws.String(bucketName),
+func deleteObjects(objectKeys []string) error {
+	for _, objectKey := range objectKeys {
+		cachedData.Remove(getKey(Env.BucketName, objectKey))
 	}
 
-	for idRange := range gopart.Partition(len(objectKeys), int(awsPagingSize)) {
-		var objIdentifier []*s3.ObjectIdentifier
-		for _, c := range objectKeys[idRange.Low:idRange.High] {
-			objIdentifier = append(objIdentifier, &s3.ObjectIdentifier{Key: aws.String(c)})
-		}
-		input.Delete = &s3.Delete{
-			Objects: objIdentifier,
-			Quiet:   aws.Bool(true),
-		}
-
-		if err := svc.DeleteObjects(input); err != nil {
-			return err
-		}
-	}
-	return nil
+	return blobStore.DeleteObjects(objectKeys)
 }
 
 func DoesDefaultBucketObjectExist(objectKey string) bool {
-	return doesObjectExist(Env.BucketName, objectKey)
+	return doesObjectExist(objectKey)
 }
 
 // Get Object operation on defaultBucketName
 func GetDefaultBucketObject(objectKey string, cached bool) (string, error) {
-	return getObjectAsString(Env.BucketName, objectKey, "", cached)
+	return getObjectAsString(objectKey, "", cached)
 }
 
 func GetBucketObject(objectKey, downloadRange string, cached bool) (*s3.GetObjectOutput, error) {
-	return getObjectOutput(Env.BucketName, objectKey, downloadRange, cached)
+	return getObjectOutput(objectKey, downloadRange, cached)
+}
+
+/*HeadBucketObject returns the headers of the object without its data*/
+func HeadBucketObject(objectKey string) (*s3.HeadObjectOutput, error) {
+	return blobStore.HeadObject(objectKey)
 }
 
 func GetDefaultBucketObjectSize(objectKey string) int64 {
-	return getObjectSizeInByte(Env.BucketName, objectKey)
+	return getObjectSizeInByte(objectKey)
+}
+
+/*HeadDefaultBucketObject returns the size of the object and whether it exists. An object that is not
+in the bucket is not an error, only a failed request is.*/
+func HeadDefaultBucketObject(objectKey string) (int64, bool, error) {
+	r, err := blobStore.HeadObject(objectKey)
+	if err != nil {
+		if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 404 {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	if r == nil {
+		return 0, false, nil
+	}
+	return aws.Int64Value(r.ContentLength), true, nil
 }
 
 // Set Object operation on defaultBucketName
@@ -333,22 +293,22 @@ func SetDefaultBucketObject(objectKey, data, fileContentType string) error {
 	if fileContentType == "" {
 		fileContentType = DefaultFileContentType
 	}
-	return setObject(Env.BucketName, objectKey, data, fileContentType)
+	return setObject(objectKey, data, fileContentType)
 }
 
 // Delete Object operation on defaultBucketName with particular prefix
 func DeleteDefaultBucketObject(objectKey string) error {
-	return deleteObject(Env.BucketName, objectKey)
+	return deleteObject(objectKey)
 }
 
 // List Object operation on defaultBucketName with particular prefix
 func ListDefaultBucketObjectKeys(objectKeyPrefix string) ([]string, error) {
-	return listObjectKeys(Env.BucketName, objectKeyPrefix)
+	return listObjectKeys(objectKeyPrefix)
 }
 
 // Delete all the object operation on defaultBucketName with particular prefix
 func DeleteDefaultBucketObjectKeys(objectKeyPrefix string) error {
-	return deleteObjectKeys(Env.BucketName, objectKeyPrefix)
+	return deleteObjectKeys(objectKeyPrefix)
 }
 
 func CreateMultiPartUpload(key, fileContentType string) (*string, *string, error) {
@@ -359,7 +319,34 @@ func CreateMultiPartUpload(key, fileContentType string) (*string, *string, error
 }
 
 func UploadMultiPartPart(key, uploadID string, fileBytes []byte, partNumber int) (*s3.CompletedPart, error) {
-	return uploadPart(key, uploadID, fileBytes, partNumber)
+	sum := md5.Sum(fileBytes)
+	return uploadPart(key, uploadID, bytes.NewReader(fileBytes), int64(len(fileBytes)), partNumber, ContentMD5(sum[:]))
+}
+
+/*UploadMultiPartPartFromReader uploads a part of size bytes read from body, which is rewound between retries.
+The blob store rejects the part if its MD5 is not contentMD5, unless contentMD5 is empty.*/
+func UploadMultiPartPartFromReader(key, uploadID string, body io.ReadSeeker, size int64, partNumber int, contentMD5 string) (*s3.CompletedPart, error) {
+	return uploadPart(key, uploadID, body, size, partNumber, contentMD5)
+}
+
+/*ContentMD5 returns the value of a Content-MD5 header for an MD5 sum*/
+func ContentMD5(sum []byte) string {
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+/*ListMultiPartUploadParts returns the parts uploaded so far to a multipart upload, ordered by part number*/
+func ListMultiPartUploadParts(key, uploadID string) ([]*s3.Part, error) {
+	return listParts(key, uploadID)
+}
+
+/*PresignMultiPartPartURL returns a URL a client can PUT a part of a multipart upload to without going through the node*/
+func PresignMultiPartPartURL(key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	return presignUploadPart(key, uploadID, partNumber, expires)
+}
+
+/*PresignDefaultBucketObjectURL returns a URL anyone can GET the object at until it expires, the object can stay private*/
+func PresignDefaultBucketObjectURL(key string, expires time.Duration) (string, error) {
+	return presignGetObject(key, expires)
 }
 
 func AbortMultiPartUpload(key, uploadID string) error {
@@ -372,23 +359,23 @@ func CompleteMultiPartUpload(key, uploadID string,
 }
 
 func SetDefaultObjectCannedAcl(objectKey string, cannedAcl string) error {
-	return setObjectCannedAcl(Env.BucketName, objectKey, cannedAcl)
+	return setObjectCannedAcl(objectKey, cannedAcl)
 }
 
 func SetDefaultBucketLifecycle(rules []*s3.LifecycleRule) error {
-	return setBucketLifecycle(Env.BucketName, rules)
+	return setBucketLifecycle(rules)
 }
 
 func GetDefaultBucketLifecycle() ([]*s3.LifecycleRule, error) {
-	return getBucketLifecycle(Env.BucketName)
+	return getBucketLifecycle()
 }
 
 func IterateDefaultBucketAllObjects(i ObjectIterator) error {
-	return iterateBucketAllObjects(Env.BucketName, i)
+	return iterateBucketAllObjects(i)
 }
 
 func DeleteDefaultBucketObjects(objectKeys []string) error {
-	return deleteObjects(Env.BucketName, objectKeys)
+	return deleteObjects(objectKeys)
 }
 
 func getKey(bucketName string, objectKey string) string {
@@ -413,11 +400,28 @@ func (svc *s3Wrapper) DeleteBucket(input *s3.DeleteBucketInput) error {
 	return err
 }
 
-func (svc *s3Wrapper) DeleteObject(input *s3.DeleteObjectInput) error {
+func (svc *s3Wrapper) GetObject(key, downloadRange string) (*s3.GetObjectOutput, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(Env.BucketName),
+		Key:    aws.String(key),
+	}
+	if downloadRange != "" {
+		input.SetRange(downloadRange)
+	}
+
+	return svc.s3.GetObject(input)
+}
+
+func (svc *s3Wrapper) DeleteObject(key string) error {
 	if svc.s3 == nil {
 		return nil
 	}
 
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(Env.BucketName),
+		Key:    aws.String(key),
+	}
+
 	_, err := svc.s3.DeleteObject(input)
 	if err != nil {
 		if aerr, ok := err.(awserr.RequestFailure); ok {
@@ -429,50 +433,93 @@ func (svc *s3Wrapper) DeleteObject(input *s3.DeleteObjectInput) error {
 	return err
 }
 
-func (svc *s3Wrapper) PutObject(input *s3.PutObjectInput) error {
+func (svc *s3Wrapper) PutObject(key string, body io.ReadSeeker, contentType string) error {
 	if svc.s3 == nil {
 		return nil
 	}
 
+	input := &s3.PutObjectInput{
+		Body:        aws.ReadSeekCloser(body),
+		Bucket:      aws.String(Env.BucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}
+
 	_, err := svc.s3.PutObject(input)
 	return err
 }
 
-func (svc *s3Wrapper) ListObjectPages(input *s3.ListObjectsV2Input, it ObjectIterator) error {
+func (svc *s3Wrapper) ListObjectPages(prefix string, it ObjectIterator) error {
 	if svc.s3 == nil {
 		it(nil)
 		return nil
 	}
 
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(Env.BucketName),
+		MaxKeys: aws.Int64(awsPagingSize),
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
 	err := svc.s3.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
 		return it(page.Contents)
 	})
 	return err
 }
 
-func (svc *s3Wrapper) DeleteObjects(input *s3.DeleteObjectsInput) error {
+func (svc *s3Wrapper) DeleteObjects(keys []string) error {
 	if svc.s3 == nil {
 		return nil
 	}
 
-	_, err := svc.s3.DeleteObjects(input)
-	return err
+	input := &s3.DeleteObjectsInput{
+		Bucket: aws.String(Env.BucketName),
+	}
+
+	for idRange := range gopart.Partition(len(keys), int(awsPagingSize)) {
+		var objIdentifier []*s3.ObjectIdentifier
+		for _, c := range keys[idRange.Low:idRange.High] {
+			objIdentifier = append(objIdentifier, &s3.ObjectIdentifier{Key: aws.String(c)})
+		}
+		input.Delete = &s3.Delete{
+			Objects: objIdentifier,
+			Quiet:   aws.Bool(true),
+		}
+
+		if _, err := svc.s3.DeleteObjects(input); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (svc *s3Wrapper) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+func (svc *s3Wrapper) HeadObject(key string) (*s3.HeadObjectOutput, error) {
 	if svc.s3 == nil {
 		return nil, nil
 	}
 
+	input := &s3.HeadObjectInput{
+		Bucket: aws.String(Env.BucketName),
+		Key:    aws.String(key),
+	}
+
 	out, err := svc.s3.HeadObject(input)
 	return out, err
 }
 
-func (svc *s3Wrapper) SetObjectCannedAcl(input *s3.PutObjectAclInput) error {
+func (svc *s3Wrapper) SetObjectCannedAcl(key, cannedAcl string) error {
 	if svc.s3 == nil {
 		return nil
 	}
 
+	input := &s3.PutObjectAclInput{
+		Bucket: aws.String(Env.BucketName),
+		Key:    aws.String(key),
+		ACL:    aws.String(cannedAcl),
+	}
+
 	_, err := svc.s3.PutObjectAcl(input)
 	return err
 }
@@ -491,19 +538,25 @@ func (svc *s3Wrapper) StartMultipartUpload(key, fileType string) (*s3.CreateMult
 	return svc.s3.CreateMultipartUpload(input)
 }
 
-func (svc *s3Wrapper) UploadPartOfMultiPartUpload(key, uploadID string, fileBytes []byte,
-	partNumber int) (*s3.CompletedPart, error) {
+func (svc *s3Wrapper) UploadPartOfMultiPartUpload(key, uploadID string, body io.ReadSeeker, size int64,
+	partNumber int, contentMD5 string) (*s3.CompletedPart, error) {
 	tryNum := 1
 	partInput := &s3.UploadPartInput{
-		Body:          bytes.NewReader(fileBytes),
+		Body:          body,
 		Bucket:        aws.String(Env.BucketName),
 		Key:           aws.String(key),
 		UploadId:      aws.String(uploadID),
 		PartNumber:    aws.Int64(int64(partNumber)),
-		ContentLength: aws.Int64(int64(len(fileBytes))),
+		ContentLength: aws.Int64(size),
+	}
+	if contentMD5 != "" {
+		partInput.ContentMD5 = aws.String(contentMD5)
 	}
 
 	for tryNum <= MaxMultiPartRetries {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
 		uploadResult, err := svc.s3.UploadPart(partInput)
 		if err != nil {
 			if tryNum == MaxMultiPartRetries {
@@ -546,20 +599,77 @@ func (svc *s3Wrapper) CancelMultipartUpload(key, uploadID string) error {
 	return err
 }
 
-func (svc *s3Wrapper) PutBucketLifecycleConfiguration(input *s3.PutBucketLifecycleConfigurationInput) error {
+func (svc *s3Wrapper) ListParts(key, uploadID string) ([]*s3.Part, error) {
+	if svc.s3 == nil {
+		return nil, nil
+	}
+
+	input := &s3.ListPartsInput{
+		Bucket:   aws.String(Env.BucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+		MaxParts: aws.Int64(awsPagingSize),
+	}
+
+	var parts []*s3.Part
+	err := svc.s3.ListPartsPages(input, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		parts = append(parts, page.Parts...)
+		return true
+	})
+	return parts, err
+}
+
+func (svc *s3Wrapper) PresignUploadPart(key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	if svc.s3 == nil {
+		return "", ErrS3NotEnabled
+	}
+
+	req, _ := svc.s3.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(Env.BucketName),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int64(int64(partNumber)),
+	})
+	return req.Presign(expires)
+}
+
+func (svc *s3Wrapper) PresignGetObject(key string, expires time.Duration) (string, error) {
+	if svc.s3 == nil {
+		return "", ErrS3NotEnabled
+	}
+
+	req, _ := svc.s3.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(Env.BucketName),
+		Key:    aws.String(key),
+	})
+	return req.Presign(expires)
+}
+
+func (svc *s3Wrapper) PutBucketLifecycleConfiguration(rules []*s3.LifecycleRule) error {
 	if svc.s3 == nil {
 		return nil
 	}
 
+	input := &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(Env.BucketName),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{
+			Rules: rules,
+		},
+	}
+
 	_, err := svc.s3.PutBucketLifecycleConfiguration(input)
 	return err
 }
 
-func (svc *s3Wrapper) GetBucketLifecycleConfiguration(input *s3.GetBucketLifecycleConfigurationInput) ([]*s3.LifecycleRule, error) {
+func (svc *s3Wrapper) GetBucketLifecycleConfiguration() ([]*s3.LifecycleRule, error) {
 	if svc.s3 == nil {
 		return nil, nil
 	}
 
+	input := &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(Env.BucketName),
+	}
+
 	v, err := svc.s3.GetBucketLifecycleConfiguration(input)
 	if err != nil {
 		if aerr, ok := err.(awserr.RequestFailure); ok {
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const s3AccessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// Positions of the fields of an S3 server access log line the node uses
+const (
+	s3AccessLogTimeField      = 2
+	s3AccessLogRequesterField = 4
+	s3AccessLogOperationField = 6
+	s3AccessLogKeyField       = 7
+	s3AccessLogStatusField    = 9
+	s3AccessLogBytesField     = 11
+)
+
+/*S3AccessLogEntry is the part of a line of an S3 server access log the node uses, see
+https://docs.aws.amazon.com/AmazonS3/latest/userguide/LogFormat.html*/
+type S3AccessLogEntry struct {
+	Time time.Time
+	/*Requester is the canonical user ID or IAM ARN the request was signed by, "-" for anonymous requests*/
+	Requester string
+	Operation string
+	Key       string
+	Status    int
+	BytesSent int64
+}
+
+/*ParseS3AccessLogLine parses a line of an S3 server access log*/
+func ParseS3AccessLogLine(line string) (S3AccessLogEntry, error) {
+	fields := splitS3AccessLogLine(line)
+	if len(fields) <= s3AccessLogBytesField {
+		return S3AccessLogEntry{}, errors.New("access log line has too few fields")
+	}
+
+	entry := S3AccessLogEntry{Requester: fields[s3AccessLogRequesterField], Operation: fields[s3AccessLogOperationField]}
+	var err error
+	if entry.Time, err = time.Parse(s3AccessLogTimeLayout, fields[s3AccessLogTimeField]); err != nil {
+		return S3AccessLogEntry{}, err
+	}
+	if entry.Key, err = url.PathUnescape(fields[s3AccessLogKeyField]); err != nil {
+		entry.Key = fields[s3AccessLogKeyField]
+	}
+	if entry.Status, err = strconv.Atoi(fields[s3AccessLogStatusField]); err != nil {
+		return S3AccessLogEntry{}, err
+	}
+	// "-" when nothing was sent
+	if bytesSent := fields[s3AccessLogBytesField]; bytesSent != "-" {
+		if entry.BytesSent, err = strconv.ParseInt(bytesSent, 10, 64); err != nil {
+			return S3AccessLogEntry{}, err
+		}
+	}
+	return entry, nil
+}
+
+/*IsObjectDownload returns whether the entry is a successful read of (part of) an object*/
+func (entry S3AccessLogEntry) IsObjectDownload() bool {
+	return entry.Operation == "REST.GET.OBJECT" && entry.BytesSent > 0 &&
+		(entry.Status == http.StatusOK || entry.Status == http.StatusPartialContent)
+}
+
+/*splitS3AccessLogLine splits the line on spaces, keeping the [time] and "quoted" fields whole without
+their delimiters*/
+func splitS3AccessLogLine(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for len(line) > 0 {
+		end := ' '
+		switch line[0] {
+		case '[':
+			end = ']'
+			line = line[1:]
+		case '"':
+			end = '"'
+			line = line[1:]
+		}
+		i := strings.IndexRune(line, end)
+		if i < 0 {
+			fields = append(fields, line)
+			break
+		}
+		fields = append(fields, line[:i])
+		line = strings.TrimLeft(line[i+1:], " ")
+	}
+	return fields
+}
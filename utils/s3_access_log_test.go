@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const s3AccessLogLineForTest = `79a59df900b949e55d96a1e698fbacedfd6e09d98eacf8f8d5218e7cd47ef2be awsexamplebucket1 ` +
+	`[06/Feb/2019:00:00:38 +0000] 192.0.2.3 - 3E57427F3EXAMPLE REST.GET.OBJECT abc123/file ` +
+	`"GET /abc123/file?X-Amz-Signature=x HTTP/1.1" 206 - 5000 20000 70 10 "-" "S3Console/0.4" - ` +
+	`s9lzHYrFp76ZVxRcpX9+5cjAnEH2ROuNkd2BHfIa6UkFVdtjf5mKR3/eTPFvsiP/XV/VLi31234= SigV4 ` +
+	`ECDHE-RSA-AES128-GCM-SHA256 QueryString awsexamplebucket1.s3.us-west-1.amazonaws.com TLSV1.2 - -`
+
+func Test_ParseS3AccessLogLine(t *testing.T) {
+	entry, err := ParseS3AccessLogLine(s3AccessLogLineForTest)
+	assert.Nil(t, err)
+	assert.Equal(t, "-", entry.Requester)
+	assert.Equal(t, "REST.GET.OBJECT", entry.Operation)
+	assert.Equal(t, "abc123/file", entry.Key)
+	assert.Equal(t, 206, entry.Status)
+	assert.Equal(t, int64(5000), entry.BytesSent)
+	assert.True(t, entry.Time.Equal(time.Date(2019, time.February, 6, 0, 0, 38, 0, time.UTC)))
+	assert.True(t, entry.IsObjectDownload())
+}
+
+func Test_ParseS3AccessLogLine_Not_A_Download(t *testing.T) {
+	entry, err := ParseS3AccessLogLine(`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 - ID REST.HEAD.OBJECT ` +
+		`abc123/file "HEAD /abc123/file HTTP/1.1" 200 - - 20000 10 10 "-" "agent" -`)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), entry.BytesSent)
+	assert.False(t, entry.IsObjectDownload())
+
+	entry, err = ParseS3AccessLogLine(`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 - ID REST.GET.OBJECT ` +
+		`abc123/file "GET /abc123/file HTTP/1.1" 403 AccessDenied 243 - 10 - "-" "agent" -`)
+	assert.Nil(t, err)
+	assert.False(t, entry.IsObjectDownload())
+}
+
+func Test_ParseS3AccessLogLine_Malformed(t *testing.T) {
+	_, err := ParseS3AccessLogLine("not an access log line")
+	assert.NotNil(t, err)
+
+	_, err = ParseS3AccessLogLine(`owner bucket [yesterday] 192.0.2.3 - ID REST.GET.OBJECT ` +
+		`abc123/file "GET /abc123/file HTTP/1.1" 200 - 10 10 10 10 "-" "agent" -`)
+	assert.NotNil(t, err)
+}
+
+func Test_ParseS3AccessLogLine_Requester(t *testing.T) {
+	entry, err := ParseS3AccessLogLine(`owner bucket [06/Feb/2019:00:00:38 +0000] 192.0.2.3 ` +
+		`arn:aws:iam::123456789012:user/storage-node ID REST.GET.OBJECT abc123/file "GET /abc123/file HTTP/1.1" ` +
+		`200 - 10 10 10 10 "-" "agent" -`)
+	assert.Nil(t, err)
+	assert.Equal(t, "arn:aws:iam::123456789012:user/storage-node", entry.Requester)
+	assert.Equal(t, "abc123/file", entry.Key)
+}
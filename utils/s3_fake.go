@@ -0,0 +1,381 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+/*S3Fake is an in-memory BlobStore that behaves like the S3 bucket behind s3Wrapper.
+SetTesting installs one so the test suite doesn't need a real bucket.*/
+type S3Fake struct {
+	mu        sync.RWMutex
+	objects   map[string]*fakeS3Object
+	uploads   map[string]*fakeS3Upload
+	lifecycle []*s3.LifecycleRule
+}
+
+// Shared by every SetTesting call so objects survive between the test files of a package
+var testS3Fake = NewS3Fake()
+
+type fakeS3Object struct {
+	data         []byte
+	contentType  string
+	etag         string
+	cannedAcl    string
+	lastModified time.Time
+}
+
+type fakeS3Upload struct {
+	key         string
+	contentType string
+	initiated   time.Time
+	parts       map[int64][]byte
+}
+
+/*NewS3Fake returns an empty in-memory bucket*/
+func NewS3Fake() *S3Fake {
+	return &S3Fake{
+		objects: make(map[string]*fakeS3Object),
+		uploads: make(map[string]*fakeS3Upload),
+	}
+}
+
+func fakeETag(data []byte) string {
+	sum := md5.Sum(data)
+	return strconv.Quote(hex.EncodeToString(sum[:]))
+}
+
+func (f *S3Fake) HeadObject(key string) (*s3.HeadObjectOutput, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	obj, ok := f.objects[key]
+	if !ok {
+		return nil, blobNotFoundError("NotFound", key)
+	}
+
+	return &s3.HeadObjectOutput{
+		AcceptRanges:  aws.String("bytes"),
+		ContentLength: aws.Int64(int64(len(obj.data))),
+		ContentType:   aws.String(obj.contentType),
+		ETag:          aws.String(obj.etag),
+		LastModified:  aws.Time(obj.lastModified),
+	}, nil
+}
+
+func (f *S3Fake) GetObject(key, downloadRange string) (*s3.GetObjectOutput, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	obj, ok := f.objects[key]
+	if !ok {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchKey, key)
+	}
+
+	data := obj.data
+	output := &s3.GetObjectOutput{
+		AcceptRanges: aws.String("bytes"),
+		ContentType:  aws.String(obj.contentType),
+		ETag:         aws.String(obj.etag),
+		LastModified: aws.Time(obj.lastModified),
+	}
+	if downloadRange != "" {
+		start, end, err := parseByteRange(downloadRange, int64(len(obj.data)))
+		if err != nil {
+			return nil, err
+		}
+		data = obj.data[start : end+1]
+		output.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(obj.data)))
+	}
+	output.Body = ioutil.NopCloser(bytes.NewReader(data))
+	output.ContentLength = aws.Int64(int64(len(data)))
+
+	return output, nil
+}
+
+func (f *S3Fake) PutObject(key string, body io.ReadSeeker, contentType string) error {
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.objects[key] = &fakeS3Object{
+		data:         data,
+		contentType:  contentType,
+		etag:         fakeETag(data),
+		cannedAcl:    CannedAcl_Private,
+		lastModified: time.Now(),
+	}
+	return nil
+}
+
+func (f *S3Fake) DeleteObject(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.objects, key)
+	return nil
+}
+
+func (f *S3Fake) DeleteObjects(keys []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, key := range keys {
+		delete(f.objects, key)
+	}
+	return nil
+}
+
+func (f *S3Fake) ListObjectPages(prefix string, it ObjectIterator) error {
+	f.mu.RLock()
+	var objects []*s3.Object
+	for key, obj := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, &s3.Object{
+				Key:          aws.String(key),
+				Size:         aws.Int64(int64(len(obj.data))),
+				ETag:         aws.String(obj.etag),
+				LastModified: aws.Time(obj.lastModified),
+			})
+		}
+	}
+	f.mu.RUnlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return aws.StringValue(objects[i].Key) < aws.StringValue(objects[j].Key)
+	})
+
+	if len(objects) == 0 {
+		it(objects)
+		return nil
+	}
+	for low := 0; low < len(objects); low += int(awsPagingSize) {
+		high := low + int(awsPagingSize)
+		if high > len(objects) {
+			high = len(objects)
+		}
+		if !it(objects[low:high]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *S3Fake) SetObjectCannedAcl(key, cannedAcl string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[key]
+	if !ok {
+		return blobNotFoundError(s3.ErrCodeNoSuchKey, key)
+	}
+	obj.cannedAcl = cannedAcl
+	return nil
+}
+
+/*GetObjectCannedAcl returns the canned ACL last set on an object*/
+func (f *S3Fake) GetObjectCannedAcl(key string) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	obj, ok := f.objects[key]
+	if !ok {
+		return "", blobNotFoundError(s3.ErrCodeNoSuchKey, key)
+	}
+	return obj.cannedAcl, nil
+}
+
+func (f *S3Fake) StartMultipartUpload(key, fileType string) (*s3.CreateMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	uploadID := RandHexString(localUploadIDSize)
+	f.uploads[uploadID] = &fakeS3Upload{
+		key:         key,
+		contentType: fileType,
+		initiated:   time.Now(),
+		parts:       make(map[int64][]byte),
+	}
+
+	return &s3.CreateMultipartUploadOutput{
+		Bucket:   aws.String(Env.BucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}, nil
+}
+
+func (f *S3Fake) UploadPartOfMultiPartUpload(key, uploadID string, body io.ReadSeeker, size int64,
+	partNumber int, contentMD5 string) (*s3.CompletedPart, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(body, size))
+	if err != nil {
+		return nil, err
+	}
+	if sum := md5.Sum(data); contentMD5 != "" && contentMD5 != ContentMD5(sum[:]) {
+		return nil, badDigestError()
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	upload, ok := f.uploads[uploadID]
+	if !ok || upload.key != key {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	if partNumber < 1 || partNumber > 10000 {
+		return nil, awserr.NewRequestFailure(awserr.New("InvalidArgument", "part number must be between 1 and 10000", nil),
+			http.StatusBadRequest, "")
+	}
+
+	upload.parts[int64(partNumber)] = data
+
+	return &s3.CompletedPart{
+		ETag:       aws.String(fakeETag(data)),
+		PartNumber: aws.Int64(int64(partNumber)),
+	}, nil
+}
+
+func (f *S3Fake) FinishMultipartUpload(key, uploadID string,
+	completedParts []*s3.CompletedPart) (*s3.CompleteMultipartUploadOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	upload, ok := f.uploads[uploadID]
+	if !ok || upload.key != key {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	if len(completedParts) == 0 {
+		return nil, awserr.NewRequestFailure(awserr.New("MalformedXML", "no parts specified", nil), http.StatusBadRequest, "")
+	}
+
+	var data []byte
+	etags := md5.New()
+	var previous int64
+	for i, part := range completedParts {
+		partNumber := aws.Int64Value(part.PartNumber)
+		if partNumber <= previous {
+			return nil, awserr.NewRequestFailure(awserr.New("InvalidPartOrder", "parts must be in ascending order", nil),
+				http.StatusBadRequest, "")
+		}
+		previous = partNumber
+
+		partData, ok := upload.parts[partNumber]
+		if !ok || fakeETag(partData) != aws.StringValue(part.ETag) {
+			return nil, awserr.NewRequestFailure(awserr.New("InvalidPart", fmt.Sprintf("part %d could not be found", partNumber), nil),
+				http.StatusBadRequest, "")
+		}
+		if i < len(completedParts)-1 && int64(len(partData)) < MinMultiPartSize {
+			return nil, awserr.NewRequestFailure(awserr.New("EntityTooSmall", fmt.Sprintf("part %d is smaller than the minimum allowed size", partNumber), nil),
+				http.StatusBadRequest, "")
+		}
+		sum := md5.Sum(partData)
+		etags.Write(sum[:])
+		data = append(data, partData...)
+	}
+
+	etag := strconv.Quote(fmt.Sprintf("%s-%d", hex.EncodeToString(etags.Sum(nil)), len(completedParts)))
+	f.objects[key] = &fakeS3Object{
+		data:         data,
+		contentType:  upload.contentType,
+		etag:         etag,
+		cannedAcl:    CannedAcl_Private,
+		lastModified: time.Now(),
+	}
+	delete(f.uploads, uploadID)
+
+	return &s3.CompleteMultipartUploadOutput{
+		Bucket:   aws.String(Env.BucketName),
+		Key:      aws.String(key),
+		ETag:     aws.String(etag),
+		Location: aws.String(GetBlobStoreBaseURL() + key),
+	}, nil
+}
+
+func (f *S3Fake) CancelMultipartUpload(key, uploadID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if upload, ok := f.uploads[uploadID]; !ok || upload.key != key {
+		return blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	delete(f.uploads, uploadID)
+	return nil
+}
+
+func (f *S3Fake) ListParts(key, uploadID string) ([]*s3.Part, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	upload, ok := f.uploads[uploadID]
+	if !ok || upload.key != key {
+		return nil, blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+
+	parts := []*s3.Part{}
+	for partNumber, data := range upload.parts {
+		parts = append(parts, &s3.Part{
+			ETag:       aws.String(fakeETag(data)),
+			PartNumber: aws.Int64(partNumber),
+			Size:       aws.Int64(int64(len(data))),
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return aws.Int64Value(parts[i].PartNumber) < aws.Int64Value(parts[j].PartNumber)
+	})
+	return parts, nil
+}
+
+/*PresignUploadPart returns a URL shaped like the one S3 would sign, nothing listens on it*/
+func (f *S3Fake) PresignUploadPart(key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if upload, ok := f.uploads[uploadID]; !ok || upload.key != key {
+		return "", blobNotFoundError(s3.ErrCodeNoSuchUpload, uploadID)
+	}
+	return fmt.Sprintf("%s%s?partNumber=%d&uploadId=%s&X-Amz-Expires=%d", GetBlobStoreBaseURL(), key, partNumber,
+		uploadID, int64(expires/time.Second)), nil
+}
+
+/*PresignGetObject returns a URL shaped like the one S3 would sign, nothing listens on it*/
+func (f *S3Fake) PresignGetObject(key string, expires time.Duration) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if _, ok := f.objects[key]; !ok {
+		return "", blobNotFoundError(s3.ErrCodeNoSuchKey, key)
+	}
+	return fmt.Sprintf("%s%s?X-Amz-Expires=%d", GetBlobStoreBaseURL(), key, int64(expires/time.Second)), nil
+}
+
+func (f *S3Fake) PutBucketLifecycleConfiguration(rules []*s3.LifecycleRule) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lifecycle = append([]*s3.LifecycleRule(nil), rules...)
+	return nil
+}
+
+func (f *S3Fake) GetBucketLifecycleConfiguration() ([]*s3.LifecycleRule, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return append([]*s3.LifecycleRule(nil), f.lifecycle...), nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_S3Fake_Canned_Acl(t *testing.T) {
+	fake := NewS3Fake()
+
+	assert.Nil(t, fake.PutObject("acl/file", strings.NewReader("data"), DefaultFileContentType))
+	acl, err := fake.GetObjectCannedAcl("acl/file")
+	assert.Nil(t, err)
+	assert.Equal(t, CannedAcl_Private, acl)
+
+	assert.Nil(t, fake.SetObjectCannedAcl("acl/file", CannedAcl_PublicRead))
+	acl, err = fake.GetObjectCannedAcl("acl/file")
+	assert.Nil(t, err)
+	assert.Equal(t, CannedAcl_PublicRead, acl)
+
+	assert.NotNil(t, fake.SetObjectCannedAcl("acl/missing", CannedAcl_PublicRead))
+}
+
+func Test_S3Fake_Range_And_Lifecycle(t *testing.T) {
+	fake := NewS3Fake()
+
+	assert.Nil(t, fake.PutObject("range", strings.NewReader("0123456789"), DefaultFileContentType))
+	output, err := fake.GetObject("range", "bytes=2-4")
+	assert.Nil(t, err)
+	data, _ := ioutil.ReadAll(output.Body)
+	assert.Equal(t, "234", string(data))
+	assert.Equal(t, "bytes 2-4/10", aws.StringValue(output.ContentRange))
+
+	rules, err := fake.GetBucketLifecycleConfiguration()
+	assert.Nil(t, err)
+	assert.Empty(t, rules)
+
+	assert.Nil(t, fake.PutBucketLifecycleConfiguration([]*s3.LifecycleRule{{ID: aws.String("rule")}}))
+	rules, err = fake.GetBucketLifecycleConfiguration()
+	assert.Nil(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "rule", aws.StringValue(rules[0].ID))
+}
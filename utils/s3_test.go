@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"os"
@@ -74,13 +75,8 @@ func multipartUploadAbortionTest(t *testing.T) {
 /*startUploadForTest starts a multipart upload for unit tests and returns the aws object key, upload id,
 buffer, and file size*/
 func startUploadForTest(t *testing.T) (string, string, []byte, int64) {
-	file, err := os.Open(localFilePath)
-	assert.Nil(t, err)
-	defer file.Close()
-	fileInfo, _ := file.Stat()
-	size := fileInfo.Size()
-	buffer := make([]byte, size)
-	file.Read(buffer)
+	buffer := []byte(strings.Repeat("Lorem ipsum dolor sit amet. ", int(MinMultiPartSize)/10))
+	size := int64(len(buffer))
 
 	awsKey := awsTestDirPath + fileName
 
@@ -105,9 +101,9 @@ func successfulMultipartUploadForTest(t *testing.T) {
 		} else {
 			partLength = MinMultiPartSize
 		}
-		completedPart, uploadPartErr := svc.UploadPartOfMultiPartUpload(key, uploadID, buffer[curr:curr+partLength], partNumber)
+		completedPart, uploadPartErr := blobStore.UploadPartOfMultiPartUpload(key, uploadID, bytes.NewReader(buffer[curr:curr+partLength]), partLength, partNumber, "")
 		if uploadPartErr != nil {
-			cancelErr := svc.CancelMultipartUpload(key, uploadID)
+			cancelErr := blobStore.CancelMultipartUpload(key, uploadID)
 			assert.Nil(t, CollectErrors([]error{uploadPartErr, cancelErr}))
 		}
 		remaining -= partLength
@@ -115,7 +111,7 @@ func successfulMultipartUploadForTest(t *testing.T) {
 		completedParts = append(completedParts, completedPart)
 	}
 
-	_, err := svc.FinishMultipartUpload(key, uploadID, completedParts)
+	_, err := blobStore.FinishMultipartUpload(key, uploadID, completedParts)
 	assert.Nil(t, err)
 }
 
@@ -129,43 +125,28 @@ func failedMultipartUploadForTest(t *testing.T) {
 	curr = 0
 	partLength = size
 
-	_, uploadPartErr := svc.UploadPartOfMultiPartUpload(key, uploadID, buffer[curr:curr+partLength], partNumber)
+	_, uploadPartErr := blobStore.UploadPartOfMultiPartUpload(key, uploadID, bytes.NewReader(buffer[curr:curr+partLength]), partLength, partNumber, "")
 	assert.Nil(t, uploadPartErr)
-	cancelErr := svc.CancelMultipartUpload(key, uploadID)
+	cancelErr := blobStore.CancelMultipartUpload(key, uploadID)
 	assert.Nil(t, cancelErr)
 }
 
 /*verifyFileIsNotOnS3 checks that the file is not on S3*/
 func verifyFileIsNotOnS3(keyOnAws string, t *testing.T) {
-	newS3Session()
-
-	_, errGetObject := svc.s3.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(Env.BucketName),
-		Key:    aws.String(keyOnAws),
-	})
+	_, errGetObject := blobStore.GetObject(keyOnAws, "")
 	assert.NotNil(t, errGetObject)
 	assert.Contains(t, errGetObject.Error(), s3.ErrCodeNoSuchKey)
 }
 
 /*verifyFileIsOnS3 checks that the file is on S3*/
 func verifyFileIsOnS3(keyOnAws string, t *testing.T) {
-	newS3Session()
-
-	_, errGetObject := svc.s3.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(Env.BucketName),
-		Key:    aws.String(keyOnAws),
-	})
+	_, errGetObject := blobStore.GetObject(keyOnAws, "")
 	assert.Nil(t, errGetObject)
 }
 
 /*removeFileFromS3 removes the file from S3 and then verifies it is no longer on S3.*/
 func removeFileFromS3(keyOnAws string, t *testing.T) {
-	newS3Session()
-
-	_, err := svc.s3.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: aws.String(Env.BucketName),
-		Key:    aws.String(keyOnAws),
-	})
+	err := blobStore.DeleteObject(keyOnAws)
 	assert.Nil(t, err)
 	verifyFileIsNotOnS3(keyOnAws, t)
 }